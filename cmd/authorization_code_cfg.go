@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"flag"
+	"slices"
+	"strings"
 
 	"github.com/jentz/oidc-cli/httpclient"
 	"github.com/jentz/oidc-cli/oidc"
@@ -20,6 +22,36 @@ func (c *CustomArgsFlag) Set(value string) error {
 	return nil
 }
 
+// validResponseModes are the response_mode values the callback server can
+// receive; the empty string leaves the provider's default in place.
+var validResponseModes = map[string]bool{
+	"":              true,
+	"query":         true,
+	"fragment":      true,
+	"form_post":     true,
+	"jwt":           true,
+	"query.jwt":     true,
+	"fragment.jwt":  true,
+	"form_post.jwt": true,
+}
+
+// validResponseType reports whether rt is a space separated combination of
+// code, id_token and token, each at most once; empty means code.
+func validResponseType(rt string) bool {
+	if rt == "" {
+		return true
+	}
+	parts := strings.Fields(rt)
+	seen := map[string]bool{}
+	for _, part := range parts {
+		if part != "code" && part != "id_token" && part != "token" || seen[part] {
+			return false
+		}
+		seen[part] = true
+	}
+	return len(parts) > 0
+}
+
 func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output string, err error) {
 	oidcConf := in.Conf
 	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
@@ -30,6 +62,7 @@ func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output st
 	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
 	flags.StringVar(&oidcConf.OIDC.AuthorizationEndpoint, "authorization-url", "", "override authorization url")
 	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.JWKSEndpoint, "jwks-url", "", "override jwks url")
 	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
 	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (required if not using PKCE)")
 	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
@@ -37,9 +70,17 @@ func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output st
 	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
 
 	var flowConf oidc.AuthorizationCodeFlowConfig
+	flags.StringVar(&flowConf.ResponseType, "response-type", "", "set response_type parameter (default: code), eg. \"id_token token\" or \"code id_token\"")
+	flags.StringVar(&flowConf.Nonce, "nonce", "", "set nonce parameter (generated when an id_token is requested)")
 	flags.StringVar(&flowConf.Scope, "scope", "openid", "set scope as a space separated list")
+	flags.Var((*ResourceFlag)(&flowConf.Resources), "resource", resourceUsage)
 	flags.StringVar(&flowConf.CallbackURI, "callback-uri", "http://localhost:9555/callback",
-		"set callback uri (default: http://localhost:9555/callback), this will also be used as the redirect_uri in the authorization request unless overridden by -redirect-uri")
+		"set callback uri (default: http://localhost:9555/callback), this will also be used as the redirect_uri in the authorization request unless overridden by -redirect-uri, port 0 listens on any free port")
+	flags.Var((*PortRangeFlag)(&flowConf.CallbackPorts), "callback-port-range", callbackPortRangeUsage)
+	var callbackTLS oidc.CallbackTLSConfig
+	flags.StringVar(&callbackTLS.CertFile, "callback-tls-cert", "", "serve an https callback-uri with the PEM certificate in this file (default: a self-signed certificate generated for the run)")
+	flags.StringVar(&callbackTLS.KeyFile, "callback-tls-key", "", "file to read the private key of callback-tls-cert from")
+	flags.BoolVar(&callbackTLS.Write, "callback-tls-write", false, "generate a self-signed certificate into callback-tls-cert and callback-tls-key unless they exist, to trust it once and reuse it")
 	flags.StringVar(&flowConf.RedirectURI, "redirect-uri", "", "set the redirect_uri parameter")
 	flags.StringVar(&flowConf.Prompt, "prompt", "", "set prompt parameter to login, consent, select_account, or none")
 	flags.StringVar(&flowConf.AcrValues, "acr-values", "", "set acr_values parameter")
@@ -52,6 +93,22 @@ func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output st
 	flags.BoolVar(&flowConf.PKCE, "pkce", false, "use proof-key for code exchange (PKCE)")
 	flags.BoolVar(&flowConf.PAR, "par", false, "use pushed authorization requests")
 	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
+	var requestObject oidc.RequestObjectConfig
+	flags.StringVar(&requestObject.KeyFile, "request-object-key", "", "sign the authorization request as a request object (JAR) with the PEM or JWK private key in this file")
+	flags.StringVar(&requestObject.KeyID, "request-object-kid", "", "override the kid header of the request object")
+	flags.BoolVar(&requestObject.Encrypt, "encrypt-request-object", false, "encrypt the request object to the provider's encryption key from its JWKS")
+	flags.StringVar(&flowConf.RequestURI, "request-uri", "", "pass a request object hosted at this uri by reference")
+	var authorizationDetails string
+	flags.StringVar(&authorizationDetails, "authorization-details", "", authorizationDetailsUsage)
+	var claims ClaimFlag
+	flags.Var(&claims, "claim", claimUsage)
+	flags.StringVar(&flowConf.ResponseMode, "response-mode", "", "set response_mode parameter to query, fragment, form_post, jwt, query.jwt, fragment.jwt, or form_post.jwt")
+	flags.StringVar(&flowConf.ResponseDecryptionKeyFile, "response-decryption-key", "", "decrypt an encrypted response JWT with the PEM or JWK private key in this file")
+	flags.BoolVar(&flowConf.NoBrowser, "no-browser", false, "print the authorization URL instead of opening it, then read the redirect URL the browser fails to load (or just the code) from stdin")
+	var stepUp oidc.StepUpConfig
+	flags.StringVar(&stepUp.Challenge, "step-up-challenge", "", "answer this insufficient_user_authentication WWW-Authenticate challenge by logging in again, or '-' to read it from stdin")
+	flags.StringVar(&stepUp.URL, "step-up-url", "", "call this resource with step-up-access-token for its step-up challenge, and again with the new access token")
+	flags.StringVar(&stepUp.AccessToken, "step-up-access-token", "", "access token the step-up-url rejects, or '-' to read it from stdin")
 
 	runner = &oidc.AuthorizationCodeFlow{
 		Config:     oidcConf,
@@ -75,6 +132,50 @@ func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output st
 		}
 	}
 
+	flowConf.AuthorizationDetails, err = readAuthorizationDetails(authorizationDetails)
+	if err != nil {
+		return nil, err.Error(), errors.New("invalid arguments: " + err.Error())
+	}
+	flowConf.Claims, err = buildClaimsRequest(claims)
+	if err != nil {
+		return nil, err.Error(), errors.New("invalid arguments: " + err.Error())
+	}
+
+	if requestObject.KeyFile != "" {
+		flowConf.RequestObject = &requestObject
+	}
+	if callbackTLS.CertFile != "" || callbackTLS.KeyFile != "" || callbackTLS.Write {
+		flowConf.CallbackTLS = &callbackTLS
+	}
+	if flowConf.NoBrowser {
+		// The redirect URL is read from stdin once the flow runs
+		if stepUp.Challenge == "-" || stepUp.AccessToken == "-" {
+			msg := "no-browser reads the redirect URL from stdin, so step-up values cannot be read from it too"
+			return nil, msg, errors.New("invalid arguments: " + msg)
+		}
+		flowConf.Stdin = in.Stdin
+	}
+	for _, stdinValue := range []struct {
+		value *string
+		label string
+	}{
+		{&stepUp.Challenge, "step-up challenge"},
+		{&stepUp.AccessToken, "access token"},
+	} {
+		if *stdinValue.value == "-" {
+			value, err := readTokenFromStdin(in.Stdin, stdinValue.label)
+			if err != nil {
+				return nil, buf.String(), err
+			}
+			*stdinValue.value = value
+		}
+	}
+	if stepUp.Challenge != "" || stepUp.URL != "" {
+		flowConf.StepUp = &stepUp
+	}
+	// The implicit response types redeem no code, so need no client secret
+	returnsCode := flowConf.ResponseType == "" || slices.Contains(strings.Fields(flowConf.ResponseType), "code")
+
 	var invalidArgsChecks = []struct {
 		condition bool
 		message   string
@@ -88,7 +189,11 @@ func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output st
 			"client-id is required",
 		},
 		{
-			oidcConf.OIDC.ClientSecret == "" && !flowConf.PKCE,
+			!validResponseType(flowConf.ResponseType),
+			"response-type must be a combination of code, id_token and token",
+		},
+		{
+			oidcConf.OIDC.ClientSecret == "" && !flowConf.PKCE && returnsCode,
 			"client-secret is required unless using PKCE",
 		},
 		{
@@ -99,10 +204,54 @@ func parseAuthorizationCodeFlags(in ParseInput) (runner CommandRunner, output st
 			flowConf.CallbackURI == "",
 			"callback-uri is required",
 		},
+		{
+			(requestObject.KeyID != "" || requestObject.Encrypt) && requestObject.KeyFile == "",
+			"request-object-key is required to set request-object-kid or encrypt-request-object",
+		},
+		{
+			flowConf.RequestURI != "" && (flowConf.PAR || requestObject.KeyFile != ""),
+			"request-uri cannot be combined with par or request-object-key",
+		},
+		{
+			!validResponseModes[flowConf.ResponseMode],
+			"response-mode must be query, fragment, form_post, jwt, query.jwt, fragment.jwt, or form_post.jwt",
+		},
+		{
+			strings.HasPrefix(flowConf.ResponseMode, "query") && flowConf.ResponseType != "" && flowConf.ResponseType != "code",
+			"response-mode query cannot return tokens, use fragment or form_post",
+		},
+		{
+			flowConf.ResponseDecryptionKeyFile != "" && !strings.HasSuffix(flowConf.ResponseMode, "jwt"),
+			"response-decryption-key requires a jwt response-mode",
+		},
 		{
 			flowConf.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
 			"both dpop-private-key and dpop-public-key are required when using DPoP",
 		},
+		{
+			stepUp.Challenge != "" && stepUp.URL != "",
+			"step-up-challenge cannot be combined with step-up-url",
+		},
+		{
+			(stepUp.URL != "") != (stepUp.AccessToken != ""),
+			"step-up-url and step-up-access-token must be given together",
+		},
+		{
+			(callbackTLS.CertFile == "") != (callbackTLS.KeyFile == "") || (callbackTLS.Write && callbackTLS.CertFile == ""),
+			"callback-tls-cert and callback-tls-key must be given together, and are required by callback-tls-write",
+		},
+		{
+			flowConf.CallbackTLS != nil && !strings.HasPrefix(flowConf.CallbackURI, "https://"),
+			"callback-tls-cert, callback-tls-key and callback-tls-write require an https callback-uri",
+		},
+		{
+			flowConf.NoBrowser && (len(flowConf.CallbackPorts) > 0 || anyCallbackPort(flowConf.CallbackURI)),
+			"no-browser starts no callback server, so callback-uri needs a fixed port",
+		},
+		{
+			flowConf.NoBrowser && strings.HasPrefix(flowConf.ResponseMode, "form_post"),
+			"no-browser cannot receive a form_post response-mode, which is never shown in the address bar",
+		},
 	}
 
 	for _, check := range invalidArgsChecks {
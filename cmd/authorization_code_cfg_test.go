@@ -11,6 +11,9 @@ import (
 
 func TestParseAuthorizationCodeFlagsResult(t *testing.T) {
 	t.Parallel()
+
+	// Stdin is only kept for --no-browser, which reads the redirect from it
+	noBrowserStdin := strings.NewReader("")
 	var tests = []struct {
 		name     string
 		args     []string
@@ -239,6 +242,242 @@ func TestParseAuthorizationCodeFlagsResult(t *testing.T) {
 				DPoP:        true,
 			},
 		},
+		{
+			"request object",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--request-object-key", "path/to/client-key.pem",
+				"--request-object-kid", "client-key",
+				"--encrypt-request-object",
+				"--par",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:       "openid",
+				CallbackURI: "http://localhost:9555/callback",
+				PAR:         true,
+				RequestObject: &oidc.RequestObjectConfig{
+					KeyFile: "path/to/client-key.pem",
+					KeyID:   "client-key",
+					Encrypt: true,
+				},
+			},
+		},
+		{
+			"request uri",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--request-uri", "https://rp.example.com/request.jwt",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:       "openid",
+				CallbackURI: "http://localhost:9555/callback",
+				RequestURI:  "https://rp.example.com/request.jwt",
+			},
+		},
+		{
+			"implicit response type without client-secret",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--response-type", "id_token token",
+				"--nonce", "nonce-123",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				ResponseType: "id_token token",
+				Nonce:        "nonce-123",
+				Scope:        "openid",
+				CallbackURI:  "http://localhost:9555/callback",
+			},
+		},
+		{
+			"form_post response mode",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--response-mode", "form_post",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:        "openid",
+				CallbackURI:  "http://localhost:9555/callback",
+				ResponseMode: "form_post",
+			},
+		},
+		{
+			"jarm response mode",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--jwks-url", "https://example.com/jwks",
+				"--response-mode", "form_post.jwt",
+				"--response-decryption-key", "path/to/client-key.pem",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					JWKSEndpoint: "https://example.com/jwks",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:                     "openid",
+				CallbackURI:               "http://localhost:9555/callback",
+				ResponseMode:              "form_post.jwt",
+				ResponseDecryptionKeyFile: "path/to/client-key.pem",
+			},
+		},
+		{
+			"claims request, authorization details and resources",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--claim", "userinfo.email=essential",
+				"--claim", "id_token.acr=value:urn:mace:incommon:iap:silver",
+				"--authorization-details", `[{"type":"account_information"}]`,
+				"--resource", "https://api.example.com",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:                "openid",
+				CallbackURI:          "http://localhost:9555/callback",
+				Claims:               `{"id_token":{"acr":{"value":"urn:mace:incommon:iap:silver"}},"userinfo":{"email":{"essential":true}}}`,
+				AuthorizationDetails: `[{"type":"account_information"}]`,
+				Resources:            []string{"https://api.example.com"},
+			},
+		},
+		{
+			"step-up from a resource",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--step-up-url", "https://api.example.com/transfers",
+				"--step-up-access-token", "access-token",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:       "openid",
+				CallbackURI: "http://localhost:9555/callback",
+				StepUp: &oidc.StepUpConfig{
+					URL:         "https://api.example.com/transfers",
+					AccessToken: "access-token",
+				},
+			},
+		},
+		{
+			"no browser",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--pkce",
+				"--no-browser",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:       "openid",
+				CallbackURI: "http://localhost:9555/callback",
+				PKCE:        true,
+				NoBrowser:   true,
+				Stdin:       noBrowserStdin,
+			},
+		},
+		{
+			"any callback port",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-uri", "http://127.0.0.1:0/callback",
+				"--callback-port-range", "9556-9558",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:         "openid",
+				CallbackURI:   "http://127.0.0.1:0/callback",
+				CallbackPorts: []int{9556, 9557, 9558},
+			},
+		},
+		{
+			"https callback with a written certificate",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-uri", "https://localhost:9555/callback",
+				"--callback-tls-cert", "callback.pem",
+				"--callback-tls-key", "callback-key.pem",
+				"--callback-tls-write",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.AuthorizationCodeFlowConfig{
+				Scope:       "openid",
+				CallbackURI: "https://localhost:9555/callback",
+				CallbackTLS: &oidc.CallbackTLSConfig{CertFile: "callback.pem", KeyFile: "callback-key.pem", Write: true},
+			},
+		},
 		{
 			"flags after non-flag argument",
 			[]string{
@@ -272,7 +511,7 @@ func TestParseAuthorizationCodeFlagsResult(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			runner, output, err := parseAuthorizationCodeFlags(ParseInput{Name: "authorization_code", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			runner, output, err := parseAuthorizationCodeFlags(ParseInput{Name: "authorization_code", Args: tt.args, Conf: &oidc.Config{}, Stdin: noBrowserStdin})
 			if err != nil {
 				t.Errorf("err got %v, want nil", err)
 			}
@@ -336,6 +575,35 @@ func TestParseAuthorizationCodeFlagsError(t *testing.T) {
 				"--callback-uri", "http://localhost:8080/callback",
 			},
 		},
+		{
+			"claim for an unknown target",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--claim", "access_token.email",
+			},
+		},
+		{
+			"step-up challenge and url",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--step-up-challenge", `Bearer error="insufficient_user_authentication", max_age=0`,
+				"--step-up-url", "https://api.example.com/transfers",
+				"--step-up-access-token", "access-token",
+			},
+		},
+		{
+			"step-up url without access token",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--step-up-url", "https://api.example.com/transfers",
+			},
+		},
 		{
 			"missing client-secret and pkce",
 			[]string{
@@ -345,6 +613,138 @@ func TestParseAuthorizationCodeFlagsError(t *testing.T) {
 				"--callback-uri", "http://localhost:8080/callback",
 			},
 		},
+		{
+			"encrypt-request-object without request-object-key",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--encrypt-request-object",
+			},
+		},
+		{
+			"request-uri with par",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--request-uri", "https://rp.example.com/request.jwt",
+				"--par",
+			},
+		},
+		{
+			"unsupported response-type",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--response-type", "code code",
+			},
+		},
+		{
+			"hybrid response-type without client-secret",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--response-type", "code id_token",
+			},
+		},
+		{
+			"tokens in the query",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--response-type", "id_token",
+				"--response-mode", "query",
+			},
+		},
+		{
+			"unsupported response-mode",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--response-mode", "form_post.json",
+			},
+		},
+		{
+			"response-decryption-key without a jwt response-mode",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--response-decryption-key", "path/to/client-key.pem",
+			},
+		},
+		{
+			"no-browser with a form_post response-mode",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--response-mode", "form_post",
+				"--no-browser",
+			},
+		},
+		{
+			"no-browser with any callback port",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-uri", "http://127.0.0.1:0/callback",
+				"--no-browser",
+			},
+		},
+		{
+			"callback-tls-cert without callback-tls-key",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-uri", "https://localhost:9555/callback",
+				"--callback-tls-cert", "callback.pem",
+			},
+		},
+		{
+			"callback-tls-write without files",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-uri", "https://localhost:9555/callback",
+				"--callback-tls-write",
+			},
+		},
+		{
+			"callback certificate for an http callback-uri",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-tls-cert", "callback.pem",
+				"--callback-tls-key", "callback-key.pem",
+			},
+		},
+		{
+			"invalid callback port range",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--callback-port-range", "9558-9556",
+			},
+		},
+		{
+			"no-browser with a step-up value from stdin",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--step-up-challenge", "-",
+				"--no-browser",
+			},
+		},
 		{
 			"missing private-key and dpop",
 			[]string{
@@ -382,3 +782,25 @@ func TestParseAuthorizationCodeFlagsError(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAuthorizationCodeFlagsStepUpStdin(t *testing.T) {
+	t.Parallel()
+	const challenge = `Bearer error="insufficient_user_authentication", acr_values="urn:example:mfa"`
+	args := []string{
+		"--issuer", "https://example.com",
+		"--client-id", "client-id",
+		"--client-secret", "client-secret",
+		"--step-up-challenge", "-",
+	}
+	runner, _, err := parseAuthorizationCodeFlags(ParseInput{Name: "authorization_code", Args: args, Conf: &oidc.Config{}, Stdin: strings.NewReader(challenge + "\n")})
+	if err != nil {
+		t.Fatalf("err got %v, want nil", err)
+	}
+	f, ok := runner.(*oidc.AuthorizationCodeFlow)
+	if !ok {
+		t.Fatalf("unexpected runner type: %T", runner)
+	}
+	if f.FlowConfig.StepUp == nil || f.FlowConfig.StepUp.Challenge != challenge {
+		t.Errorf("StepUp got %+v, want the challenge read from stdin", f.FlowConfig.StepUp)
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const authorizationDetailsUsage = "set authorization_details (RFC 9396) as a JSON array, or '@file' to read it from a file"
+
+// readAuthorizationDetails resolves an --authorization-details argument and
+// checks it is a JSON array of objects that each name their type (RFC 9396
+// §2), returning it compacted for sending as a form parameter.
+func readAuthorizationDetails(value string) (string, error) {
+	raw, err := readRequestBody(value)
+	if err != nil || raw == nil {
+		return "", err
+	}
+	var details []map[string]any
+	if err := json.Unmarshal(raw, &details); err != nil {
+		return "", fmt.Errorf("authorization-details must be a JSON array of objects: %w", err)
+	}
+	if len(details) == 0 {
+		return "", errors.New("authorization-details must not be empty")
+	}
+	for i, detail := range details {
+		if typ, ok := detail["type"].(string); !ok || typ == "" {
+			return "", fmt.Errorf("authorization-details entry %d has no type", i)
+		}
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", fmt.Errorf("authorization-details: %w", err)
+	}
+	return compact.String(), nil
+}
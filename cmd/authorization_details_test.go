@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadAuthorizationDetails(t *testing.T) {
+	t.Parallel()
+
+	detailsFile := filepath.Join(t.TempDir(), "details.json")
+	if err := os.WriteFile(detailsFile, []byte("[\n  {\"type\": \"payment_initiation\", \"actions\": [\"initiate\"]}\n]\n"), 0o600); err != nil {
+		t.Fatalf("writing details file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "unset", value: ""},
+		{
+			name:  "inline",
+			value: `[{"type": "account_information", "locations": ["https://example.com/accounts"]}]`,
+			want:  `[{"type":"account_information","locations":["https://example.com/accounts"]}]`,
+		},
+		{
+			name:  "from file",
+			value: "@" + detailsFile,
+			want:  `[{"type":"payment_initiation","actions":["initiate"]}]`,
+		},
+		{name: "missing file", value: "@" + filepath.Join(t.TempDir(), "missing.json"), wantErr: "failed to read"},
+		{name: "not json", value: "payment", wantErr: "must be a JSON array"},
+		{name: "object instead of array", value: `{"type":"payment_initiation"}`, wantErr: "must be a JSON array"},
+		{name: "empty array", value: `[]`, wantErr: "must not be empty"},
+		{name: "entry without type", value: `[{"type":"a"},{"actions":["read"]}]`, wantErr: "entry 1 has no type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := readAuthorizationDetails(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("readAuthorizationDetails() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readAuthorizationDetails() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readAuthorizationDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
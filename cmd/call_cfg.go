@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+type invalidArgsCheck struct {
+	condition bool
+	message   string
+}
+
+// addTokenSourceFlags registers the flags that configure where a command that
+// calls a protected resource gets its access tokens, returning the source they
+// fill in.
+func addTokenSourceFlags(flags *flag.FlagSet, oidcConf *oidc.Config) *oidc.TokenSource {
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required with a grant)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required with a grant)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (eg. for DPoP)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
+
+	tokens := &oidc.TokenSource{Config: oidcConf}
+	flags.StringVar(&tokens.Grant, "grant", "", "grant to obtain access tokens with (client_credentials or refresh_token)")
+	flags.StringVar(&tokens.Scope, "scope", "", "set scope as a space separated list")
+	flags.StringVar(&tokens.AccessToken, "access-token", "", "access token to start with, or '-' to read it from stdin")
+	flags.StringVar(&tokens.RefreshToken, "refresh-token", "", "refresh token for the refresh_token grant, or '-' to read it from stdin")
+	flags.BoolVar(&tokens.DPoP, "dpop", false, "use dpop-bound access tokens")
+	return tokens
+}
+
+// completeTokenSource reads any token given as '-' from stdin and infers the
+// refresh_token grant from a lone refresh token.
+func completeTokenSource(tokens *oidc.TokenSource, stdin io.Reader) error {
+	if tokens.AccessToken == "-" && tokens.RefreshToken == "-" {
+		return errors.New("only one of access-token and refresh-token can be read from stdin")
+	}
+	for _, stdinToken := range []struct {
+		value *string
+		label string
+	}{
+		{&tokens.AccessToken, "access token"},
+		{&tokens.RefreshToken, "refresh token"},
+	} {
+		if *stdinToken.value == "-" {
+			token, err := readTokenFromStdin(stdin, stdinToken.label)
+			if err != nil {
+				return err
+			}
+			*stdinToken.value = token
+		}
+	}
+
+	// A refresh token alone implies the grant it is for
+	if tokens.Grant == "" && tokens.RefreshToken != "" {
+		tokens.Grant = oidc.GrantRefreshToken
+	}
+	return nil
+}
+
+// tokenSourceChecks are the argument checks for the flags addTokenSourceFlags
+// registers.
+func tokenSourceChecks(tokens *oidc.TokenSource) []invalidArgsCheck {
+	oidcConf := tokens.Config
+	return []invalidArgsCheck{
+		{
+			tokens.Grant == "" && tokens.AccessToken == "",
+			"access-token or grant is required",
+		},
+		{
+			tokens.Grant != "" && tokens.Grant != oidc.GrantClientCredentials && tokens.Grant != oidc.GrantRefreshToken,
+			"grant must be client_credentials or refresh_token",
+		},
+		{
+			tokens.Grant != "" && oidcConf.OIDC.IssuerURL == "",
+			"issuer is required when using a grant",
+		},
+		{
+			tokens.Grant != "" && oidcConf.OIDC.ClientID == "",
+			"client-id is required when using a grant",
+		},
+		{
+			tokens.Grant == oidc.GrantClientCredentials && oidcConf.OIDC.ClientSecret == "",
+			"client-secret is required for the client_credentials grant",
+		},
+		{
+			tokens.Grant == oidc.GrantRefreshToken && tokens.RefreshToken == "",
+			"refresh-token is required for the refresh_token grant",
+		},
+		{
+			tokens.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
+			"both dpop-private-key and dpop-public-key are required when using DPoP",
+		},
+	}
+}
+
+func parseCallFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	tokens := addTokenSourceFlags(flags, oidcConf)
+
+	var flowConf oidc.CallFlowConfig
+	flags.StringVar(&flowConf.Method, "method", http.MethodGet, "HTTP method to use")
+	flags.StringVar(&flowConf.URL, "url", "", "resource URL to call (required)")
+	var headers HeaderFlag
+	flags.Var(&headers, "header", "request header as 'Name: value', argument can be given multiple times")
+	var data string
+	flags.StringVar(&data, "data", "", "request body, or '@file' to read it from a file")
+
+	flowConf.Tokens = tokens
+	runner = &oidc.CallFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	flowConf.Headers = headers
+	flowConf.Body, err = readRequestBody(data)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if err := completeTokenSource(tokens, in.Stdin); err != nil {
+		return nil, buf.String(), err
+	}
+
+	var invalidArgsChecks = append([]invalidArgsCheck{
+		{
+			flowConf.URL == "",
+			"url is required",
+		},
+	}, tokenSourceChecks(tokens)...)
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseCallFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.Config
+		tokens   *oidc.TokenSource
+		flowConf oidc.CallFlowConfig
+	}{
+		{
+			"client credentials with dpop",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--grant", "client_credentials",
+				"--scope", "api",
+				"--dpop",
+				"--dpop-private-key", "private.pem",
+				"--dpop-public-key", "public.pem",
+				"--method", "POST",
+				"--url", "https://api.example.com/items",
+				"--header", "Content-Type: application/json",
+				"--data", `{"a":1}`,
+			},
+			"",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+				DPoPKeys: oidc.DPoPKeys{PrivateKeyFile: "private.pem", PublicKeyFile: "public.pem"},
+			},
+			&oidc.TokenSource{
+				Grant: "client_credentials",
+				Scope: "api",
+				DPoP:  true,
+			},
+			oidc.CallFlowConfig{
+				Method:  "POST",
+				URL:     "https://api.example.com/items",
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    []byte(`{"a":1}`),
+			},
+		},
+		{
+			"refresh token implies refresh grant",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--access-token", "at",
+				"--refresh-token", "-",
+				"--url", "https://api.example.com/items",
+			},
+			"rt-from-stdin\n",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			&oidc.TokenSource{
+				Grant:        "refresh_token",
+				AccessToken:  "at",
+				RefreshToken: "rt-from-stdin",
+			},
+			oidc.CallFlowConfig{
+				Method: "GET",
+				URL:    "https://api.example.com/items",
+			},
+		},
+		{
+			"static access token",
+			[]string{
+				"--access-token", "-",
+				"--url", "https://api.example.com/items",
+			},
+			"at-from-stdin\n",
+			oidc.Config{},
+			&oidc.TokenSource{
+				AccessToken: "at-from-stdin",
+			},
+			oidc.CallFlowConfig{
+				Method: "GET",
+				URL:    "https://api.example.com/items",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseCallFlags(ParseInput{Name: "call", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.CallFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if f.FlowConfig.Tokens == nil || f.FlowConfig.Tokens.Config != f.Config {
+				t.Fatal("token source is not wired to the command config")
+			}
+			tt.tokens.Config = f.Config
+			if !reflect.DeepEqual(f.FlowConfig.Tokens, tt.tokens) {
+				t.Errorf("Tokens got %+v, want %+v", f.FlowConfig.Tokens, &tt.tokens)
+			}
+			tt.flowConf.Tokens = f.FlowConfig.Tokens
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseCallFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing url",
+			[]string{"--access-token", "at"},
+		},
+		{
+			"missing token and grant",
+			[]string{"--url", "https://api.example.com"},
+		},
+		{
+			"unknown grant",
+			[]string{"--url", "https://api.example.com", "--grant", "password", "--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"grant missing issuer",
+			[]string{"--url", "https://api.example.com", "--grant", "client_credentials", "--client-id", "client-id", "--client-secret", "secret"},
+		},
+		{
+			"client credentials missing secret",
+			[]string{"--url", "https://api.example.com", "--grant", "client_credentials", "--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"refresh grant missing refresh token",
+			[]string{"--url", "https://api.example.com", "--grant", "refresh_token", "--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"dpop missing keys",
+			[]string{"--url", "https://api.example.com", "--access-token", "at", "--dpop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseCallFlags(ParseInput{Name: "call", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const callbackPortRangeUsage = "ports to fall back on when the callback-uri port is taken, as a port or a range like 9556-9565, argument can be given multiple times"
+
+// PortRangeFlag collects the ports of repeated port ranges, in the order
+// given.
+type PortRangeFlag []int
+
+func (*PortRangeFlag) String() string {
+	return ""
+}
+
+func (p *PortRangeFlag) Set(value string) error {
+	first, last, isRange := strings.Cut(value, "-")
+	if !isRange {
+		last = first
+	}
+	from, err := parsePort(first)
+	if err != nil {
+		return fmt.Errorf("invalid port range %q: %w", value, err)
+	}
+	to, err := parsePort(last)
+	if err != nil {
+		return fmt.Errorf("invalid port range %q: %w", value, err)
+	}
+	if from > to {
+		return fmt.Errorf("invalid port range %q, the first port is above the last", value)
+	}
+	for port := from; port <= to; port++ {
+		*p = append(*p, port)
+	}
+	return nil
+}
+
+// anyCallbackPort reports whether the callback URI asks for any free port.
+func anyCallbackPort(callback string) bool {
+	u, err := url.Parse(callback)
+	return err == nil && u.Port() == "0"
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("expected a port from 1 to 65535, got %q", s)
+	}
+	return port, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPortRangeFlagSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		values  []string
+		want    []int
+		wantErr string
+	}{
+		{name: "range", values: []string{"9556-9558"}, want: []int{9556, 9557, 9558}},
+		{name: "single port and range", values: []string{"8080", "9556-9557"}, want: []int{8080, 9556, 9557}},
+		{name: "reversed", values: []string{"9558-9556"}, wantErr: "the first port is above the last"},
+		{name: "not a port", values: []string{"http"}, wantErr: "expected a port from 1 to 65535"},
+		{name: "out of range", values: []string{"65535-65536"}, wantErr: "expected a port from 1 to 65535"},
+		{name: "zero", values: []string{"0"}, wantErr: "expected a port from 1 to 65535"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var p PortRangeFlag
+			var err error
+			for _, value := range tt.values {
+				if err = p.Set(value); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Set() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if !slices.Equal(p, tt.want) {
+				t.Errorf("ports = %v, want %v", p, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func parseCIBAFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.BackchannelAuthenticationEndpoint, "backchannel-url", "", "override backchannel authentication url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (required)")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (eg. for DPoP)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
+
+	var flowConf oidc.CIBAFlowConfig
+	flags.StringVar(&flowConf.Scope, "scope", "openid", "set scope as a space separated list, must include openid")
+	flags.StringVar(&flowConf.LoginHint, "login-hint", "", "identify the user to authenticate, eg. by email or phone number")
+	flags.StringVar(&flowConf.IDTokenHint, "id-token-hint", "", "identify the user by an id token previously issued to the client, or '-' to read it from stdin")
+	flags.StringVar(&flowConf.LoginHintToken, "login-hint-token", "", "identify the user by a token in a format the provider understands")
+	flags.StringVar(&flowConf.BindingMessage, "binding-message", "", "short message shown on both devices to tie the request to this session")
+	flags.StringVar(&flowConf.ACRValues, "acr-values", "", "space separated list of requested authentication context class references")
+	flags.IntVar(&flowConf.RequestedExpiry, "requested-expiry", 0, "requested lifetime of the authentication request in seconds")
+	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
+
+	runner = &oidc.CIBAFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.IDTokenHint == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "id token hint")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.IDTokenHint = token
+	}
+
+	hints := 0
+	for _, hint := range []string{flowConf.LoginHint, flowConf.IDTokenHint, flowConf.LoginHintToken} {
+		if hint != "" {
+			hints++
+		}
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			oidcConf.OIDC.ClientSecret == "",
+			"client-secret is required",
+		},
+		{
+			!slices.Contains(strings.Fields(flowConf.Scope), "openid"),
+			"scope must include openid",
+		},
+		{
+			hints != 1,
+			"exactly one of login-hint, id-token-hint or login-hint-token is required",
+		},
+		{
+			flowConf.RequestedExpiry < 0,
+			"requested-expiry must not be negative",
+		},
+		{
+			flowConf.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
+			"both dpop-private-key and dpop-public-key are required when using DPoP",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
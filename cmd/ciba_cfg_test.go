@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseCIBAFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.Config
+		flowConf oidc.CIBAFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--discovery-url", "https://example.com/.well-known/openid-configuration",
+				"--backchannel-url", "https://example.com/bc-authorize",
+				"--token-url", "https://example.com/token",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--scope", "openid profile",
+				"--login-hint", "alice@example.com",
+				"--binding-message", "W4SCT",
+				"--acr-values", "urn:example:mfa",
+				"--requested-expiry", "120",
+				"--dpop",
+				"--dpop-private-key", "path/to/private-key.pem",
+				"--dpop-public-key", "path/to/public-key.pem",
+			},
+			"",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:                         "https://example.com",
+					DiscoveryEndpoint:                 "https://example.com/.well-known/openid-configuration",
+					BackchannelAuthenticationEndpoint: "https://example.com/bc-authorize",
+					TokenEndpoint:                     "https://example.com/token",
+					ClientID:                          "client-id",
+					ClientSecret:                      "client-secret",
+				},
+				DPoPKeys: oidc.DPoPKeys{
+					PrivateKeyFile: "path/to/private-key.pem",
+					PublicKeyFile:  "path/to/public-key.pem",
+				},
+			},
+			oidc.CIBAFlowConfig{
+				Scope:           "openid profile",
+				LoginHint:       "alice@example.com",
+				BindingMessage:  "W4SCT",
+				ACRValues:       "urn:example:mfa",
+				RequestedExpiry: 120,
+				DPoP:            true,
+			},
+		},
+		{
+			"id token hint from stdin",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--id-token-hint", "-",
+			},
+			"id-token\n",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.CIBAFlowConfig{
+				Scope:       "openid",
+				IDTokenHint: "id-token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseCIBAFlags(ParseInput{Name: "ciba", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.CIBAFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseCIBAFlagsError(t *testing.T) {
+	t.Parallel()
+	base := []string{"--issuer", "https://example.com", "--client-id", "client-id", "--client-secret", "client-secret"}
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id", "--client-secret", "client-secret", "--login-hint", "alice"},
+		},
+		{
+			"missing client secret",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--login-hint", "alice"},
+		},
+		{
+			"no hint",
+			base,
+		},
+		{
+			"two hints",
+			append(slices.Clone(base), "--login-hint", "alice", "--login-hint-token", "token"),
+		},
+		{
+			"scope without openid",
+			append(slices.Clone(base), "--login-hint", "alice", "--scope", "profile"),
+		},
+		{
+			"negative requested expiry",
+			append(slices.Clone(base), "--login-hint", "alice", "--requested-expiry", "-1"),
+		},
+		{
+			"dpop without keys",
+			append(slices.Clone(base), "--login-hint", "alice", "--dpop"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseCIBAFlags(ParseInput{Name: "ciba", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const claimUsage = "request a claim as 'id_token.<name>' or 'userinfo.<name>', optionally followed by '=essential', '=value:<value>' or '=values:<a>,<b>', or '@file' to read a claims request JSON object from a file, argument can be given multiple times"
+
+// ClaimFlag collects repeated --claim arguments. A claim argument's syntax is
+// checked as it is given; '@file' arguments are read by buildClaimsRequest.
+type ClaimFlag []string
+
+func (*ClaimFlag) String() string {
+	return ""
+}
+
+func (c *ClaimFlag) Set(value string) error {
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		if path == "" {
+			return errors.New("invalid claim \"@\", expected '@file'")
+		}
+	} else if err := (claimsRequest{}).add(value); err != nil {
+		return err
+	}
+	*c = append(*c, value)
+	return nil
+}
+
+// claimsRequest is the claims request parameter (OpenID Connect Core §5.5):
+// the requested claims per target, each null or an object of essential,
+// value and values members.
+type claimsRequest map[string]map[string]map[string]any
+
+// claimTargets are the members of a claims request this tool checks.
+var claimTargets = map[string]bool{
+	"id_token": true,
+	"userinfo": true,
+}
+
+// buildClaimsRequest turns repeated --claim arguments into the claims request
+// parameter, returning it as JSON. Arguments naming the same claim are
+// merged, so essential and a value can be asked for together.
+func buildClaimsRequest(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	request := claimsRequest{}
+	for _, arg := range args {
+		var err error
+		if strings.HasPrefix(arg, "@") {
+			err = request.mergeFile(arg)
+		} else {
+			err = request.add(arg)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("claims: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// add requests the claim an argument such as 'userinfo.email=essential'
+// names.
+func (r claimsRequest) add(arg string) error {
+	target, rest, ok := strings.Cut(arg, ".")
+	name, spec, hasSpec := strings.Cut(rest, "=")
+	if !ok || !claimTargets[target] || name == "" {
+		return fmt.Errorf("invalid claim %q, expected 'id_token.<name>' or 'userinfo.<name>'", arg)
+	}
+	member := r.member(target, name)
+	switch {
+	case !hasSpec:
+		// Requested in the default manner, as null
+		return nil
+	case spec == "essential":
+		member["essential"] = true
+	case strings.HasPrefix(spec, "value:") && len(spec) > len("value:"):
+		member["value"] = strings.TrimPrefix(spec, "value:")
+	case strings.HasPrefix(spec, "values:") && len(spec) > len("values:"):
+		member["values"] = strings.Split(strings.TrimPrefix(spec, "values:"), ",")
+	default:
+		return fmt.Errorf("invalid claim %q, expected '=essential', '=value:<value>' or '=values:<a>,<b>' after the name", arg)
+	}
+	r[target][name] = member
+	return nil
+}
+
+// member returns the requested members of a claim, adding the claim as null
+// when it is new.
+func (r claimsRequest) member(target, name string) map[string]any {
+	if r[target] == nil {
+		r[target] = map[string]map[string]any{}
+	}
+	member, ok := r[target][name]
+	if !ok {
+		r[target][name] = nil
+	}
+	if member == nil {
+		member = map[string]any{}
+	}
+	return member
+}
+
+// mergeFile merges the claims request JSON object in the '@path' file.
+func (r claimsRequest) mergeFile(arg string) error {
+	raw, err := readRequestBody(arg)
+	if err != nil {
+		return err
+	}
+	var file map[string]map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return fmt.Errorf("claims file %s must be a JSON object of requested claims: %w", arg[1:], err)
+	}
+	for target, claims := range file {
+		if !claimTargets[target] {
+			return fmt.Errorf("claims file %s requests claims for %q, expected id_token or userinfo", arg[1:], target)
+		}
+		for name, members := range claims {
+			member := r.member(target, name)
+			for key, value := range members {
+				decoded, err := decodeClaimMember(key, value)
+				if err != nil {
+					return fmt.Errorf("claims file %s: %s.%s: %w", arg[1:], target, name, err)
+				}
+				member[key] = decoded
+			}
+			if len(member) > 0 {
+				r[target][name] = member
+			}
+		}
+	}
+	return nil
+}
+
+// decodeClaimMember checks the members OpenID Connect Core §5.5.1 defines
+// have their types; others are kept as given.
+func decodeClaimMember(key string, value json.RawMessage) (any, error) {
+	var decoded any
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return nil, err
+	}
+	switch key {
+	case "essential":
+		if _, ok := decoded.(bool); !ok {
+			return nil, errors.New("essential must be a boolean")
+		}
+	case "values":
+		if _, ok := decoded.([]any); !ok {
+			return nil, errors.New("values must be an array")
+		}
+	}
+	return decoded, nil
+}
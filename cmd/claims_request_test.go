@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildClaimsRequest(t *testing.T) {
+	t.Parallel()
+
+	claimsFile := filepath.Join(t.TempDir(), "claims.json")
+	if err := os.WriteFile(claimsFile, []byte(`{"id_token": {"auth_time": {"essential": true}, "sub": null}}`), 0o600); err != nil {
+		t.Fatalf("writing claims file: %v", err)
+	}
+	badFile := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(badFile, []byte(`{"id_token": {"acr": {"essential": "yes"}}}`), 0o600); err != nil {
+		t.Fatalf("writing claims file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "unset"},
+		{
+			name: "voluntary",
+			args: []string{"userinfo.email"},
+			want: `{"userinfo":{"email":null}}`,
+		},
+		{
+			name: "essential and value",
+			args: []string{"userinfo.email=essential", "id_token.acr=value:urn:mace:incommon:iap:silver"},
+			want: `{"id_token":{"acr":{"value":"urn:mace:incommon:iap:silver"}},"userinfo":{"email":{"essential":true}}}`,
+		},
+		{
+			name: "merged",
+			args: []string{"id_token.acr=essential", "id_token.acr=values:urn:a,urn:b", "id_token.acr"},
+			want: `{"id_token":{"acr":{"essential":true,"values":["urn:a","urn:b"]}}}`,
+		},
+		{
+			name: "from file",
+			args: []string{"@" + claimsFile, "id_token.auth_time=value:0"},
+			want: `{"id_token":{"auth_time":{"essential":true,"value":"0"},"sub":null}}`,
+		},
+		{name: "unknown target", args: []string{"access_token.email"}, wantErr: "expected 'id_token.<name>' or 'userinfo.<name>'"},
+		{name: "missing name", args: []string{"userinfo.=essential"}, wantErr: "expected 'id_token.<name>' or 'userinfo.<name>'"},
+		{name: "unknown requirement", args: []string{"userinfo.email=required"}, wantErr: "expected '=essential'"},
+		{name: "empty value", args: []string{"id_token.acr=value:"}, wantErr: "expected '=essential'"},
+		{name: "missing file", args: []string{"@" + filepath.Join(t.TempDir(), "missing.json")}, wantErr: "failed to read"},
+		{name: "file with mistyped member", args: []string{"@" + badFile}, wantErr: "essential must be a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := buildClaimsRequest(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("buildClaimsRequest() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildClaimsRequest() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("buildClaimsRequest() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimFlagSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "repeated",
+			values: []string{"userinfo.email=essential", "id_token.acr=value:silver", "@claims.json"},
+			want:   []string{"userinfo.email=essential", "id_token.acr=value:silver", "@claims.json"},
+		},
+		{name: "unknown target", values: []string{"access_token.email"}, wantErr: "invalid claim"},
+		{name: "unknown member", values: []string{"userinfo.email=optional"}, wantErr: "invalid claim"},
+		{name: "file without a path", values: []string{"@"}, wantErr: "expected '@file'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var c ClaimFlag
+			var err error
+			for _, value := range tt.values {
+				if err = c.Set(value); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Set() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if !slices.Equal(c, tt.want) {
+				t.Errorf("claims = %q, want %q", c, tt.want)
+			}
+		})
+	}
+}
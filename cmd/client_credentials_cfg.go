@@ -23,6 +23,9 @@ func parseClientCredentialsFlags(in ParseInput) (runner CommandRunner, output st
 
 	var flowConf oidc.ClientCredentialsFlowConfig
 	flags.StringVar(&flowConf.Scope, "scope", "", "set scope as a space separated list")
+	flags.Var((*ResourceFlag)(&flowConf.Resources), "resource", resourceUsage)
+	var authorizationDetails string
+	flags.StringVar(&authorizationDetails, "authorization-details", "", authorizationDetailsUsage)
 
 	runner = &oidc.ClientCredentialsFlow{
 		Config:     oidcConf,
@@ -34,6 +37,11 @@ func parseClientCredentialsFlags(in ParseInput) (runner CommandRunner, output st
 		return nil, buf.String(), err
 	}
 
+	flowConf.AuthorizationDetails, err = readAuthorizationDetails(authorizationDetails)
+	if err != nil {
+		return nil, err.Error(), errors.New("invalid arguments: " + err.Error())
+	}
+
 	var invalidArgsChecks = []struct {
 		condition bool
 		message   string
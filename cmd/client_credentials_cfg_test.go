@@ -81,6 +81,28 @@ func TestParseClientCredentialsFlagsResult(t *testing.T) {
 				Scope: "expected",
 			},
 		},
+		{
+			"authorization details and resources",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--authorization-details", `[ {"type": "payment_initiation", "actions": ["initiate"]} ]`,
+				"--resource", "https://payments.example.com",
+				"--resource", "https://accounts.example.com",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.ClientCredentialsFlowConfig{
+				AuthorizationDetails: `[{"type":"payment_initiation","actions":["initiate"]}]`,
+				Resources:            []string{"https://payments.example.com", "https://accounts.example.com"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -120,6 +142,15 @@ func TestParseClientCredentialsFlagsError(t *testing.T) {
 				"--client-secret", "client-secret",
 			},
 		},
+		{
+			"authorization details without a type",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--authorization-details", `[{"actions": ["initiate"]}]`,
+			},
+		},
 		{
 			"missing client-secret",
 			[]string{
@@ -36,6 +36,8 @@ type ParseInput struct {
 	Args  []string
 	Conf  *oidc.Config
 	Stdin io.Reader
+	// Logger writes prompts a command shows while its flags are parsed.
+	Logger *log.Logger
 }
 
 type Command struct {
@@ -48,9 +50,22 @@ var commands = []Command{
 	{Name: "authorization_code", Help: "Use the Authorization Code flow to obtain tokens.", Configure: parseAuthorizationCodeFlags},
 	{Name: "client_credentials", Help: "Use the Client Credentials flow to obtain tokens.", Configure: parseClientCredentialsFlags},
 	{Name: "device", Help: "Use the Device flow to obtain tokens.", Configure: parseDeviceFlags},
+	{Name: "ciba", Help: "Use Client-Initiated Backchannel Authentication to obtain tokens.", Configure: parseCIBAFlags},
+	{Name: "password", Help: "Use the deprecated Resource Owner Password Credentials grant to obtain tokens.", Configure: parsePasswordFlags},
+	{Name: "jwt_bearer", Help: "Exchange a signed JWT assertion for tokens.", Configure: parseJWTBearerFlags},
+	{Name: "saml2-bearer", Help: "Exchange a SAML 2.0 assertion for tokens.", Configure: parseSAML2BearerFlags},
+	{Name: "grant", Help: "Obtain tokens with any grant type and custom parameters.", Configure: parseGrantFlags},
+	{Name: "uma", Help: "Exchange an UMA permission ticket for a requesting party token.", Configure: parseUMAFlags},
 	{Name: "introspect", Help: "Validate a token and retrieve associated claims.", Configure: parseIntrospectFlags},
 	{Name: "token_refresh", Help: "Use a refresh token to obtain new tokens.", Configure: parseTokenRefreshFlags},
 	{Name: "token_exchange", Help: "Exchange a token for different tokens.", Configure: parseTokenExchangeFlags},
+	{Name: "logout", Help: "End the session at the provider with RP-initiated logout.", Configure: parseLogoutFlags},
+	{Name: "logout-listen", Help: "Receive back-channel and front-channel logout requests and print them.", Configure: parseLogoutListenFlags},
+	{Name: "call", Help: "Call an API with an access token attached.", Configure: parseCallFlags},
+	{Name: "dpop", Help: "Create DPoP proofs and call DPoP-protected resources.", Configure: parseDPoPFlags},
+	{Name: "proxy", Help: "Forward requests to an API with an access token attached.", Configure: parseProxyFlags},
+	{Name: "register", Help: "Register a client dynamically, or read, update or delete one.", Configure: parseRegisterFlags},
+	{Name: "serve-mock", Help: "Run a local mock OpenID Provider for offline testing.", Configure: parseServeMockFlags},
 	{Name: "version", Help: "Display the current version of oidc-cli."},
 	{Name: "help", Help: "Show help for oidc-cli or a specific command."},
 }
@@ -78,10 +93,11 @@ func RunCommand(name string, args []string, globalConf *oidc.Config, logger *log
 	}
 
 	command, output, err := cmd.Configure(ParseInput{
-		Name:  name,
-		Args:  args,
-		Conf:  globalConf,
-		Stdin: stdin,
+		Name:   name,
+		Args:   args,
+		Conf:   globalConf,
+		Stdin:  stdin,
+		Logger: logger,
 	})
 	if errors.Is(err, flag.ErrHelp) {
 		logger.Outputln(output)
@@ -132,8 +148,12 @@ func RunCommand(name string, args []string, globalConf *oidc.Config, logger *log
 }
 
 func prepareOIDCConfig(ctx context.Context, conf *oidc.Config) error {
-	if err := conf.OIDC.DiscoverEndpoints(ctx, conf.Runtime.Client); err != nil {
-		return fmt.Errorf("failed to discover endpoints: %w", err)
+	// Commands such as dpop talk to no authorization server, so there is
+	// nothing to discover unless an issuer or discovery url was given.
+	if conf.OIDC.IssuerURL != "" || conf.OIDC.DiscoveryEndpoint != "" {
+		if err := conf.OIDC.DiscoverEndpoints(ctx, conf.Runtime.Client); err != nil {
+			return fmt.Errorf("failed to discover endpoints: %w", err)
+		}
 	}
 	if err := conf.DPoPKeys.Load(); err != nil {
 		return fmt.Errorf("failed to read key files: %w", err)
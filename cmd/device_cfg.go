@@ -28,6 +28,7 @@ func parseDeviceFlags(in ParseInput) (runner CommandRunner, output string, err e
 	var flowConf oidc.DeviceFlowConfig
 	flags.BoolVar(&flowConf.PKCE, "pkce", false, "use proof-key for code exchange (PKCE)")
 	flags.StringVar(&flowConf.Scope, "scope", "openid", "set scope as a space separated list")
+	flags.Var((*ResourceFlag)(&flowConf.Resources), "resource", resourceUsage)
 	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
 
 	runner = &oidc.DeviceFlow{
@@ -26,6 +26,7 @@ func TestParseDeviceFlags(t *testing.T) {
 				"--client-id", "client-id",
 				"--client-secret", "client-secret",
 				"--scope", "openid profile email",
+				"--resource", "https://api.example.com",
 				"--dpop",
 				"--dpop-private-key", "path/to/private-key.pem",
 				"--dpop-public-key", "path/to/public-key.pem",
@@ -45,8 +46,9 @@ func TestParseDeviceFlags(t *testing.T) {
 				},
 			},
 			oidc.DeviceFlowConfig{
-				Scope: "openid profile email",
-				DPoP:  true,
+				Scope:     "openid profile email",
+				Resources: []string{"https://api.example.com"},
+				DPoP:      true,
 			},
 		},
 		{
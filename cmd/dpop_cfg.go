@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+// HeaderFlag collects repeated "Name: value" request headers.
+type HeaderFlag map[string]string
+
+func (*HeaderFlag) String() string {
+	return ""
+}
+
+func (h *HeaderFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("invalid header %q, expected 'Name: value'", value)
+	}
+	if *h == nil {
+		*h = make(HeaderFlag)
+	}
+	(*h)[http.CanonicalHeaderKey(name)] = strings.TrimSpace(val)
+	return nil
+}
+
+// readRequestBody resolves a --data argument: '@path' reads the body from a
+// file, anything else is sent as given.
+func readRequestBody(data string) ([]byte, error) {
+	if data == "" {
+		return nil, nil
+	}
+	if path, ok := strings.CutPrefix(data, "@"); ok {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		return body, nil
+	}
+	return []byte(data), nil
+}
+
+var dpopSubcommands = []Command{
+	{Name: "proof", Help: "Print a DPoP proof for a request.", Configure: parseDPoPProofFlags},
+	{Name: "request", Help: "Call a resource server with a DPoP-bound access token.", Configure: parseDPoPRequestFlags},
+}
+
+func dpopUsage(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: oidc-cli %s <subcommand> [flags]\n\nSubcommands:\n", name)
+	for _, sub := range dpopSubcommands {
+		fmt.Fprintf(&b, "  %-8s: %s\n", sub.Name, sub.Help)
+	}
+	return b.String()
+}
+
+// parseDPoPFlags dispatches to the subcommand named by the first argument.
+func parseDPoPFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	if len(in.Args) == 0 {
+		return nil, dpopUsage(in.Name), errors.New("invalid arguments: subcommand is required")
+	}
+	name := in.Args[0]
+	if name == "-h" || name == "-help" || name == "--help" {
+		return nil, dpopUsage(in.Name), flag.ErrHelp
+	}
+	idx := slices.IndexFunc(dpopSubcommands, func(sub Command) bool {
+		return sub.Name == name
+	})
+	if idx < 0 {
+		return nil, dpopUsage(in.Name), fmt.Errorf("invalid arguments: unknown subcommand %q", name)
+	}
+	sub := in
+	sub.Name = in.Name + " " + name
+	sub.Args = in.Args[1:]
+	return dpopSubcommands[idx].Configure(sub)
+}
+
+func parseDPoPProofFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (required)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (required)")
+
+	var flowConf oidc.DPoPProofFlowConfig
+	flags.StringVar(&flowConf.Method, "method", http.MethodGet, "HTTP method the proof is for")
+	flags.StringVar(&flowConf.URL, "url", "", "URL the proof is for (required)")
+	flags.StringVar(&flowConf.AccessToken, "access-token", "", "access token to bind the proof to with ath, or '-' to read it from stdin")
+	flags.StringVar(&flowConf.Nonce, "nonce", "", "server-provided nonce to include")
+
+	runner = &oidc.DPoPProofFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.AccessToken == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "access token")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.AccessToken = token
+	}
+
+	var invalidArgsChecks = []struct {
+		condition bool
+		message   string
+	}{
+		{
+			flowConf.URL == "",
+			"url is required",
+		},
+		{
+			oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == "",
+			"both dpop-private-key and dpop-public-key are required",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
+
+func parseDPoPRequestFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (required)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (required)")
+
+	var flowConf oidc.DPoPRequestFlowConfig
+	flags.StringVar(&flowConf.Method, "method", http.MethodGet, "HTTP method to use")
+	flags.StringVar(&flowConf.URL, "url", "", "resource URL to call (required)")
+	flags.StringVar(&flowConf.AccessToken, "access-token", "", "DPoP-bound access token, or '-' to read it from stdin (required)")
+	flags.StringVar(&flowConf.Nonce, "nonce", "", "server-provided nonce to include in the first proof")
+	var headers HeaderFlag
+	flags.Var(&headers, "header", "request header as 'Name: value', argument can be given multiple times")
+	var data string
+	flags.StringVar(&data, "data", "", "request body, or '@file' to read it from a file")
+
+	runner = &oidc.DPoPRequestFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	flowConf.Headers = headers
+	flowConf.Body, err = readRequestBody(data)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.AccessToken == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "access token")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.AccessToken = token
+	}
+
+	var invalidArgsChecks = []struct {
+		condition bool
+		message   string
+	}{
+		{
+			flowConf.URL == "",
+			"url is required",
+		},
+		{
+			flowConf.AccessToken == "",
+			"access-token is required",
+		},
+		{
+			oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == "",
+			"both dpop-private-key and dpop-public-key are required",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseDPoPProofFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.Config
+		flowConf oidc.DPoPProofFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"proof",
+				"--dpop-private-key", "private.pem",
+				"--dpop-public-key", "public.pem",
+				"--method", "POST",
+				"--url", "https://api.example.com/items",
+				"--access-token", "access-123",
+				"--nonce", "nonce-123",
+			},
+			"",
+			oidc.Config{
+				DPoPKeys: oidc.DPoPKeys{PrivateKeyFile: "private.pem", PublicKeyFile: "public.pem"},
+			},
+			oidc.DPoPProofFlowConfig{
+				Method:      "POST",
+				URL:         "https://api.example.com/items",
+				AccessToken: "access-123",
+				Nonce:       "nonce-123",
+			},
+		},
+		{
+			"defaults to GET without access token",
+			[]string{
+				"proof",
+				"--dpop-private-key", "private.pem",
+				"--dpop-public-key", "public.pem",
+				"--url", "https://op.example.com/token",
+			},
+			"",
+			oidc.Config{
+				DPoPKeys: oidc.DPoPKeys{PrivateKeyFile: "private.pem", PublicKeyFile: "public.pem"},
+			},
+			oidc.DPoPProofFlowConfig{
+				Method: "GET",
+				URL:    "https://op.example.com/token",
+			},
+		},
+		{
+			"access token from stdin",
+			[]string{
+				"proof",
+				"--dpop-private-key", "private.pem",
+				"--dpop-public-key", "public.pem",
+				"--url", "https://api.example.com/items",
+				"--access-token", "-",
+			},
+			"access-from-stdin\n",
+			oidc.Config{
+				DPoPKeys: oidc.DPoPKeys{PrivateKeyFile: "private.pem", PublicKeyFile: "public.pem"},
+			},
+			oidc.DPoPProofFlowConfig{
+				Method:      "GET",
+				URL:         "https://api.example.com/items",
+				AccessToken: "access-from-stdin",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseDPoPFlags(ParseInput{Name: "dpop", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.DPoPProofFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseDPoPRequestFlagsResult(t *testing.T) {
+	t.Parallel()
+
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(bodyFile, []byte(`{"from":"file"}`), 0o600); err != nil {
+		t.Fatalf("writing body file: %v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		args     []string
+		flowConf oidc.DPoPRequestFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"request",
+				"--dpop-private-key", "private.pem",
+				"--dpop-public-key", "public.pem",
+				"--method", "POST",
+				"--url", "https://api.example.com/items",
+				"--access-token", "access-123",
+				"--nonce", "nonce-123",
+				"--header", "content-type: application/json",
+				"--header", "X-Request-Id:abc",
+				"--data", `{"a":1}`,
+			},
+			oidc.DPoPRequestFlowConfig{
+				Method:      "POST",
+				URL:         "https://api.example.com/items",
+				AccessToken: "access-123",
+				Nonce:       "nonce-123",
+				Headers:     map[string]string{"Content-Type": "application/json", "X-Request-Id": "abc"},
+				Body:        []byte(`{"a":1}`),
+			},
+		},
+		{
+			"body from file",
+			[]string{
+				"request",
+				"--dpop-private-key", "private.pem",
+				"--dpop-public-key", "public.pem",
+				"--method", "PUT",
+				"--url", "https://api.example.com/items/1",
+				"--access-token", "access-123",
+				"--data", "@" + bodyFile,
+			},
+			oidc.DPoPRequestFlowConfig{
+				Method:      "PUT",
+				URL:         "https://api.example.com/items/1",
+				AccessToken: "access-123",
+				Body:        []byte(`{"from":"file"}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseDPoPFlags(ParseInput{Name: "dpop", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.DPoPRequestFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			want := oidc.Config{DPoPKeys: oidc.DPoPKeys{PrivateKeyFile: "private.pem", PublicKeyFile: "public.pem"}}
+			if !reflect.DeepEqual(*f.Config, want) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, want)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseDPoPFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing subcommand",
+			[]string{},
+		},
+		{
+			"unknown subcommand",
+			[]string{"sign"},
+		},
+		{
+			"proof missing url",
+			[]string{"proof", "--dpop-private-key", "private.pem", "--dpop-public-key", "public.pem"},
+		},
+		{
+			"proof missing keys",
+			[]string{"proof", "--url", "https://api.example.com"},
+		},
+		{
+			"request missing access token",
+			[]string{"request", "--dpop-private-key", "private.pem", "--dpop-public-key", "public.pem", "--url", "https://api.example.com"},
+		},
+		{
+			"request malformed header",
+			[]string{"request", "--header", "no-colon"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseDPoPFlags(ParseInput{Name: "dpop", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
+
+func TestParseDPoPFlagsHelp(t *testing.T) {
+	t.Parallel()
+	_, output, err := parseDPoPFlags(ParseInput{Name: "dpop", Args: []string{"--help"}, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("err got %v, want %v", err, flag.ErrHelp)
+	}
+	for _, sub := range []string{"proof", "request"} {
+		if !strings.Contains(output, sub) {
+			t.Errorf("help output %q does not list %q", output, sub)
+		}
+	}
+}
+
+func TestReadRequestBodyMissingFile(t *testing.T) {
+	t.Parallel()
+	if _, err := readRequestBody("@" + filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("err got nil, want error")
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+
+	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func parseGrantFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (omit for a public client)")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (eg. for DPoP)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
+
+	var flowConf oidc.CustomGrantFlowConfig
+	flags.StringVar(&flowConf.GrantType, "grant-type", "", "grant_type to request, eg. 'urn:example:params:grant-type:otp' (required)")
+	var customArgs CustomArgsFlag
+	flags.Var(&customArgs, "param", "token request parameter as key=value, argument can be given multiple times")
+	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
+
+	runner = &oidc.CustomGrantFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if len(customArgs) > 0 {
+		flowConf.CustomArgs = &httpclient.CustomArgs{}
+		for _, arg := range customArgs {
+			err := flowConf.CustomArgs.Set(arg)
+			if err != nil {
+				return nil, buf.String(), err
+			}
+		}
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			flowConf.GrantType == "",
+			"grant-type is required",
+		},
+		{
+			flowConf.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
+			"both dpop-private-key and dpop-public-key are required when using DPoP",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseGrantFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		oidcConf oidc.Config
+		flowConf oidc.CustomGrantFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--discovery-url", "https://example.com/.well-known/openid-configuration",
+				"--token-url", "https://example.com/token",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--grant-type", "urn:example:params:grant-type:otp",
+				"--param", "otp=123456",
+				"--param", "username=alice",
+				"--param", "filter=a=b",
+				"--dpop",
+				"--dpop-private-key", "path/to/private-key.pem",
+				"--dpop-public-key", "path/to/public-key.pem",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:         "https://example.com",
+					DiscoveryEndpoint: "https://example.com/.well-known/openid-configuration",
+					TokenEndpoint:     "https://example.com/token",
+					ClientID:          "client-id",
+					ClientSecret:      "client-secret",
+				},
+				DPoPKeys: oidc.DPoPKeys{
+					PrivateKeyFile: "path/to/private-key.pem",
+					PublicKeyFile:  "path/to/public-key.pem",
+				},
+			},
+			oidc.CustomGrantFlowConfig{
+				GrantType:  "urn:example:params:grant-type:otp",
+				CustomArgs: &httpclient.CustomArgs{"otp": "123456", "username": "alice", "filter": "a=b"},
+				DPoP:       true,
+			},
+		},
+		{
+			"no params",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--grant-type", "urn:example:params:grant-type:anonymous",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			oidc.CustomGrantFlowConfig{
+				GrantType: "urn:example:params:grant-type:anonymous",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseGrantFlags(ParseInput{Name: "grant", Args: tt.args, Conf: &oidc.Config{}})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.CustomGrantFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseGrantFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id", "--grant-type", "urn:example:otp"},
+		},
+		{
+			"missing client id",
+			[]string{"--issuer", "https://example.com", "--grant-type", "urn:example:otp"},
+		},
+		{
+			"missing grant type",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"dpop without keys",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--grant-type", "urn:example:otp", "--dpop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseGrantFlags(ParseInput{Name: "grant", Args: tt.args, Conf: &oidc.Config{}})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
+
+func TestParseGrantFlagsInvalidParam(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"--issuer", "https://example.com", "--client-id", "client-id", "--grant-type", "urn:example:otp", "--param", "otp"}
+	_, _, err := parseGrantFlags(ParseInput{Name: "grant", Args: args, Conf: &oidc.Config{}})
+	if err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Errorf("err got %v, want an invalid parameter error", err)
+	}
+}
@@ -21,6 +21,7 @@ func parseIntrospectFlags(in ParseInput) (runner CommandRunner, output string, e
 	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
 	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (required unless bearer token is provided)")
 	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
 
 	var flowConf oidc.IntrospectFlowConfig
 	flags.StringVar(&flowConf.BearerToken, "bearer-token", "", "bearer token for authorization (required unless client secret is provided)")
@@ -29,6 +30,7 @@ func parseIntrospectFlags(in ParseInput) (runner CommandRunner, output string, e
 	flags.StringVar(&flowConf.AcceptMediaType, "accept-header", "", "set a custom accept header to request a format (e.g. application/json)")
 	var customArgs CustomArgsFlag
 	flags.Var(&customArgs, "custom", "custom parameters to send in the body of the request, argument can be given multiple times")
+	flags.BoolVar(&flowConf.DPoP, "dpop", false, "verify that the token's cnf.jkt matches the DPoP public key")
 
 	runner = &oidc.IntrospectFlow{
 		Config:     oidcConf,
@@ -80,6 +82,10 @@ func parseIntrospectFlags(in ParseInput) (runner CommandRunner, output string, e
 			flowConf.Token == "",
 			"token is required",
 		},
+		{
+			flowConf.DPoP && oidcConf.DPoPKeys.PublicKeyFile == "",
+			"dpop-public-key is required when using DPoP",
+		},
 	}
 
 	for _, check := range invalidArgsChecks {
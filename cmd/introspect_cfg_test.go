@@ -92,6 +92,32 @@ func TestParseIntrospectFlagsResult(t *testing.T) {
 				AcceptMediaType: "",
 			},
 		},
+		{
+			"dpop binding check",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--token", "token",
+				"--dpop",
+				"--dpop-public-key", "path/to/public-key.pem",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+				DPoPKeys: oidc.DPoPKeys{
+					PublicKeyFile: "path/to/public-key.pem",
+				},
+			},
+			oidc.IntrospectFlowConfig{
+				Token:         "token",
+				TokenTypeHint: "access_token",
+				DPoP:          true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -147,6 +173,16 @@ func TestParseIntrospectFlagsError(t *testing.T) {
 				"--client-secret", "client-secret",
 			},
 		},
+		{
+			"dpop without public key",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--token", "token",
+				"--dpop",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+// readAssertion resolves an --assertion argument: '-' reads it from stdin,
+// '@path' from a file, and anything else is the assertion itself.
+func readAssertion(value string, in ParseInput) (string, error) {
+	if value == "-" {
+		return readTokenFromStdin(in.Stdin, "assertion")
+	}
+	raw, err := readRequestBody(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+// parseClaims turns repeated 'name=value' arguments into claims. A value that
+// is valid JSON, such as a number or an array, keeps its type; any other
+// value is a string.
+func parseClaims(args []string) (map[string]any, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	claims := make(map[string]any, len(args))
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid claim %q, expected 'name=value'", arg)
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		claims[name] = decoded
+	}
+	return claims, nil
+}
+
+func parseJWTBearerFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (omit for a public client)")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (eg. for DPoP)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
+
+	var flowConf oidc.JWTBearerFlowConfig
+	var assertion string
+	flags.StringVar(&assertion, "assertion", "", "signed JWT to present, '@file' to read it from a file or '-' to read it from stdin")
+	flags.StringVar(&flowConf.KeyFile, "signing-key", "", "PEM or JWK file with the private key to mint an assertion with")
+	flags.StringVar(&flowConf.KeyID, "kid", "", "kid header of the minted assertion (defaults to the JWK's kid)")
+	flags.StringVar(&flowConf.Issuer, "assertion-issuer", "", "iss claim of the minted assertion (required when minting)")
+	flags.StringVar(&flowConf.Subject, "subject", "", "sub claim of the minted assertion (required when minting)")
+	flags.StringVar(&flowConf.Audience, "audience", "", "aud claim of the minted assertion (defaults to the token url)")
+	flags.DurationVar(&flowConf.Lifetime, "lifetime", 0, "lifetime of the minted assertion (default 5m)")
+	var claims CustomArgsFlag
+	flags.Var(&claims, "claim", "additional 'name=value' claim of the minted assertion, argument can be given multiple times")
+	flags.StringVar(&flowConf.Scope, "scope", "", "set scope as a space separated list")
+	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
+
+	runner = &oidc.JWTBearerFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	flowConf.Assertion, err = readAssertion(assertion, in)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+	flowConf.Claims, err = parseClaims(claims)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	minting := flowConf.KeyFile != ""
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			(flowConf.Assertion == "") == !minting,
+			"exactly one of assertion or signing-key is required",
+		},
+		{
+			minting && (flowConf.Issuer == "" || flowConf.Subject == ""),
+			"assertion-issuer and subject are required with signing-key",
+		},
+		{
+			flowConf.Lifetime < 0,
+			"lifetime must not be negative",
+		},
+		{
+			flowConf.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
+			"both dpop-private-key and dpop-public-key are required when using DPoP",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseJWTBearerFlagsResult(t *testing.T) {
+	t.Parallel()
+	assertionFile := filepath.Join(t.TempDir(), "assertion.jwt")
+	if err := os.WriteFile(assertionFile, []byte("eyJ.from-file.sig\n"), 0o600); err != nil {
+		t.Fatalf("writing assertion file: %v", err)
+	}
+
+	base := oidc.OIDCConfig{IssuerURL: "https://example.com", ClientID: "client-id"}
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.OIDCConfig
+		flowConf oidc.JWTBearerFlowConfig
+	}{
+		{
+			"mint assertion",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--signing-key", "path/to/key.pem",
+				"--kid", "key-1",
+				"--assertion-issuer", "service-a",
+				"--subject", "alice",
+				"--audience", "https://example.com",
+				"--lifetime", "2m",
+				"--claim", "tenant=acme",
+				"--claim", "level=2",
+				"--claim", `roles=["admin"]`,
+				"--scope", "read",
+			},
+			"",
+			oidc.OIDCConfig{IssuerURL: "https://example.com", ClientID: "client-id", ClientSecret: "client-secret"},
+			oidc.JWTBearerFlowConfig{
+				KeyFile:  "path/to/key.pem",
+				KeyID:    "key-1",
+				Issuer:   "service-a",
+				Subject:  "alice",
+				Audience: "https://example.com",
+				Lifetime: 2 * time.Minute,
+				Claims:   map[string]any{"tenant": "acme", "level": float64(2), "roles": []any{"admin"}},
+				Scope:    "read",
+			},
+		},
+		{
+			"assertion as value",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--assertion", "eyJ.value.sig"},
+			"",
+			base,
+			oidc.JWTBearerFlowConfig{Assertion: "eyJ.value.sig"},
+		},
+		{
+			"assertion from file",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--assertion", "@" + assertionFile},
+			"",
+			base,
+			oidc.JWTBearerFlowConfig{Assertion: "eyJ.from-file.sig"},
+		},
+		{
+			"assertion from stdin",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--assertion", "-"},
+			"eyJ.from-stdin.sig\n",
+			base,
+			oidc.JWTBearerFlowConfig{Assertion: "eyJ.from-stdin.sig"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseJWTBearerFlags(ParseInput{Name: "jwt_bearer", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.JWTBearerFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(f.Config.OIDC, tt.oidcConf) {
+				t.Errorf("OIDC config got %+v, want %+v", f.Config.OIDC, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseJWTBearerFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id", "--assertion", "eyJ.a.b"},
+		},
+		{
+			"missing client id",
+			[]string{"--issuer", "https://example.com", "--assertion", "eyJ.a.b"},
+		},
+		{
+			"neither assertion nor key",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"both assertion and key",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--assertion", "eyJ.a.b", "--signing-key", "key.pem", "--assertion-issuer", "a", "--subject", "a"},
+		},
+		{
+			"minting without subject",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--signing-key", "key.pem", "--assertion-issuer", "a"},
+		},
+		{
+			"negative lifetime",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--signing-key", "key.pem", "--assertion-issuer", "a", "--subject", "a", "--lifetime", "-1m"},
+		},
+		{
+			"dpop without keys",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--assertion", "eyJ.a.b", "--dpop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseJWTBearerFlags(ParseInput{Name: "jwt_bearer", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
+
+func TestParseJWTBearerFlagsInvalidClaim(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"--issuer", "https://example.com", "--client-id", "client-id", "--signing-key", "key.pem", "--assertion-issuer", "a", "--subject", "a", "--claim", "no-value"}
+	_, _, err := parseJWTBearerFlags(ParseInput{Name: "jwt_bearer", Args: args, Conf: &oidc.Config{}})
+	if err == nil || !strings.Contains(err.Error(), "invalid claim") {
+		t.Errorf("err got %v, want an invalid claim error", err)
+	}
+}
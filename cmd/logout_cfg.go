@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func parseLogoutFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.EndSessionEndpoint, "end-session-url", "", "override end session url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+
+	var flowConf oidc.LogoutFlowConfig
+	flags.StringVar(&flowConf.IDTokenHint, "id-token-hint", "", "id token previously issued to the client, or '-' to read it from stdin")
+	flags.StringVar(&flowConf.LogoutHint, "logout-hint", "", "identify the user to log out, eg. by email")
+	flags.StringVar(&flowConf.State, "state", "", "set state parameter (generated when waiting for a loopback redirect)")
+	flags.StringVar(&flowConf.PostLogoutRedirectURI, "post-logout-redirect-uri", "",
+		"where the provider redirects after logout, a loopback uri (eg. http://localhost:9555/logout) is served to confirm the logout")
+
+	runner = &oidc.LogoutFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.IDTokenHint == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "id token hint")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.IDTokenHint = token
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseLogoutFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.Config
+		flowConf oidc.LogoutFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--discovery-url", "https://example.com/.well-known/openid-configuration",
+				"--end-session-url", "https://example.com/logout",
+				"--client-id", "client-id",
+				"--id-token-hint", "id-token",
+				"--logout-hint", "alice@example.com",
+				"--state", "state-123",
+				"--post-logout-redirect-uri", "http://localhost:9555/logout",
+			},
+			"",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:          "https://example.com",
+					DiscoveryEndpoint:  "https://example.com/.well-known/openid-configuration",
+					EndSessionEndpoint: "https://example.com/logout",
+					ClientID:           "client-id",
+				},
+			},
+			oidc.LogoutFlowConfig{
+				IDTokenHint:           "id-token",
+				LogoutHint:            "alice@example.com",
+				State:                 "state-123",
+				PostLogoutRedirectURI: "http://localhost:9555/logout",
+			},
+		},
+		{
+			"id token hint from stdin",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--id-token-hint", "-",
+			},
+			"id-token\n",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			oidc.LogoutFlowConfig{
+				IDTokenHint: "id-token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseLogoutFlags(ParseInput{Name: "logout", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.LogoutFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseLogoutFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name  string
+		args  []string
+		stdin string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id"},
+			"",
+		},
+		{
+			"missing client id",
+			[]string{"--issuer", "https://example.com"},
+			"",
+		},
+		{
+			"empty id token hint on stdin",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--id-token-hint", "-"},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, _, err := parseLogoutFlags(ParseInput{Name: "logout", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func parseLogoutListenFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.JWKSEndpoint, "jwks-url", "", "override jwks url the logout tokens are verified with")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID logout tokens must be addressed to (required)")
+
+	var flowConf oidc.LogoutListenFlowConfig
+	flags.StringVar(&flowConf.ListenURI, "listen-uri", "http://localhost:9555/logout",
+		"uri to receive back-channel (POST) and front-channel (GET) logout requests on")
+
+	runner = &oidc.LogoutListenFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			flowConf.ListenURI == "",
+			"listen-uri is required",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseLogoutListenFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		oidcConf oidc.Config
+		flowConf oidc.LogoutListenFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--discovery-url", "https://example.com/.well-known/openid-configuration",
+				"--jwks-url", "https://example.com/jwks",
+				"--client-id", "client-id",
+				"--listen-uri", "http://localhost:8080/backchannel",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:         "https://example.com",
+					DiscoveryEndpoint: "https://example.com/.well-known/openid-configuration",
+					JWKSEndpoint:      "https://example.com/jwks",
+					ClientID:          "client-id",
+				},
+			},
+			oidc.LogoutListenFlowConfig{
+				ListenURI: "http://localhost:8080/backchannel",
+			},
+		},
+		{
+			"default listen uri",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			oidc.LogoutListenFlowConfig{
+				ListenURI: "http://localhost:9555/logout",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseLogoutListenFlags(ParseInput{Name: "logout-listen", Args: tt.args, Conf: &oidc.Config{}})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.LogoutListenFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseLogoutListenFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id"},
+		},
+		{
+			"missing client id",
+			[]string{"--issuer", "https://example.com"},
+		},
+		{
+			"empty listen uri",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--listen-uri", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseLogoutListenFlags(ParseInput{Name: "logout-listen", Args: tt.args, Conf: &oidc.Config{}})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jentz/oidc-cli/log"
+	"github.com/jentz/oidc-cli/oidc"
+	"golang.org/x/term"
+)
+
+// readPassword prompts for the password on the logger's stderr without
+// echoing it when stdin is a terminal, and otherwise reads it as the first
+// line of stdin so scripts can pipe it in.
+func readPassword(r io.Reader, logger *log.Logger, username string) (string, error) {
+	f, ok := r.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return readTokenFromStdin(r, "password")
+	}
+	logger.Errorf("Password for %s: ", username)
+	password, err := term.ReadPassword(int(f.Fd()))
+	logger.Errorln()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(password), nil
+}
+
+func parsePasswordFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (omit for a public client)")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (eg. for DPoP)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
+
+	var flowConf oidc.PasswordFlowConfig
+	flags.StringVar(&flowConf.Username, "username", "", "resource owner username, the password is prompted for (required)")
+	flags.StringVar(&flowConf.Scope, "scope", "", "set scope as a space separated list")
+	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
+
+	runner = &oidc.PasswordFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			flowConf.Username == "",
+			"username is required",
+		},
+		{
+			flowConf.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
+			"both dpop-private-key and dpop-public-key are required when using DPoP",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	// Prompt only once the arguments are known to be usable
+	flowConf.Password, err = readPassword(in.Stdin, in.Logger, flowConf.Username)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	return runner, buf.String(), nil
+}
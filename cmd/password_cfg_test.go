@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParsePasswordFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.Config
+		flowConf oidc.PasswordFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--discovery-url", "https://example.com/.well-known/openid-configuration",
+				"--token-url", "https://example.com/token",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--username", "alice",
+				"--scope", "openid profile",
+				"--dpop",
+				"--dpop-private-key", "path/to/private-key.pem",
+				"--dpop-public-key", "path/to/public-key.pem",
+			},
+			"s3cret\n",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:         "https://example.com",
+					DiscoveryEndpoint: "https://example.com/.well-known/openid-configuration",
+					TokenEndpoint:     "https://example.com/token",
+					ClientID:          "client-id",
+					ClientSecret:      "client-secret",
+				},
+				DPoPKeys: oidc.DPoPKeys{
+					PrivateKeyFile: "path/to/private-key.pem",
+					PublicKeyFile:  "path/to/public-key.pem",
+				},
+			},
+			oidc.PasswordFlowConfig{
+				Username: "alice",
+				Password: "s3cret",
+				Scope:    "openid profile",
+				DPoP:     true,
+			},
+		},
+		{
+			"public client",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--username", "alice",
+			},
+			"s3cret\n",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL: "https://example.com",
+					ClientID:  "client-id",
+				},
+			},
+			oidc.PasswordFlowConfig{
+				Username: "alice",
+				Password: "s3cret",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parsePasswordFlags(ParseInput{Name: "password", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.PasswordFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParsePasswordFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id", "--username", "alice"},
+		},
+		{
+			"missing client id",
+			[]string{"--issuer", "https://example.com", "--username", "alice"},
+		},
+		{
+			"missing username",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"dpop without keys",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--username", "alice", "--dpop"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parsePasswordFlags(ParseInput{Name: "password", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("s3cret\n")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
+
+func TestParsePasswordFlagsWithoutPassword(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"--issuer", "https://example.com", "--client-id", "client-id", "--username", "alice"}
+	_, _, err := parsePasswordFlags(ParseInput{Name: "password", Args: args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+	if err == nil || !strings.Contains(err.Error(), "no password provided") {
+		t.Errorf("err got %v, want a missing password error", err)
+	}
+}
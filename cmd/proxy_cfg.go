@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/url"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func parseProxyFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	tokens := addTokenSourceFlags(flags, oidcConf)
+
+	var flowConf oidc.ProxyFlowConfig
+	flags.StringVar(&flowConf.Listen, "listen", "localhost:8080", "address to listen on")
+	flags.StringVar(&flowConf.Upstream, "upstream", "", "upstream url to forward requests to (required)")
+
+	flowConf.Tokens = tokens
+	runner = &oidc.ProxyFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if err := completeTokenSource(tokens, in.Stdin); err != nil {
+		return nil, buf.String(), err
+	}
+
+	upstream, parseErr := url.Parse(flowConf.Upstream)
+	var invalidArgsChecks = append([]invalidArgsCheck{
+		{
+			flowConf.Upstream == "",
+			"upstream is required",
+		},
+		{
+			parseErr != nil || upstream.Scheme == "" || upstream.Host == "",
+			"upstream must be an absolute url",
+		},
+	}, tokenSourceChecks(tokens)...)
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseProxyFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		oidcConf oidc.Config
+		tokens   *oidc.TokenSource
+		flowConf oidc.ProxyFlowConfig
+	}{
+		{
+			"client credentials",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--grant", "client_credentials",
+				"--listen", ":9090",
+				"--upstream", "https://api.example.com",
+			},
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			&oidc.TokenSource{Grant: "client_credentials"},
+			oidc.ProxyFlowConfig{
+				Listen:   ":9090",
+				Upstream: "https://api.example.com",
+			},
+		},
+		{
+			"default listen address",
+			[]string{
+				"--access-token", "at",
+				"--upstream", "http://localhost:3000/api",
+			},
+			oidc.Config{},
+			&oidc.TokenSource{AccessToken: "at"},
+			oidc.ProxyFlowConfig{
+				Listen:   "localhost:8080",
+				Upstream: "http://localhost:3000/api",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseProxyFlags(ParseInput{Name: "proxy", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.ProxyFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			tt.tokens.Config = f.Config
+			if !reflect.DeepEqual(f.FlowConfig.Tokens, tt.tokens) {
+				t.Errorf("Tokens got %+v, want %+v", f.FlowConfig.Tokens, tt.tokens)
+			}
+			tt.flowConf.Tokens = f.FlowConfig.Tokens
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseProxyFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing upstream",
+			[]string{"--access-token", "at"},
+		},
+		{
+			"relative upstream",
+			[]string{"--access-token", "at", "--upstream", "api.example.com"},
+		},
+		{
+			"missing token and grant",
+			[]string{"--upstream", "https://api.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseProxyFlags(ParseInput{Name: "proxy", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+// clientMetadataFlags are the flags describing client metadata (RFC 7591
+// §2). Values given as flags override the same fields of --metadata.
+type clientMetadataFlags struct {
+	metadata      string
+	clientName    string
+	redirectURIs  CustomArgsFlag
+	grantTypes    CustomArgsFlag
+	responseTypes CustomArgsFlag
+	contacts      CustomArgsFlag
+	scope         string
+	authMethod    string
+	jwksURI       string
+	dpopBound     bool
+}
+
+func (m *clientMetadataFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&m.metadata, "metadata", "", "client metadata as a JSON object, or '@file' to read it from a file")
+	flags.StringVar(&m.clientName, "client-name", "", "human-readable client name")
+	flags.Var(&m.redirectURIs, "redirect-uri", "redirect uri, argument can be given multiple times")
+	flags.Var(&m.grantTypes, "grant-type", "grant type the client will use, argument can be given multiple times")
+	flags.Var(&m.responseTypes, "response-type", "response type the client will use, argument can be given multiple times")
+	flags.Var(&m.contacts, "contact", "contact email address, argument can be given multiple times")
+	flags.StringVar(&m.scope, "scope", "", "space separated list of scopes the client may request")
+	flags.StringVar(&m.authMethod, "token-endpoint-auth-method", "", "token endpoint auth method (eg. 'client_secret_basic' or 'none')")
+	flags.StringVar(&m.jwksURI, "jwks-uri", "", "url of the client's JSON Web Key Set")
+	flags.BoolVar(&m.dpopBound, "dpop-bound-access-tokens", false, "require DPoP-bound access tokens for the client")
+}
+
+// build returns the metadata to send: --metadata with the flags applied.
+func (m *clientMetadataFlags) build() (map[string]any, error) {
+	metadata := make(map[string]any)
+	raw, err := readRequestBody(m.metadata)
+	if err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse client metadata: %w", err)
+		}
+	}
+
+	for name, value := range map[string]string{
+		"client_name":                m.clientName,
+		"scope":                      m.scope,
+		"token_endpoint_auth_method": m.authMethod,
+		"jwks_uri":                   m.jwksURI,
+	} {
+		if value != "" {
+			metadata[name] = value
+		}
+	}
+	for name, values := range map[string]CustomArgsFlag{
+		"redirect_uris":  m.redirectURIs,
+		"grant_types":    m.grantTypes,
+		"response_types": m.responseTypes,
+		"contacts":       m.contacts,
+	} {
+		if len(values) > 0 {
+			metadata[name] = []string(values)
+		}
+	}
+	if m.dpopBound {
+		metadata["dpop_bound_access_tokens"] = true
+	}
+	return metadata, nil
+}
+
+var registerSubcommands = []Command{
+	{Name: oidc.RegistrationRead, Help: "Read a registered client's configuration.", Configure: parseRegistrationReadFlags},
+	{Name: oidc.RegistrationUpdate, Help: "Replace a registered client's metadata.", Configure: parseRegistrationUpdateFlags},
+	{Name: oidc.RegistrationDelete, Help: "Delete a registered client.", Configure: parseRegistrationDeleteFlags},
+}
+
+// parseRegisterFlags registers a client, or dispatches to the client
+// management subcommand named by the first argument.
+func parseRegisterFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	if len(in.Args) > 0 {
+		idx := slices.IndexFunc(registerSubcommands, func(sub Command) bool {
+			return sub.Name == in.Args[0]
+		})
+		if idx >= 0 {
+			sub := in
+			sub.Name = in.Name + " " + in.Args[0]
+			sub.Args = in.Args[1:]
+			return registerSubcommands[idx].Configure(sub)
+		}
+	}
+
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+	flags.Usage = func() {
+		fmt.Fprintf(&buf, "Usage: oidc-cli %s [flags]\n       oidc-cli %s <subcommand> [flags]\n\nSubcommands:\n", in.Name, in.Name)
+		for _, sub := range registerSubcommands {
+			fmt.Fprintf(&buf, "  %-8s: %s\n", sub.Name, sub.Help)
+		}
+		fmt.Fprintf(&buf, "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required unless registration-url is set)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.RegistrationEndpoint, "registration-url", "", "override registration url")
+
+	flowConf := oidc.RegisterFlowConfig{Operation: oidc.RegistrationCreate}
+	flags.StringVar(&flowConf.AccessToken, "initial-access-token", "", "initial access token, or '-' to read it from stdin")
+	var metadata clientMetadataFlags
+	metadata.register(flags)
+
+	runner = &oidc.RegisterFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.AccessToken == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "initial access token")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.AccessToken = token
+	}
+	flowConf.Metadata, err = metadata.build()
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "" && oidcConf.OIDC.DiscoveryEndpoint == "" && oidcConf.OIDC.RegistrationEndpoint == "",
+			"issuer or registration-url is required",
+		},
+		{
+			flags.NArg() > 0,
+			fmt.Sprintf("unknown subcommand %q", flags.Arg(0)),
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
+
+// parseClientManagementFlags parses the flags every RFC 7592 operation
+// shares: where the client lives and the token that grants access to it.
+// Update also takes the client metadata to replace the registered one with.
+func parseClientManagementFlags(in ParseInput, operation string) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flowConf := oidc.RegisterFlowConfig{Operation: operation}
+	flags.StringVar(&flowConf.ClientURI, "registration-client-uri", "", "registration_client_uri returned at registration (required)")
+	flags.StringVar(&flowConf.AccessToken, "registration-access-token", "", "registration_access_token returned at registration, or '-' to read it from stdin (required)")
+	var metadata clientMetadataFlags
+	if operation == oidc.RegistrationUpdate {
+		flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "client ID of the client to update (required unless in metadata)")
+		metadata.register(flags)
+	}
+
+	runner = &oidc.RegisterFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.AccessToken == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "registration access token")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.AccessToken = token
+	}
+	if operation == oidc.RegistrationUpdate {
+		flowConf.Metadata, err = metadata.build()
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		// The update request must name the client (RFC 7592 §2.2)
+		if _, ok := flowConf.Metadata["client_id"]; !ok && oidcConf.OIDC.ClientID != "" {
+			flowConf.Metadata["client_id"] = oidcConf.OIDC.ClientID
+		}
+	}
+
+	_, hasClientID := flowConf.Metadata["client_id"]
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			flowConf.ClientURI == "",
+			"registration-client-uri is required",
+		},
+		{
+			flowConf.AccessToken == "",
+			"registration-access-token is required",
+		},
+		{
+			operation == oidc.RegistrationUpdate && !hasClientID,
+			"client-id is required",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
+
+func parseRegistrationReadFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	return parseClientManagementFlags(in, oidc.RegistrationRead)
+}
+
+func parseRegistrationUpdateFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	return parseClientManagementFlags(in, oidc.RegistrationUpdate)
+}
+
+func parseRegistrationDeleteFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	return parseClientManagementFlags(in, oidc.RegistrationDelete)
+}
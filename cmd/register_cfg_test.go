@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseRegisterFlagsResult(t *testing.T) {
+	t.Parallel()
+	metadataFile := filepath.Join(t.TempDir(), "client.json")
+	if err := os.WriteFile(metadataFile, []byte(`{"client_name":"from file","application_type":"native"}`), 0o600); err != nil {
+		t.Fatalf("writing metadata file: %v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.OIDCConfig
+		flowConf oidc.RegisterFlowConfig
+	}{
+		{
+			"register from flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--initial-access-token", "-",
+				"--client-name", "demo",
+				"--redirect-uri", "http://localhost:9555/callback",
+				"--redirect-uri", "http://localhost:9556/callback",
+				"--grant-type", "authorization_code",
+				"--scope", "openid profile",
+				"--token-endpoint-auth-method", "none",
+				"--dpop-bound-access-tokens",
+			},
+			"initial-1\n",
+			oidc.OIDCConfig{IssuerURL: "https://example.com"},
+			oidc.RegisterFlowConfig{
+				Operation:   oidc.RegistrationCreate,
+				AccessToken: "initial-1",
+				Metadata: map[string]any{
+					"client_name":                "demo",
+					"redirect_uris":              []string{"http://localhost:9555/callback", "http://localhost:9556/callback"},
+					"grant_types":                []string{"authorization_code"},
+					"scope":                      "openid profile",
+					"token_endpoint_auth_method": "none",
+					"dpop_bound_access_tokens":   true,
+				},
+			},
+		},
+		{
+			"register from file with override",
+			[]string{
+				"--registration-url", "https://example.com/register",
+				"--metadata", "@" + metadataFile,
+				"--client-name", "demo",
+			},
+			"",
+			oidc.OIDCConfig{RegistrationEndpoint: "https://example.com/register"},
+			oidc.RegisterFlowConfig{
+				Operation: oidc.RegistrationCreate,
+				Metadata:  map[string]any{"client_name": "demo", "application_type": "native"},
+			},
+		},
+		{
+			"read",
+			[]string{
+				"read",
+				"--registration-client-uri", "https://example.com/register/c-1",
+				"--registration-access-token", "rat-1",
+			},
+			"",
+			oidc.OIDCConfig{},
+			oidc.RegisterFlowConfig{
+				Operation:   oidc.RegistrationRead,
+				ClientURI:   "https://example.com/register/c-1",
+				AccessToken: "rat-1",
+			},
+		},
+		{
+			"update",
+			[]string{
+				"update",
+				"--registration-client-uri", "https://example.com/register/c-1",
+				"--registration-access-token", "rat-1",
+				"--client-id", "c-1",
+				"--metadata", `{"client_name":"renamed"}`,
+			},
+			"",
+			oidc.OIDCConfig{ClientID: "c-1"},
+			oidc.RegisterFlowConfig{
+				Operation:   oidc.RegistrationUpdate,
+				ClientURI:   "https://example.com/register/c-1",
+				AccessToken: "rat-1",
+				Metadata:    map[string]any{"client_id": "c-1", "client_name": "renamed"},
+			},
+		},
+		{
+			"delete",
+			[]string{
+				"delete",
+				"--registration-client-uri", "https://example.com/register/c-1",
+				"--registration-access-token", "-",
+			},
+			"rat-1\n",
+			oidc.OIDCConfig{},
+			oidc.RegisterFlowConfig{
+				Operation:   oidc.RegistrationDelete,
+				ClientURI:   "https://example.com/register/c-1",
+				AccessToken: "rat-1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseRegisterFlags(ParseInput{Name: "register", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.RegisterFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(f.Config.OIDC, tt.oidcConf) {
+				t.Errorf("OIDC config got %+v, want %+v", f.Config.OIDC, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseRegisterFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-name", "demo"},
+		},
+		{
+			"unknown subcommand",
+			[]string{"--issuer", "https://example.com", "rename"},
+		},
+		{
+			"read missing client uri",
+			[]string{"read", "--registration-access-token", "rat-1"},
+		},
+		{
+			"delete missing access token",
+			[]string{"delete", "--registration-client-uri", "https://example.com/register/c-1"},
+		},
+		{
+			"update missing client id",
+			[]string{"update", "--registration-client-uri", "https://example.com/register/c-1", "--registration-access-token", "rat-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseRegisterFlags(ParseInput{Name: "register", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
+
+func TestParseRegisterFlagsInvalidMetadata(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := parseRegisterFlags(ParseInput{Name: "register", Args: []string{"--issuer", "https://example.com", "--metadata", "{not json"}, Conf: &oidc.Config{}})
+	if err == nil || !strings.Contains(err.Error(), "client metadata") {
+		t.Errorf("err got %v, want a metadata parse error", err)
+	}
+}
+
+func TestParseRegisterFlagsHelpListsSubcommands(t *testing.T) {
+	t.Parallel()
+
+	_, output, err := parseRegisterFlags(ParseInput{Name: "register", Args: []string{"--help"}, Conf: &oidc.Config{}})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("err got %v, want %v", err, flag.ErrHelp)
+	}
+	for _, want := range []string{"read", "update", "delete", "-initial-access-token"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("help output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const resourceUsage = "resource indicator (RFC 8707) of an API the token is for, argument can be given multiple times"
+
+// ResourceFlag collects repeated resource indicators, which must be absolute
+// URIs without a fragment (RFC 8707 §2).
+type ResourceFlag []string
+
+func (*ResourceFlag) String() string {
+	return ""
+}
+
+func (r *ResourceFlag) Set(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() {
+		return fmt.Errorf("invalid resource %q, expected an absolute URI", value)
+	}
+	if strings.Contains(value, "#") {
+		return fmt.Errorf("invalid resource %q, must not contain a fragment", value)
+	}
+	*r = append(*r, value)
+	return nil
+}
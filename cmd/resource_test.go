@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResourceFlagSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "repeated",
+			values: []string{"https://api.example.com", "urn:example:api"},
+			want:   []string{"https://api.example.com", "urn:example:api"},
+		},
+		{name: "relative", values: []string{"/api"}, wantErr: "expected an absolute URI"},
+		{name: "fragment", values: []string{"https://api.example.com/#v1"}, wantErr: "must not contain a fragment"},
+		{name: "empty fragment", values: []string{"https://api.example.com/#"}, wantErr: "must not contain a fragment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var r ResourceFlag
+			var err error
+			for _, value := range tt.values {
+				if err = r.Set(value); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Set() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if !slices.Equal(r, tt.want) {
+				t.Errorf("resources = %q, want %q", r, tt.want)
+			}
+		})
+	}
+}
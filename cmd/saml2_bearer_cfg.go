@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+// encodeSAMLAssertion returns the assertion base64url-encoded as RFC 7522
+// §2.1 requires. A raw XML assertion is encoded; anything else must already
+// be base64url, with or without padding.
+func encodeSAMLAssertion(assertion string) (string, error) {
+	if strings.HasPrefix(assertion, "<") {
+		return base64.RawURLEncoding.EncodeToString([]byte(assertion)), nil
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(assertion, "=")); err != nil {
+		return "", errors.New("assertion is neither XML nor base64url encoded")
+	}
+	return assertion, nil
+}
+
+func parseSAML2BearerFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (omit for a public client)")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+	flags.StringVar(&oidcConf.DPoPKeys.PrivateKeyFile, "dpop-private-key", "", "file to read private key from (eg. for DPoP)")
+	flags.StringVar(&oidcConf.DPoPKeys.PublicKeyFile, "dpop-public-key", "", "file to read public key from (eg. for DPoP)")
+
+	var flowConf oidc.SAML2BearerFlowConfig
+	var assertion string
+	flags.StringVar(&assertion, "assertion", "", "base64url SAML assertion or its XML, '@file' to read it from a file or '-' to read it from stdin (required)")
+	flags.StringVar(&flowConf.Scope, "scope", "", "set scope as a space separated list")
+	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound access tokens")
+
+	runner = &oidc.SAML2BearerFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	flowConf.Assertion, err = readAssertion(assertion, in)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			flowConf.Assertion == "",
+			"assertion is required",
+		},
+		{
+			flowConf.DPoP && (oidcConf.DPoPKeys.PrivateKeyFile == "" || oidcConf.DPoPKeys.PublicKeyFile == ""),
+			"both dpop-private-key and dpop-public-key are required when using DPoP",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	flowConf.Assertion, err = encodeSAMLAssertion(flowConf.Assertion)
+	if err != nil {
+		return nil, err.Error(), errors.New("invalid arguments: " + err.Error())
+	}
+
+	return runner, buf.String(), nil
+}
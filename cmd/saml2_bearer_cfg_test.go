@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseSAML2BearerFlagsResult(t *testing.T) {
+	t.Parallel()
+	xmlFile := filepath.Join(t.TempDir(), "assertion.xml")
+	if err := os.WriteFile(xmlFile, []byte("<saml:Assertion/>\n"), 0o600); err != nil {
+		t.Fatalf("writing assertion file: %v", err)
+	}
+
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		flowConf oidc.SAML2BearerFlowConfig
+	}{
+		{
+			"base64url assertion",
+			[]string{"--assertion", "PHNhbWw6QXNzZXJ0aW9uLz4", "--scope", "read"},
+			"",
+			oidc.SAML2BearerFlowConfig{Assertion: "PHNhbWw6QXNzZXJ0aW9uLz4", Scope: "read"},
+		},
+		{
+			"padded assertion from stdin",
+			[]string{"--assertion", "-", "--dpop", "--dpop-private-key", "priv.pem", "--dpop-public-key", "pub.pem"},
+			"PHNhbWw6QXNzZXJ0aW9uLz4=\n",
+			oidc.SAML2BearerFlowConfig{Assertion: "PHNhbWw6QXNzZXJ0aW9uLz4=", DPoP: true},
+		},
+		{
+			"xml assertion from file",
+			[]string{"--assertion", "@" + xmlFile},
+			"",
+			oidc.SAML2BearerFlowConfig{Assertion: "PHNhbWw6QXNzZXJ0aW9uLz4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			args := append([]string{"--issuer", "https://example.com", "--client-id", "client-id"}, tt.args...)
+			runner, output, err := parseSAML2BearerFlags(ParseInput{Name: "saml2-bearer", Args: args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.SAML2BearerFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if f.Config.OIDC.IssuerURL != "https://example.com" || f.Config.OIDC.ClientID != "client-id" {
+				t.Errorf("OIDC config got %+v, want the issuer and client id", f.Config.OIDC)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseSAML2BearerFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id", "--assertion", "PHNhbWw6QXNzZXJ0aW9uLz4"},
+		},
+		{
+			"missing client id",
+			[]string{"--issuer", "https://example.com", "--assertion", "PHNhbWw6QXNzZXJ0aW9uLz4"},
+		},
+		{
+			"missing assertion",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id"},
+		},
+		{
+			"not base64url",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--assertion", "not+base64/url"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseSAML2BearerFlags(ParseInput{Name: "saml2-bearer", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
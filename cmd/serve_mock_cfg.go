@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/url"
+	"strings"
+
+	"github.com/jentz/oidc-cli/mockop"
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+// FaultFlag collects repeated endpoint=error[:count] faults to inject.
+type FaultFlag []mockop.Fault
+
+func (f *FaultFlag) String() string {
+	faults := make([]string, 0, len(*f))
+	for _, fault := range *f {
+		faults = append(faults, fault.String())
+	}
+	return strings.Join(faults, ",")
+}
+
+func (f *FaultFlag) Set(value string) error {
+	fault, err := mockop.ParseFault(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, fault)
+	return nil
+}
+
+func parseServeMockFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	var flowConf oidc.ServeMockFlowConfig
+	var faults FaultFlag
+	flags.StringVar(&flowConf.Listen, "listen", "localhost:9000", "address to listen on")
+	flags.StringVar(&flowConf.Provider.Issuer, "issuer", "", "issuer url to advertise (defaults to http://<listen address>)")
+	flags.StringVar(&flowConf.Provider.ClientID, "client-id", "", "only accept this client id (any client is accepted when empty)")
+	flags.StringVar(&flowConf.Provider.ClientSecret, "client-secret", "", "only accept this client secret (any secret is accepted when empty)")
+	flags.StringVar(&flowConf.Provider.Subject, "subject", "mock-user", "user to grant authorizations for")
+	flags.BoolVar(&flowConf.Provider.LoginPage, "login-page", false, "show a sign-in page instead of approving every request")
+	flags.DurationVar(&flowConf.Provider.TokenLifetime, "token-lifetime", 0, "lifetime of access and id tokens (default 1h)")
+	flags.Var(&faults, "inject", "inject a fault as endpoint=error[:count], eg. 'token=slow_down:2' (repeatable)")
+
+	runner = &oidc.ServeMockFlow{
+		Config:     in.Conf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+	flowConf.Faults = faults
+
+	issuer, parseErr := url.Parse(flowConf.Provider.Issuer)
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			flowConf.Provider.Issuer != "" && (parseErr != nil || issuer.Scheme == "" || issuer.Host == ""),
+			"issuer must be an absolute url",
+		},
+		{
+			flowConf.Provider.TokenLifetime < 0,
+			"token-lifetime must not be negative",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jentz/oidc-cli/mockop"
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func TestParseServeMockFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		flowConf oidc.ServeMockFlowConfig
+	}{
+		{
+			"defaults",
+			[]string{},
+			oidc.ServeMockFlowConfig{
+				Listen:   "localhost:9000",
+				Provider: mockop.Config{Subject: "mock-user"},
+			},
+		},
+		{
+			"all flags",
+			[]string{
+				"--listen", ":8443",
+				"--issuer", "https://op.example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--subject", "alice",
+				"--login-page",
+				"--token-lifetime", "5m",
+				"--inject", "token=slow_down:2",
+				"--inject", "userinfo=use_dpop_nonce",
+			},
+			oidc.ServeMockFlowConfig{
+				Listen: ":8443",
+				Provider: mockop.Config{
+					Issuer:        "https://op.example.com",
+					ClientID:      "client-id",
+					ClientSecret:  "client-secret",
+					Subject:       "alice",
+					LoginPage:     true,
+					TokenLifetime: 5 * time.Minute,
+				},
+				Faults: []mockop.Fault{
+					{Endpoint: "token", Error: "slow_down", Count: 2},
+					{Endpoint: "userinfo", Error: "use_dpop_nonce"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			conf := &oidc.Config{}
+			runner, output, err := parseServeMockFlags(ParseInput{Name: "serve-mock", Args: tt.args, Conf: conf})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.ServeMockFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			// The provider's issuer is its own; it must not trigger discovery
+			if f.Config != conf || conf.OIDC.IssuerURL != "" {
+				t.Errorf("Config got %+v, want the untouched global config", *f.Config)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseServeMockFlagsError(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"relative issuer",
+			[]string{"--issuer", "op.example.com"},
+		},
+		{
+			"negative token lifetime",
+			[]string{"--token-lifetime", "-1m"},
+		},
+		{
+			"malformed fault",
+			[]string{"--inject", "token"},
+		},
+		{
+			"unknown fault endpoint",
+			[]string{"--inject", "jwks=server_error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseServeMockFlags(ParseInput{Name: "serve-mock", Args: tt.args, Conf: &oidc.Config{}})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
@@ -29,10 +29,12 @@ func parseTokenExchangeFlags(in ParseInput) (runner CommandRunner, output string
 	flags.StringVar(&flowConf.Audience, "audience", "", "audience to be used for the token exchange")
 	flags.StringVar(&flowConf.Scope, "scope", "", "scope to be used for the token exchange")
 	flags.StringVar(&flowConf.RequestedTokenType, "requested-token-type", "", "requested token type to be used for the exchange (eg. 'urn:ietf:params:oauth:token-type:access_token')")
-	flags.StringVar(&flowConf.Resource, "resource", "", "resource to be used for the token exchange")
+	flags.Var((*ResourceFlag)(&flowConf.Resources), "resource", resourceUsage)
 	flags.StringVar(&flowConf.ActorToken, "actor-token", "", "actor token to be used for the token exchange")
 	flags.StringVar(&flowConf.ActorTokenType, "actor-token-type", "", "actor token type to be used for the exchange (eg. 'urn:ietf:params:oauth:token-type:access_token')")
 	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use DPoP-bound access tokens")
+	var authorizationDetails string
+	flags.StringVar(&authorizationDetails, "authorization-details", "", authorizationDetailsUsage)
 
 	runner = &oidc.TokenExchangeFlow{
 		Config:     oidcConf,
@@ -52,6 +54,11 @@ func parseTokenExchangeFlags(in ParseInput) (runner CommandRunner, output string
 		flowConf.SubjectToken = token
 	}
 
+	flowConf.AuthorizationDetails, err = readAuthorizationDetails(authorizationDetails)
+	if err != nil {
+		return nil, err.Error(), errors.New("invalid arguments: " + err.Error())
+	}
+
 	var invalidArgsChecks = []struct {
 		condition bool
 		message   string
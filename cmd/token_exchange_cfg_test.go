@@ -32,7 +32,8 @@ func TestParseTokenExchangeFlagsResult(t *testing.T) {
 				"--audience", "audience",
 				"--scope", "scope",
 				"--requested-token-type", "requested-token-type",
-				"--resource", "resource",
+				"--resource", "https://api.example.com",
+				"--resource", "https://other.example.com/v1",
 				"--dpop",
 				"--dpop-private-key", "path/to/private-key.pem",
 				"--dpop-public-key", "path/to/public-key.pem",
@@ -51,7 +52,7 @@ func TestParseTokenExchangeFlagsResult(t *testing.T) {
 				},
 			},
 			oidc.TokenExchangeFlowConfig{
-				Resource:           "resource",
+				Resources:          []string{"https://api.example.com", "https://other.example.com/v1"},
 				Audience:           "audience",
 				Scope:              "scope",
 				RequestedTokenType: "requested-token-type",
@@ -149,6 +150,16 @@ func TestParseTokenExchangeFlagsError(t *testing.T) {
 			},
 			"flag provided but not defined: -undefined-argument",
 		},
+		{
+			"relative resource",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--subject-token", "subject-token",
+				"--resource", "api",
+			},
+			`invalid value "api" for flag -resource: invalid resource "api", expected an absolute URI`,
+		},
 		{
 			"help flag",
 			[]string{
@@ -26,7 +26,10 @@ func parseTokenRefreshFlags(in ParseInput) (runner CommandRunner, output string,
 	var flowConf oidc.TokenRefreshFlowConfig
 	flags.StringVar(&flowConf.RefreshToken, "refresh-token", "", "refresh token to be used for token refresh")
 	flags.StringVar(&flowConf.Scope, "scope", "", "set scope as a space separated list")
+	flags.Var((*ResourceFlag)(&flowConf.Resources), "resource", resourceUsage)
 	flags.BoolVar(&flowConf.DPoP, "dpop", false, "use dpop-bound refresh tokens")
+	var authorizationDetails string
+	flags.StringVar(&authorizationDetails, "authorization-details", "", authorizationDetailsUsage)
 
 	runner = &oidc.TokenRefreshFlow{
 		Config:     oidcConf,
@@ -46,6 +49,11 @@ func parseTokenRefreshFlags(in ParseInput) (runner CommandRunner, output string,
 		flowConf.RefreshToken = token
 	}
 
+	flowConf.AuthorizationDetails, err = readAuthorizationDetails(authorizationDetails)
+	if err != nil {
+		return nil, err.Error(), errors.New("invalid arguments: " + err.Error())
+	}
+
 	var invalidArgsChecks = []struct {
 		condition bool
 		message   string
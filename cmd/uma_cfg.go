@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+func parseUMAFlags(in ParseInput) (runner CommandRunner, output string, err error) {
+	oidcConf := in.Conf
+	flags := flag.NewFlagSet(in.Name, flag.ContinueOnError)
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+
+	flags.StringVar(&oidcConf.OIDC.IssuerURL, "issuer", oidcConf.OIDC.IssuerURL, "set issuer url (required)")
+	flags.StringVar(&oidcConf.OIDC.DiscoveryEndpoint, "discovery-url", oidcConf.OIDC.DiscoveryEndpoint, "override discovery url")
+	flags.StringVar(&oidcConf.OIDC.TokenEndpoint, "token-url", "", "override token url")
+	flags.StringVar(&oidcConf.OIDC.ClientID, "client-id", oidcConf.OIDC.ClientID, "set client ID (required)")
+	flags.StringVar(&oidcConf.OIDC.ClientSecret, "client-secret", oidcConf.OIDC.ClientSecret, "set client secret (required)")
+	flags.Var(&oidcConf.OIDC.AuthMethod, "auth-method", "auth method to use (client_secret_basic or client_secret_post)")
+
+	var flowConf oidc.UMAFlowConfig
+	flags.StringVar(&flowConf.Ticket, "ticket", "", "permission ticket to exchange")
+	flags.StringVar(&flowConf.ResourceURL, "resource-url", "", "resource to call without a token to obtain a permission ticket")
+	flags.StringVar(&flowConf.ClaimToken, "claim-token", "", "token with claims about the requesting party, or '-' to read it from stdin")
+	flags.StringVar(&flowConf.ClaimTokenFormat, "claim-token-format", "http://openid.net/specs/openid-connect-core-1_0.html#IDToken", "format of the claim token")
+	flags.StringVar(&flowConf.PCT, "pct", "", "persisted claims token from an earlier grant")
+	flags.StringVar(&flowConf.RPT, "rpt", "", "existing requesting party token to upgrade")
+	flags.StringVar(&flowConf.Scope, "scope", "", "set scope as a space separated list")
+
+	runner = &oidc.UMAFlow{
+		Config:     oidcConf,
+		FlowConfig: &flowConf,
+	}
+
+	err = flags.Parse(in.Args)
+	if err != nil {
+		return nil, buf.String(), err
+	}
+
+	if flowConf.ClaimToken == "-" {
+		token, err := readTokenFromStdin(in.Stdin, "claim token")
+		if err != nil {
+			return nil, buf.String(), err
+		}
+		flowConf.ClaimToken = token
+	}
+
+	var invalidArgsChecks = []invalidArgsCheck{
+		{
+			oidcConf.OIDC.IssuerURL == "",
+			"issuer is required",
+		},
+		{
+			oidcConf.OIDC.ClientID == "",
+			"client-id is required",
+		},
+		{
+			oidcConf.OIDC.ClientSecret == "",
+			"client-secret is required",
+		},
+		{
+			(flowConf.Ticket == "") == (flowConf.ResourceURL == ""),
+			"exactly one of ticket or resource-url is required",
+		},
+		{
+			flowConf.ClaimToken != "" && flowConf.ClaimTokenFormat == "",
+			"claim-token-format is required with claim-token",
+		},
+	}
+
+	for _, check := range invalidArgsChecks {
+		if check.condition {
+			return nil, check.message, errors.New("invalid arguments: " + check.message)
+		}
+	}
+
+	return runner, buf.String(), nil
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/oidc"
+)
+
+const idTokenClaimFormat = "http://openid.net/specs/openid-connect-core-1_0.html#IDToken"
+
+func TestParseUMAFlagsResult(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name     string
+		args     []string
+		stdin    string
+		oidcConf oidc.Config
+		flowConf oidc.UMAFlowConfig
+	}{
+		{
+			"all flags",
+			[]string{
+				"--issuer", "https://example.com",
+				"--discovery-url", "https://example.com/.well-known/openid-configuration",
+				"--token-url", "https://example.com/token",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--ticket", "ticket-1",
+				"--claim-token", "-",
+				"--claim-token-format", "urn:ietf:params:oauth:token-type:jwt",
+				"--pct", "pct-1",
+				"--rpt", "rpt-1",
+				"--scope", "view",
+			},
+			"claim-token\n",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:         "https://example.com",
+					DiscoveryEndpoint: "https://example.com/.well-known/openid-configuration",
+					TokenEndpoint:     "https://example.com/token",
+					ClientID:          "client-id",
+					ClientSecret:      "client-secret",
+				},
+			},
+			oidc.UMAFlowConfig{
+				Ticket:           "ticket-1",
+				ClaimToken:       "claim-token",
+				ClaimTokenFormat: "urn:ietf:params:oauth:token-type:jwt",
+				PCT:              "pct-1",
+				RPT:              "rpt-1",
+				Scope:            "view",
+			},
+		},
+		{
+			"ticket from resource",
+			[]string{
+				"--issuer", "https://example.com",
+				"--client-id", "client-id",
+				"--client-secret", "client-secret",
+				"--resource-url", "https://api.example.com/photos/1",
+			},
+			"",
+			oidc.Config{
+				OIDC: oidc.OIDCConfig{
+					IssuerURL:    "https://example.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				},
+			},
+			oidc.UMAFlowConfig{
+				ResourceURL:      "https://api.example.com/photos/1",
+				ClaimTokenFormat: idTokenClaimFormat,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner, output, err := parseUMAFlags(ParseInput{Name: "uma", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader(tt.stdin)})
+			if err != nil {
+				t.Fatalf("err got %v, want nil", err)
+			}
+			if output != "" {
+				t.Errorf("output got %q, want empty", output)
+			}
+			f, ok := runner.(*oidc.UMAFlow)
+			if !ok {
+				t.Fatalf("unexpected runner type: %T", runner)
+			}
+			if !reflect.DeepEqual(*f.Config, tt.oidcConf) {
+				t.Errorf("Config got %+v, want %+v", *f.Config, tt.oidcConf)
+			}
+			if !reflect.DeepEqual(*f.FlowConfig, tt.flowConf) {
+				t.Errorf("FlowConfig got %+v, want %+v", *f.FlowConfig, tt.flowConf)
+			}
+		})
+	}
+}
+
+func TestParseUMAFlagsError(t *testing.T) {
+	t.Parallel()
+	client := []string{"--issuer", "https://example.com", "--client-id", "client-id", "--client-secret", "client-secret"}
+	var tests = []struct {
+		name string
+		args []string
+	}{
+		{
+			"missing issuer",
+			[]string{"--client-id", "client-id", "--client-secret", "client-secret", "--ticket", "ticket-1"},
+		},
+		{
+			"missing client secret",
+			[]string{"--issuer", "https://example.com", "--client-id", "client-id", "--ticket", "ticket-1"},
+		},
+		{
+			"neither ticket nor resource",
+			client,
+		},
+		{
+			"both ticket and resource",
+			append(append([]string{}, client...), "--ticket", "ticket-1", "--resource-url", "https://api.example.com"),
+		},
+		{
+			"claim token without format",
+			append(append([]string{}, client...), "--ticket", "ticket-1", "--claim-token", "token", "--claim-token-format", ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, output, err := parseUMAFlags(ParseInput{Name: "uma", Args: tt.args, Conf: &oidc.Config{}, Stdin: strings.NewReader("")})
+			if err == nil {
+				t.Errorf("err got nil, want error")
+			}
+			if output == "" {
+				t.Errorf("output got empty, want error message")
+			}
+		})
+	}
+}
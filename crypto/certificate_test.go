@@ -0,0 +1,46 @@
+package crypto
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGenerateSelfSignedCertificate(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := GenerateSelfSignedCertificate([]string{"localhost", "127.0.0.1", "::1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateSelfSignedCertificate() error = %v", err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	if !slices.Equal(cert.DNSNames, []string{"localhost"}) {
+		t.Errorf("DNSNames = %q, want localhost", cert.DNSNames)
+	}
+	if len(cert.IPAddresses) != 2 || !cert.IPAddresses[0].Equal(net.IPv4(127, 0, 0, 1)) || !cert.IPAddresses[1].Equal(net.IPv6loopback) {
+		t.Errorf("IPAddresses = %v, want the loopback addresses", cert.IPAddresses)
+	}
+	if lifetime := time.Until(cert.NotAfter); lifetime > time.Hour || lifetime < 59*time.Minute {
+		t.Errorf("certificate expires in %s, want an hour", lifetime)
+	}
+	// Self-signed: the certificate verifies against itself
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: roots}); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	if _, _, err := GenerateSelfSignedCertificate(nil, time.Hour); err == nil {
+		t.Error("GenerateSelfSignedCertificate() without hosts error = nil, want an error")
+	}
+}
@@ -32,6 +32,7 @@ type rsaJWK struct {
 
 type ed25519JWK struct {
 	PublicKey string `json:"x"`
+	Crv       string `json:"crv"`
 	Kty       string `json:"kty"`
 }
 
@@ -42,6 +43,8 @@ type DPoPProofBuilder struct {
 	privateKey    any
 	method        string
 	url           string
+	nonce         string
+	ath           string
 	jti           string
 	alg           string
 	jwk           any
@@ -95,15 +98,49 @@ func (d *DPoPProofBuilder) Method(s string) *DPoPProofBuilder {
 	return d
 }
 
+// URL sets the htu claim. The query and fragment are dropped, as the proof
+// names the target URI without them (RFC 9449 §4.2).
 func (d *DPoPProofBuilder) URL(s string) *DPoPProofBuilder {
-	_, err := url.Parse(s)
+	u, err := url.Parse(s)
 	if err != nil {
 		d.errs = append(d.errs, fmt.Errorf("error parsing url: %w", err))
+		d.url = s
+		return d
 	}
-	d.url = s
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	d.url = u.String()
 	return d
 }
 
+// Nonce sets the server-provided nonce claim (RFC 9449 §8). An empty nonce
+// leaves the claim out.
+func (d *DPoPProofBuilder) Nonce(s string) *DPoPProofBuilder {
+	d.nonce = s
+	return d
+}
+
+// AccessToken binds the proof to an access token through the ath claim, the
+// base64url SHA-256 hash of the token that resource servers require
+// (RFC 9449 §4.2). An empty token leaves the claim out.
+func (d *DPoPProofBuilder) AccessToken(s string) *DPoPProofBuilder {
+	if s == "" {
+		d.ath = ""
+		return d
+	}
+	d.ath = AccessTokenHash(s)
+	return d
+}
+
+// AccessTokenHash returns the ath value for an access token: the base64url
+// encoded SHA-256 hash of its ASCII representation.
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
 func (d *DPoPProofBuilder) Build() (*DPoPProof, error) {
 	if len(d.errs) > 0 {
 		return nil, errors.Join(d.errs...)
@@ -177,6 +214,12 @@ func (d *DPoPProofBuilder) constructJWT() {
 		"htu": d.url,
 		"iat": time.Now().Unix(),
 	}
+	if d.nonce != "" {
+		claims["nonce"] = d.nonce
+	}
+	if d.ath != "" {
+		claims["ath"] = d.ath
+	}
 	d.token = jwt.NewWithClaims(d.signingMethod, claims)
 	d.token.Header = header
 }
@@ -224,6 +267,7 @@ func rsaPublicKeyToJWK(k *rsa.PublicKey) any {
 func ed25519PublicKeyToJWK(k ed25519.PublicKey) any {
 	return &ed25519JWK{
 		PublicKey: base64.RawURLEncoding.EncodeToString(k),
+		Crv:       "Ed25519",
 		Kty:       "OKP",
 	}
 }
@@ -308,6 +308,108 @@ func TestConstructJWT(t *testing.T) {
 	}
 }
 
+func TestConstructJWTOptionalClaims(t *testing.T) {
+	t.Parallel()
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name        string
+		nonce       string
+		accessToken string
+		wantClaims  map[string]any
+		wantAbsent  []string
+	}{
+		{
+			name:       "no nonce or access token",
+			wantAbsent: []string{"nonce", "ath"},
+		},
+		{
+			name:       "nonce only",
+			nonce:      "server-nonce",
+			wantClaims: map[string]any{"nonce": "server-nonce"},
+			wantAbsent: []string{"ath"},
+		},
+		{
+			// The access token and ath value are the RFC 9449 §7.1 example.
+			name:        "nonce and access token",
+			nonce:       "server-nonce",
+			accessToken: "Kz~8mXK1EalYznwH-LC-1fBAo.4Ljp~zsPE_NeO.gxU",
+			wantClaims: map[string]any{
+				"nonce": "server-nonce",
+				"ath":   "fUHyO2r2Z3DZ53EsNrWBb0xWXoaNy59IiKCAqksmQEo",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			builder := NewDPoPProofBuilder().
+				PublicKey(&privateKey.PublicKey).
+				PrivateKey(privateKey).
+				Method("GET").
+				URL("https://rs.example.com/resource").
+				Nonce(tt.nonce).
+				AccessToken(tt.accessToken)
+			if _, err := builder.Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			claims, ok := builder.token.Claims.(jwt.MapClaims)
+			if !ok {
+				t.Fatalf("token.Claims type = %T, want jwt.MapClaims", builder.token.Claims)
+			}
+			for k, want := range tt.wantClaims {
+				if claims[k] != want {
+					t.Errorf("claims[%q] = %v, want %v", k, claims[k], want)
+				}
+			}
+			for _, k := range tt.wantAbsent {
+				if _, ok := claims[k]; ok {
+					t.Errorf("claims[%q] = %v, want absent", k, claims[k])
+				}
+			}
+		})
+	}
+}
+
+func TestDPoPProofBuilderURL(t *testing.T) {
+	t.Parallel()
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantHTU string
+	}{
+		{name: "plain", url: "https://rs.example.com/resource", wantHTU: "https://rs.example.com/resource"},
+		{name: "query", url: "https://rs.example.com/resource?page=2", wantHTU: "https://rs.example.com/resource"},
+		{name: "fragment", url: "https://rs.example.com/resource#top", wantHTU: "https://rs.example.com/resource"},
+		{name: "empty query", url: "https://rs.example.com/resource?", wantHTU: "https://rs.example.com/resource"},
+		{name: "query and fragment", url: "https://rs.example.com/items?page=2&sort=asc#top", wantHTU: "https://rs.example.com/items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			builder := NewDPoPProofBuilder().
+				PublicKey(&privateKey.PublicKey).
+				PrivateKey(privateKey).
+				Method("GET").
+				URL(tt.url)
+			if _, err := builder.Build(); err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			claims, ok := builder.token.Claims.(jwt.MapClaims)
+			if !ok {
+				t.Fatalf("token.Claims type = %T, want jwt.MapClaims", builder.token.Claims)
+			}
+			if claims["htu"] != tt.wantHTU {
+				t.Errorf("claims[\"htu\"] = %v, want %v", claims["htu"], tt.wantHTU)
+			}
+		})
+	}
+}
+
 func TestSignJWT(t *testing.T) {
 	t.Parallel()
 	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
@@ -0,0 +1,353 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // RSA-OAEP is defined with SHA-1 (RFC 7518 §4.3)
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"maps"
+	"strings"
+)
+
+// JWE key management algorithms (RFC 7518 §4) supported for encryption and
+// decryption. ECDH-ES is used in Direct Key Agreement mode.
+const (
+	JWEAlgRSAOAEP    = "RSA-OAEP"
+	JWEAlgRSAOAEP256 = "RSA-OAEP-256"
+	JWEAlgECDHES     = "ECDH-ES"
+)
+
+// JWE content encryption algorithms (RFC 7518 §5) supported.
+const (
+	JWEEncA128GCM = "A128GCM"
+	JWEEncA256GCM = "A256GCM"
+)
+
+// EncryptionKey returns the key a client should encrypt to: the first key
+// marked for encryption that JWEAlgorithm can encrypt to, or failing that the
+// first such key with no use.
+func (s JWKS) EncryptionKey() (JWK, bool) {
+	for _, key := range s.Keys {
+		if _, err := JWEAlgorithm(key); key.Use == "enc" && err == nil {
+			return key, true
+		}
+	}
+	for _, key := range s.Keys {
+		if _, err := JWEAlgorithm(key); key.Use == "" && err == nil {
+			return key, true
+		}
+	}
+	return JWK{}, false
+}
+
+// JWEAlgorithm returns the key management algorithm to encrypt to a key: its
+// alg member when set, RSA-OAEP-256 for RSA and ECDH-ES for EC keys.
+func JWEAlgorithm(key JWK) (string, error) {
+	switch key.Alg {
+	case JWEAlgRSAOAEP, JWEAlgRSAOAEP256, JWEAlgECDHES:
+		return key.Alg, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported JWE algorithm: %q", key.Alg)
+	}
+	switch key.Kty {
+	case "RSA":
+		return JWEAlgRSAOAEP256, nil
+	case "EC":
+		return JWEAlgECDHES, nil
+	default:
+		return "", fmt.Errorf("unsupported key type for encryption: %q", key.Kty)
+	}
+}
+
+// EncryptJWE encrypts plaintext to the public part of key as a compact JWE
+// with content encryption enc. The members of header, such as cty, are added
+// to the protected header alongside alg, enc and the key's kid.
+func EncryptJWE(plaintext []byte, key JWK, enc string, header map[string]any) (string, error) {
+	alg, err := JWEAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+	keySize, err := jweKeySize(enc)
+	if err != nil {
+		return "", err
+	}
+	public, err := key.PublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	protected := map[string]any{"alg": alg, "enc": enc}
+	if key.Kid != "" {
+		protected["kid"] = key.Kid
+	}
+	maps.Copy(protected, header)
+
+	var cek, encryptedKey []byte
+	switch alg {
+	case JWEAlgRSAOAEP, JWEAlgRSAOAEP256:
+		rsaKey, ok := public.(*rsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("%s requires an RSA key", alg)
+		}
+		cek = make([]byte, keySize)
+		if _, err := rand.Read(cek); err != nil {
+			return "", fmt.Errorf("error generating content encryption key: %w", err)
+		}
+		encryptedKey, err = rsa.EncryptOAEP(oaepHash(alg), rand.Reader, rsaKey, cek, nil)
+		if err != nil {
+			return "", fmt.Errorf("error encrypting content encryption key: %w", err)
+		}
+	case JWEAlgECDHES:
+		ecKey, ok := public.(*ecdsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("%s requires an EC key", alg)
+		}
+		recipient, err := ecKey.ECDH()
+		if err != nil {
+			return "", fmt.Errorf("error converting EC key: %w", err)
+		}
+		ephemeral, err := recipient.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("error generating ephemeral key: %w", err)
+		}
+		z, err := ephemeral.ECDH(recipient)
+		if err != nil {
+			return "", fmt.Errorf("error computing shared secret: %w", err)
+		}
+		protected["epk"] = ephemeralJWK(ephemeral.PublicKey(), key.Crv)
+		cek = concatKDF(z, enc, keySize)
+	}
+
+	headerJSON, err := json.Marshal(protected)
+	if err != nil {
+		return "", fmt.Errorf("error encoding JWE header: %w", err)
+	}
+	encodedHeader := b64(headerJSON)
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("error generating iv: %w", err)
+	}
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(encodedHeader))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{encodedHeader, b64(encryptedKey), b64(iv), b64(ciphertext), b64(tag)}, "."), nil
+}
+
+// IsJWE reports whether token has the five parts of a compact JWE rather than
+// the three of a JWS.
+func IsJWE(token string) bool {
+	return strings.Count(token, ".") == 4
+}
+
+// DecryptJWE decrypts a compact JWE with a *rsa.PrivateKey or
+// *ecdsa.PrivateKey and returns the plaintext and protected header.
+func DecryptJWE(token string, key any) ([]byte, map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, nil, errors.New("JWE must have five parts")
+	}
+	decoded := make([][]byte, 5)
+	for i, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("JWE part %d is not base64url: %w", i+1, err)
+		}
+		decoded[i] = b
+	}
+
+	var header map[string]any
+	if err := json.Unmarshal(decoded[0], &header); err != nil {
+		return nil, nil, fmt.Errorf("error parsing JWE header: %w", err)
+	}
+	alg, _ := header["alg"].(string)
+	enc, _ := header["enc"].(string)
+	keySize, err := jweKeySize(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cek []byte
+	switch alg {
+	case JWEAlgRSAOAEP, JWEAlgRSAOAEP256:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s requires an RSA private key", alg)
+		}
+		cek, err = rsa.DecryptOAEP(oaepHash(alg), nil, rsaKey, decoded[1], nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error decrypting content encryption key: %w", err)
+		}
+	case JWEAlgECDHES:
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s requires an EC private key", alg)
+		}
+		cek, err = ecdhESKey(ecKey, header, enc, keySize)
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported JWE algorithm: %q", alg)
+	}
+	if len(cek) != keySize {
+		return nil, nil, errors.New("content encryption key has the wrong size")
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(decoded[2]) != gcm.NonceSize() {
+		return nil, nil, errors.New("JWE iv has the wrong size")
+	}
+	plaintext, err := gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decrypting JWE: %w", err)
+	}
+	return plaintext, header, nil
+}
+
+// ecdhESKey derives the content encryption key from the sender's ephemeral
+// public key in the epk header, with the party information the apu and apv
+// headers carry.
+func ecdhESKey(key *ecdsa.PrivateKey, header map[string]any, enc string, keySize int) ([]byte, error) {
+	partyU, err := partyInfo(header, "apu")
+	if err != nil {
+		return nil, err
+	}
+	partyV, err := partyInfo(header, "apv")
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(header["epk"])
+	if err != nil {
+		return nil, fmt.Errorf("error reading epk header: %w", err)
+	}
+	var epkJWK JWK
+	if err := json.Unmarshal(raw, &epkJWK); err != nil {
+		return nil, fmt.Errorf("error reading epk header: %w", err)
+	}
+	public, err := epkJWK.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid epk header: %w", err)
+	}
+	ephemeral, ok := public.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("epk header must be an EC key")
+	}
+	sender, err := ephemeral.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("invalid epk header: %w", err)
+	}
+	recipient, err := key.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("error converting EC key: %w", err)
+	}
+	z, err := recipient.ECDH(sender)
+	if err != nil {
+		return nil, fmt.Errorf("error computing shared secret: %w", err)
+	}
+	return concatKDFWithParties(z, enc, partyU, partyV, keySize), nil
+}
+
+// partyInfo decodes the base64url apu or apv header named name, nil when it
+// is absent.
+func partyInfo(header map[string]any, name string) ([]byte, error) {
+	value, ok := header[name]
+	if !ok {
+		return nil, nil
+	}
+	encoded, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s header must be a string", name)
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", name, err)
+	}
+	return decoded, nil
+}
+
+// concatKDF derives a key of keySize bytes from the shared secret z with the
+// Concat KDF of NIST SP 800-56A as profiled by RFC 7518 §4.6.2. The apu and
+// apv headers are never sent, so PartyUInfo and PartyVInfo are empty when
+// encrypting.
+func concatKDF(z []byte, algID string, keySize int) []byte {
+	return concatKDFWithParties(z, algID, nil, nil, keySize)
+}
+
+func concatKDFWithParties(z []byte, algID string, partyU, partyV []byte, keySize int) []byte {
+	var otherInfo []byte
+	for _, field := range [][]byte{[]byte(algID), partyU, partyV} {
+		otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(field))) //nolint:gosec // header values are short
+		otherInfo = append(otherInfo, field...)
+	}
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keySize*8)) //nolint:gosec // key sizes are small
+
+	var derived []byte
+	for counter := uint32(1); len(derived) < keySize; counter++ {
+		h := sha256.New()
+		h.Write(binary.BigEndian.AppendUint32(nil, counter))
+		h.Write(z)
+		h.Write(otherInfo)
+		derived = h.Sum(derived)
+	}
+	return derived[:keySize]
+}
+
+func ephemeralJWK(public *ecdh.PublicKey, crv string) map[string]string {
+	raw := public.Bytes() // uncompressed: 0x04 || X || Y
+	coordSize := (len(raw) - 1) / 2
+	return map[string]string{
+		"kty": "EC",
+		"crv": crv,
+		"x":   b64(raw[1 : 1+coordSize]),
+		"y":   b64(raw[1+coordSize:]),
+	}
+}
+
+func jweKeySize(enc string) (int, error) {
+	switch enc {
+	case JWEEncA128GCM:
+		return 16, nil
+	case JWEEncA256GCM:
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("unsupported JWE content encryption: %q", enc)
+	}
+}
+
+func oaepHash(alg string) hash.Hash {
+	if alg == JWEAlgRSAOAEP {
+		return sha1.New() //nolint:gosec // RSA-OAEP is defined with SHA-1 (RFC 7518 §4.3)
+	}
+	return sha256.New()
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return gcm, nil
+}
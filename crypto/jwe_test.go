@@ -0,0 +1,224 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestJWERoundTrip(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ecdsa key: %v", err)
+	}
+	ec521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ecdsa key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     any
+		alg     string
+		enc     string
+		wantAlg string
+	}{
+		{name: "rsa default", key: rsaKey, enc: JWEEncA256GCM, wantAlg: JWEAlgRSAOAEP256},
+		{name: "rsa-oaep", key: rsaKey, alg: JWEAlgRSAOAEP, enc: JWEEncA128GCM, wantAlg: JWEAlgRSAOAEP},
+		{name: "ecdh-es p-256", key: ecKey, enc: JWEEncA256GCM, wantAlg: JWEAlgECDHES},
+		{name: "ecdh-es p-521", key: ec521Key, enc: JWEEncA128GCM, wantAlg: JWEAlgECDHES},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			jwk, err := NewJWK(tt.key)
+			if err != nil {
+				t.Fatalf("NewJWK() error = %v", err)
+			}
+			public := jwk.Public()
+			public.Alg = tt.alg
+			public.Use = "enc"
+
+			token, err := EncryptJWE([]byte("secret payload"), public, tt.enc, map[string]any{"cty": "JWT"})
+			if err != nil {
+				t.Fatalf("EncryptJWE() error = %v", err)
+			}
+			if !IsJWE(token) {
+				t.Fatalf("IsJWE(%q) = false, want true", token)
+			}
+
+			plaintext, header, err := DecryptJWE(token, tt.key)
+			if err != nil {
+				t.Fatalf("DecryptJWE() error = %v", err)
+			}
+			if string(plaintext) != "secret payload" {
+				t.Errorf("plaintext = %q, want %q", plaintext, "secret payload")
+			}
+			for name, want := range map[string]string{"alg": tt.wantAlg, "enc": tt.enc, "cty": "JWT", "kid": jwk.Kid} {
+				if header[name] != want {
+					t.Errorf("header %s = %v, want %s", name, header[name], want)
+				}
+			}
+
+			// Tampering with the protected header must break the authentication tag.
+			parts := strings.Split(token, ".")
+			parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + tt.wantAlg + `","enc":"` + tt.enc + `"}`))
+			if _, _, err := DecryptJWE(strings.Join(parts, "."), tt.key); err == nil {
+				t.Error("DecryptJWE() of a tampered JWE error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDecryptJWEWrongKey(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ecdsa key: %v", err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ecdsa key: %v", err)
+	}
+	jwk, err := NewJWK(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("NewJWK() error = %v", err)
+	}
+	jwk.Alg = ""
+	token, err := EncryptJWE([]byte("payload"), jwk, JWEEncA256GCM, nil)
+	if err != nil {
+		t.Fatalf("EncryptJWE() error = %v", err)
+	}
+	if _, _, err := DecryptJWE(token, other); err == nil {
+		t.Error("DecryptJWE() with another key error = nil, want error")
+	}
+	if _, _, err := DecryptJWE("a.b.c", key); err == nil {
+		t.Error("DecryptJWE() of a JWS error = nil, want error")
+	}
+}
+
+// TestConcatKDF checks the key derivation against RFC 7518 Appendix C.
+func TestConcatKDF(t *testing.T) {
+	t.Parallel()
+	z := []byte{
+		158, 86, 217, 29, 129, 113, 53, 211, 114, 131, 66, 131, 191, 132,
+		38, 156, 251, 49, 110, 163, 218, 128, 106, 72, 246, 218, 167, 121,
+		140, 254, 144, 196,
+	}
+	got := concatKDFWithParties(z, "A128GCM", []byte("Alice"), []byte("Bob"), 16)
+	if want := "VqqN6vgjbSBcIijNcacQGg"; base64.RawURLEncoding.EncodeToString(got) != want {
+		t.Errorf("concatKDF() = %s, want %s", base64.RawURLEncoding.EncodeToString(got), want)
+	}
+}
+
+// TestECDHESKeyPartyInfo derives the key of RFC 7518 Appendix C, whose
+// header carries apu and apv.
+func TestECDHESKeyPartyInfo(t *testing.T) {
+	t.Parallel()
+	decode := func(s string) *big.Int {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", s, err)
+		}
+		return new(big.Int).SetBytes(b)
+	}
+	bob := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     decode("weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ"),
+			Y:     decode("e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck"),
+		},
+		D: decode("VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"),
+	}
+	header := map[string]any{
+		"alg": "ECDH-ES",
+		"enc": "A128GCM",
+		"apu": "QWxpY2U",
+		"apv": "Qm9i",
+		"epk": map[string]any{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   "gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0",
+			"y":   "SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps",
+		},
+	}
+	got, err := ecdhESKey(bob, header, "A128GCM", 16)
+	if err != nil {
+		t.Fatalf("ecdhESKey() error = %v", err)
+	}
+	if want := "VqqN6vgjbSBcIijNcacQGg"; base64.RawURLEncoding.EncodeToString(got) != want {
+		t.Errorf("ecdhESKey() = %s, want %s", base64.RawURLEncoding.EncodeToString(got), want)
+	}
+
+	header["apu"] = "not base64!"
+	if _, err := ecdhESKey(bob, header, "A128GCM", 16); err == nil {
+		t.Error("ecdhESKey() with an invalid apu error = nil, want error")
+	}
+}
+
+func TestJWKSEncryptionKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		keys    []JWK
+		wantKid string
+		wantOK  bool
+	}{
+		{
+			name:    "key marked for encryption wins",
+			keys:    []JWK{{Kty: "EC", Kid: "sig", Use: "sig"}, {Kty: "RSA", Kid: "any"}, {Kty: "RSA", Kid: "enc", Use: "enc"}},
+			wantKid: "enc",
+			wantOK:  true,
+		},
+		{
+			name:    "key without use is the fallback",
+			keys:    []JWK{{Kty: "EC", Kid: "sig", Use: "sig"}, {Kty: "OKP", Kid: "okp"}, {Kty: "RSA", Kid: "rs256", Alg: "RS256"}, {Kty: "RSA", Kid: "any"}},
+			wantKid: "any",
+			wantOK:  true,
+		},
+		{
+			name:    "encryption key with an unsupported alg is skipped",
+			keys:    []JWK{{Kty: "RSA", Kid: "rsa1_5", Use: "enc", Alg: "RSA1_5"}, {Kty: "OKP", Kid: "okp", Use: "enc"}, {Kty: "EC", Kid: "enc", Use: "enc"}},
+			wantKid: "enc",
+			wantOK:  true,
+		},
+		{
+			name: "unsupported encryption keys only",
+			keys: []JWK{{Kty: "RSA", Kid: "rsa1_5", Use: "enc", Alg: "RSA1_5"}},
+		},
+		{
+			name: "signing keys only",
+			keys: []JWK{{Kty: "EC", Kid: "sig", Use: "sig"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := JWKS{Keys: tt.keys}.EncryptionKey()
+			if ok != tt.wantOK || got.Kid != tt.wantKid {
+				t.Errorf("EncryptionKey() = %q, %v, want %q, %v", got.Kid, ok, tt.wantKid, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJWEAlgorithmUnsupported(t *testing.T) {
+	t.Parallel()
+	for _, key := range []JWK{{Kty: "RSA", Alg: "RSA1_5"}, {Kty: "OKP"}} {
+		if _, err := JWEAlgorithm(key); err == nil {
+			t.Errorf("JWEAlgorithm(%+v) error = nil, want error", key)
+		}
+	}
+}
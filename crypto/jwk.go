@@ -0,0 +1,283 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a JSON Web Key (RFC 7517) holding an EC, RSA or OKP (Ed25519) key.
+// The private members are empty for a public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	DP  string `json:"dp,omitempty"`
+	DQ  string `json:"dq,omitempty"`
+	QI  string `json:"qi,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, the document served from a jwks_uri.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key returns the key with the given kid. An empty kid matches the only key
+// of a single-key set, which is how issuers with one key often omit it.
+func (s JWKS) Key(kid string) (JWK, bool) {
+	if kid == "" && len(s.Keys) == 1 {
+		return s.Keys[0], true
+	}
+	for _, key := range s.Keys {
+		if kid != "" && key.Kid == kid {
+			return key, true
+		}
+	}
+	return JWK{}, false
+}
+
+// NewJWK encodes a public or private key as a JWK. The kid is the key's RFC 7638
+// thumbprint and alg the signing algorithm SigningAlgorithm picks for it.
+func NewJWK(key any) (JWK, error) {
+	var jwk JWK
+	var public any
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		d, err := k.Bytes()
+		if err != nil {
+			return JWK{}, fmt.Errorf("error encoding ecdsa private key: %w", err)
+		}
+		jwk.D = b64(d)
+		public = &k.PublicKey
+	case *rsa.PrivateKey:
+		if len(k.Primes) != 2 {
+			return JWK{}, errors.New("multi-prime rsa keys are not supported")
+		}
+		k.Precompute()
+		jwk.D = b64(k.D.Bytes())
+		jwk.P = b64(k.Primes[0].Bytes())
+		jwk.Q = b64(k.Primes[1].Bytes())
+		jwk.DP = b64(k.Precomputed.Dp.Bytes())
+		jwk.DQ = b64(k.Precomputed.Dq.Bytes())
+		jwk.QI = b64(k.Precomputed.Qinv.Bytes())
+		public = &k.PublicKey
+	case ed25519.PrivateKey:
+		jwk.D = b64(k.Seed())
+		public = k.Public()
+	default:
+		public = key
+	}
+
+	switch k := public.(type) {
+	case *ecdsa.PublicKey:
+		ec, _ := ecdsaPublicKeyToJWK(k).(*ecdsaJWK)
+		jwk.Kty, jwk.Crv, jwk.X, jwk.Y = ec.Kty, ec.Crv, ec.X, ec.Y
+	case *rsa.PublicKey:
+		rsaKey, _ := rsaPublicKeyToJWK(k).(*rsaJWK)
+		jwk.Kty, jwk.N, jwk.E = rsaKey.Kty, rsaKey.Modulus, rsaKey.Exponent
+	case ed25519.PublicKey:
+		okp, _ := ed25519PublicKeyToJWK(k).(*ed25519JWK)
+		jwk.Kty, jwk.Crv, jwk.X = okp.Kty, okp.Crv, okp.PublicKey
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type: %T", key)
+	}
+
+	var err error
+	if jwk.Kid, err = JWKThumbprint(public); err != nil {
+		return JWK{}, err
+	}
+	if jwk.Alg, err = SigningAlgorithm(public); err != nil {
+		return JWK{}, err
+	}
+	return jwk, nil
+}
+
+// Public returns the key with its private members removed.
+func (k JWK) Public() JWK {
+	k.D, k.P, k.Q, k.DP, k.DQ, k.QI = "", "", "", "", "", ""
+	return k
+}
+
+// PublicKey decodes the public part of the key into a *ecdsa.PublicKey,
+// *rsa.PublicKey or ed25519.PublicKey.
+func (k JWK) PublicKey() (any, error) {
+	switch k.Kty {
+	case "EC":
+		curve, err := jwkCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := k.coordinate(k.X, curve)
+		if err != nil {
+			return nil, err
+		}
+		y, err := k.coordinate(k.Y, curve)
+		if err != nil {
+			return nil, err
+		}
+		uncompressed := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(curve, uncompressed)
+	case "RSA":
+		n, err := decodeB64(k.N, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeB64(k.E, "e")
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent is too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve: %q", k.Crv)
+		}
+		x, err := decodeB64(k.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", k.Kty)
+	}
+}
+
+// PrivateKey decodes the key into a *ecdsa.PrivateKey, *rsa.PrivateKey or
+// ed25519.PrivateKey. It fails for a public-only key.
+func (k JWK) PrivateKey() (crypto.Signer, error) {
+	if k.D == "" {
+		return nil, errors.New("jwk has no private key")
+	}
+	d, err := decodeB64(k.D, "d")
+	if err != nil {
+		return nil, err
+	}
+	switch k.Kty {
+	case "EC":
+		curve, err := jwkCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.ParseRawPrivateKey(curve, d)
+	case "RSA":
+		return k.rsaPrivateKey(d)
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve: %q", k.Crv)
+		}
+		if len(d) != ed25519.SeedSize {
+			return nil, errors.New("invalid Ed25519 private key size")
+		}
+		return ed25519.NewKeyFromSeed(d), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", k.Kty)
+	}
+}
+
+func (k JWK) rsaPrivateKey(d []byte) (*rsa.PrivateKey, error) {
+	public, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeB64(k.P, "p")
+	if err != nil {
+		return nil, err
+	}
+	q, err := decodeB64(k.Q, "q")
+	if err != nil {
+		return nil, err
+	}
+	rsaPublic, _ := public.(*rsa.PublicKey)
+	key := &rsa.PrivateKey{
+		PublicKey: *rsaPublic,
+		D:         new(big.Int).SetBytes(d),
+		Primes:    []*big.Int{new(big.Int).SetBytes(p), new(big.Int).SetBytes(q)},
+	}
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rsa private key: %w", err)
+	}
+	key.Precompute()
+	return key, nil
+}
+
+// coordinate decodes an EC coordinate, which must be exactly the curve's
+// coordinate size (RFC 7518 §6.2.1.2).
+func (k JWK) coordinate(s string, curve elliptic.Curve) ([]byte, error) {
+	b, err := decodeB64(s, "coordinate")
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != (curve.Params().BitSize+7)/8 {
+		return nil, fmt.Errorf("invalid %s coordinate size", k.Crv)
+	}
+	return b, nil
+}
+
+func jwkCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported EC curve: %q", crv)
+	}
+}
+
+// SigningAlgorithm returns the JWS algorithm this package signs with for a
+// public key: ES256/384/512 by curve, RS256/384/512 by modulus size, or EdDSA.
+func SigningAlgorithm(publicKey any) (string, error) {
+	var alg string
+	switch k := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		alg = ecdsaAlgorithmString(k)
+	case *rsa.PublicKey:
+		alg = rsaAlgorithmString(k)
+	case ed25519.PublicKey:
+		alg = ed25519AlgorithmString()
+	default:
+		return "", fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+	if alg == "" {
+		return "", fmt.Errorf("no supported signing algorithm for key type %T: ECDSA must use P-256, P-384, or P-521, and RSA must be at least 2048 bits", publicKey)
+	}
+	return alg, nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeB64(s, member string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("jwk member %q is missing", member)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("jwk member %q is not base64url: %w", member, err)
+	}
+	return b, nil
+}
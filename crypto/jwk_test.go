@@ -0,0 +1,149 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+)
+
+type equaler interface {
+	Equal(x crypto.PublicKey) bool
+}
+
+func testSigners(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ecdsa key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ed25519 key: %v", err)
+	}
+	return map[string]crypto.Signer{"ecdsa": ecKey, "rsa": rsaKey, "ed25519": edKey}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for name, key := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			jwk, err := NewJWK(key)
+			if err != nil {
+				t.Fatalf("NewJWK() error = %v", err)
+			}
+
+			// Survive a JSON round trip, as a key file or JWKS document would
+			encoded, err := json.Marshal(jwk)
+			if err != nil {
+				t.Fatalf("marshaling jwk: %v", err)
+			}
+			var decoded JWK
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("unmarshaling jwk: %v", err)
+			}
+
+			private, err := decoded.PrivateKey()
+			if err != nil {
+				t.Fatalf("PrivateKey() error = %v", err)
+			}
+			if eq, ok := private.(interface{ Equal(crypto.PrivateKey) bool }); !ok || !eq.Equal(key) {
+				t.Errorf("PrivateKey() does not match the encoded key")
+			}
+
+			public, err := decoded.Public().PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey() error = %v", err)
+			}
+			if eq, ok := public.(equaler); !ok || !eq.Equal(key.Public()) {
+				t.Errorf("PublicKey() does not match the encoded key")
+			}
+
+			thumbprint, err := JWKThumbprint(key.Public())
+			if err != nil {
+				t.Fatalf("JWKThumbprint() error = %v", err)
+			}
+			if jwk.Kid != thumbprint {
+				t.Errorf("Kid = %q, want the thumbprint %q", jwk.Kid, thumbprint)
+			}
+			if jwk.Alg == "" {
+				t.Error("Alg is empty")
+			}
+		})
+	}
+}
+
+func TestJWKPublicHasNoPrivateMembers(t *testing.T) {
+	t.Parallel()
+
+	for name, key := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			jwk, err := NewJWK(key)
+			if err != nil {
+				t.Fatalf("NewJWK() error = %v", err)
+			}
+			public := jwk.Public()
+			if public.D != "" || public.P != "" || public.Q != "" || public.DP != "" || public.DQ != "" || public.QI != "" {
+				t.Errorf("Public() = %+v, want no private members", public)
+			}
+			if _, err := public.PrivateKey(); err == nil {
+				t.Error("PrivateKey() error = nil for a public key")
+			}
+		})
+	}
+}
+
+func TestJWKPublicKeyErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		jwk  JWK
+	}{
+		{"unknown kty", JWK{Kty: "oct"}},
+		{"unknown curve", JWK{Kty: "EC", Crv: "secp256k1", X: "AA", Y: "AA"}},
+		{"short coordinate", JWK{Kty: "EC", Crv: "P-256", X: "AA", Y: "AA"}},
+		{"missing modulus", JWK{Kty: "RSA", E: "AQAB"}},
+		{"bad base64", JWK{Kty: "OKP", Crv: "Ed25519", X: "!!"}},
+		{"unknown okp curve", JWK{Kty: "OKP", Crv: "X25519", X: "AA"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := tt.jwk.PublicKey(); err == nil {
+				t.Error("PublicKey() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestJWKSKey(t *testing.T) {
+	t.Parallel()
+
+	set := JWKS{Keys: []JWK{{Kty: "EC", Kid: "one"}, {Kty: "EC", Kid: "two"}}}
+	if key, ok := set.Key("two"); !ok || key.Kid != "two" {
+		t.Errorf("Key(two) = %+v, %v", key, ok)
+	}
+	if _, ok := set.Key("three"); ok {
+		t.Error("Key(three) found a key")
+	}
+	if _, ok := set.Key(""); ok {
+		t.Error("Key(\"\") matched in a multi-key set")
+	}
+	single := JWKS{Keys: []JWK{{Kty: "EC", Kid: "only"}}}
+	if key, ok := single.Key(""); !ok || key.Kid != "only" {
+		t.Errorf("Key(\"\") on a single-key set = %+v, %v", key, ok)
+	}
+}
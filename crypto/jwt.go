@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"maps"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SignJWT signs claims with key, using the algorithm SigningAlgorithm picks for
+// its public half. The members of header, such as kid and typ, are added to
+// the JOSE header.
+func SignJWT(key crypto.Signer, claims jwt.Claims, header map[string]any) (string, error) {
+	alg, err := SigningAlgorithm(key.Public())
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(alg), claims)
+	maps.Copy(token.Header, header)
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("error signing JWT: %w", err)
+	}
+	return signed, nil
+}
+
+// verifyAlgorithms are the JWS algorithms VerifyJWT accepts. "none" and the
+// HMAC algorithms are never among them, as a key set holds no shared secrets.
+var verifyAlgorithms = []string{
+	"ES256", "ES384", "ES512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"EdDSA",
+}
+
+// VerifyJWT parses a signed JWT and verifies its signature with the key in
+// keys that its kid header names. The options add claim validation, such as
+// jwt.WithIssuer and jwt.WithAudience; exp and nbf are always checked when
+// present.
+func VerifyJWT(token string, keys JWKS, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	keyFunc := func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		jwk, ok := keys.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("no key with kid %q in the key set", kid)
+		}
+		if jwk.Alg != "" && jwk.Alg != t.Method.Alg() {
+			return nil, fmt.Errorf("key %q is for alg %s, not %s", kid, jwk.Alg, t.Method.Alg())
+		}
+		return jwk.PublicKey()
+	}
+	opts = append([]jwt.ParserOption{jwt.WithValidMethods(verifyAlgorithms)}, opts...)
+	if _, err := jwt.ParseWithClaims(token, claims, keyFunc, opts...); err != nil {
+		return nil, fmt.Errorf("error verifying JWT: %w", err)
+	}
+	return claims, nil
+}
+
+// TokenHash computes the at_hash or c_hash claim an ID token signed with alg
+// carries for value (OpenID Connect Core §3.1.3.6 and §3.3.2.11): the left
+// half of the hash of value, base64url-encoded, with the hash alg uses.
+func TokenHash(alg, value string) (string, error) {
+	var h hash.Hash
+	switch alg {
+	case "ES256", "RS256", "PS256":
+		h = sha256.New()
+	case "ES384", "RS384", "PS384":
+		h = sha512.New384()
+	case "ES512", "RS512", "PS512", "EdDSA":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported algorithm for token hash: %q", alg)
+	}
+	h.Write([]byte(value))
+	sum := h.Sum(nil)
+	return b64(sum[:len(sum)/2]), nil
+}
@@ -0,0 +1,153 @@
+package crypto
+
+import (
+	"crypto"
+	"encoding/base64"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestSignJWTWithKeyTypes(t *testing.T) {
+	t.Parallel()
+
+	for name, key := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			signed, err := SignJWT(key, jwt.MapClaims{"sub": "alice"}, map[string]any{"kid": "k1", "typ": "at+jwt"})
+			if err != nil {
+				t.Fatalf("SignJWT() error = %v", err)
+			}
+
+			token, err := jwt.Parse(signed, func(*jwt.Token) (any, error) { return key.Public(), nil })
+			if err != nil {
+				t.Fatalf("verifying signed JWT: %v", err)
+			}
+			if token.Header["kid"] != "k1" || token.Header["typ"] != "at+jwt" {
+				t.Errorf("header = %v, want kid and typ set", token.Header)
+			}
+			want, _ := SigningAlgorithm(key.Public())
+			if token.Header["alg"] != want {
+				t.Errorf("alg = %v, want %v", token.Header["alg"], want)
+			}
+			if sub, _ := token.Claims.GetSubject(); sub != "alice" {
+				t.Errorf("sub = %q, want alice", sub)
+			}
+		})
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	t.Parallel()
+
+	for name, key := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			jwk, err := NewJWK(key)
+			if err != nil {
+				t.Fatalf("NewJWK() error = %v", err)
+			}
+			keys := JWKS{Keys: []JWK{jwk.Public()}}
+			signed, err := SignJWT(key, jwt.MapClaims{"iss": "https://op.example.com", "sub": "alice"}, map[string]any{"kid": jwk.Kid})
+			if err != nil {
+				t.Fatalf("SignJWT() error = %v", err)
+			}
+
+			claims, err := VerifyJWT(signed, keys, jwt.WithIssuer("https://op.example.com"))
+			if err != nil {
+				t.Fatalf("VerifyJWT() error = %v", err)
+			}
+			if claims["sub"] != "alice" {
+				t.Errorf("sub = %v, want alice", claims["sub"])
+			}
+
+			if _, err := VerifyJWT(signed, keys, jwt.WithIssuer("https://other.example.com")); err == nil {
+				t.Error("VerifyJWT() with a different issuer error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyJWTRejects(t *testing.T) {
+	t.Parallel()
+
+	signers := testSigners(t)
+	key, other := signers["ecdsa"], signers["ed25519"]
+	jwk, err := NewJWK(key)
+	if err != nil {
+		t.Fatalf("NewJWK() error = %v", err)
+	}
+	otherJWK, err := NewJWK(other)
+	if err != nil {
+		t.Fatalf("NewJWK() error = %v", err)
+	}
+	keys := JWKS{Keys: []JWK{jwk.Public(), otherJWK.Public()}}
+
+	sign := func(t *testing.T, signer crypto.Signer, kid string) string {
+		t.Helper()
+		signed, err := SignJWT(signer, jwt.MapClaims{"sub": "alice"}, map[string]any{"kid": kid})
+		if err != nil {
+			t.Fatalf("SignJWT() error = %v", err)
+		}
+		return signed
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "alice"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("building unsigned JWT: %v", err)
+	}
+
+	tests := map[string]string{
+		"unknown kid":         sign(t, key, "unknown"),
+		"signed by other key": sign(t, other, jwk.Kid),
+		"alg none":            unsigned,
+		"malformed":           "not-a-jwt",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := VerifyJWT(token, keys); err == nil {
+				t.Error("VerifyJWT() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTokenHash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		alg     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		// The at_hash and c_hash of the OpenID Connect Core Appendix A examples.
+		{name: "at_hash", alg: "RS256", value: "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y", want: "77QmUPtjPfzWtF2AnpK9RQ"},
+		{name: "c_hash", alg: "ES256", value: "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk", want: "LDktKdoQak3Pk0cnXxCltA"},
+		{name: "unsupported alg", alg: "HS256", value: "token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := TokenHash(tt.alg, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TokenHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TokenHash() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Longer hashes keep their left half too.
+	for alg, size := range map[string]int{"ES384": 24, "PS512": 32, "EdDSA": 32} {
+		got, err := TokenHash(alg, "token")
+		if err != nil {
+			t.Fatalf("TokenHash(%s) error = %v", alg, err)
+		}
+		if decoded, _ := base64.RawURLEncoding.DecodeString(got); len(decoded) != size {
+			t.Errorf("TokenHash(%s) has %d bytes, want %d", alg, len(decoded), size)
+		}
+	}
+}
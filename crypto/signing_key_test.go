@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadSigningKeyFromFile(t *testing.T) {
+	t.Parallel()
+
+	for name, key := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				t.Fatalf("marshaling key: %v", err)
+			}
+			pemFile := filepath.Join(dir, "key.pem")
+			if err := os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+				t.Fatalf("writing PEM file: %v", err)
+			}
+
+			jwk, err := NewJWK(key)
+			if err != nil {
+				t.Fatalf("NewJWK() error = %v", err)
+			}
+			raw, _ := json.Marshal(jwk)
+			jwkFile := filepath.Join(dir, "key.json")
+			if err := os.WriteFile(jwkFile, raw, 0o600); err != nil {
+				t.Fatalf("writing JWK file: %v", err)
+			}
+
+			for file, wantKid := range map[string]string{pemFile: "", jwkFile: jwk.Kid} {
+				got, kid, err := ReadSigningKeyFromFile(file)
+				if err != nil {
+					t.Fatalf("ReadSigningKeyFromFile(%s) error = %v", filepath.Base(file), err)
+				}
+				if !reflect.DeepEqual(got.Public(), key.Public()) {
+					t.Errorf("ReadSigningKeyFromFile(%s) returned a different key", filepath.Base(file))
+				}
+				if kid != wantKid {
+					t.Errorf("ReadSigningKeyFromFile(%s) kid = %q, want %q", filepath.Base(file), kid, wantKid)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSigningKeyFromFileErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"not a key":   "hello",
+		"public jwk":  `{"kty":"EC","crv":"P-256","x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU","y":"x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0"}`,
+		"invalid jwk": `{"kty":`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			file := filepath.Join(dir, name)
+			if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+				t.Fatalf("writing file: %v", err)
+			}
+			if _, _, err := ReadSigningKeyFromFile(file); err == nil {
+				t.Error("ReadSigningKeyFromFile() error = nil, want an error")
+			}
+		})
+	}
+
+	if _, _, err := ReadSigningKeyFromFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ReadSigningKeyFromFile() error = nil, want an error for a missing file")
+	}
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// The thumbprint input members are declared in lexicographic order so that
+// encoding/json, which emits struct fields in declaration order without
+// whitespace, produces the RFC 7638 canonical form directly.
+
+type ecdsaThumbprintInput struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type rsaThumbprintInput struct {
+	E   string `json:"e"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+}
+
+type okpThumbprintInput struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+}
+
+// JWKThumbprint computes the RFC 7638 SHA-256 thumbprint of a public key,
+// base64url-encoded without padding. It accepts the key types a DPoP proof can
+// be signed with, and the result is the value RFC 9449 uses for dpop_jkt and
+// the cnf.jkt confirmation claim.
+func JWKThumbprint(publicKey any) (string, error) {
+	var input any
+	switch k := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		jwk, ok := ecdsaPublicKeyToJWK(k).(*ecdsaJWK)
+		if !ok {
+			return "", fmt.Errorf("unexpected jwk type for %T", k)
+		}
+		input = ecdsaThumbprintInput{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.X, Y: jwk.Y}
+	case *rsa.PublicKey:
+		jwk, ok := rsaPublicKeyToJWK(k).(*rsaJWK)
+		if !ok {
+			return "", fmt.Errorf("unexpected jwk type for %T", k)
+		}
+		input = rsaThumbprintInput{E: jwk.Exponent, Kty: jwk.Kty, N: jwk.Modulus}
+	case ed25519.PublicKey:
+		jwk, ok := ed25519PublicKeyToJWK(k).(*ed25519JWK)
+		if !ok {
+			return "", fmt.Errorf("unexpected jwk type for %T", k)
+		}
+		input = okpThumbprintInput{Crv: jwk.Crv, Kty: jwk.Kty, X: jwk.PublicKey}
+	default:
+		return "", fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+
+	canonical, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("error encoding jwk thumbprint input: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
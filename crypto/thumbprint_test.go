@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"crypto/dsa" //nolint:staticcheck // SA1019: used to exercise an unsupported key type
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"testing"
+)
+
+func mustDecodeB64URL(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", s, err)
+	}
+	return b
+}
+
+// TestJWKThumbprintKnownVectors pins the canonicalization against the worked
+// examples in RFC 7638 §3.1 (RSA) and RFC 8037 §A.3 (Ed25519).
+func TestJWKThumbprintKnownVectors(t *testing.T) {
+	t.Parallel()
+
+	rsaKey := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(mustDecodeB64URL(t, "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")),
+		E: 65537,
+	}
+	edKey := ed25519.PublicKey(mustDecodeB64URL(t, "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"))
+
+	tests := []struct {
+		name string
+		key  any
+		want string
+	}{
+		{"RFC 7638 RSA example", rsaKey, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+		{"RFC 8037 Ed25519 example", edKey, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := JWKThumbprint(tt.key)
+			if err != nil {
+				t.Fatalf("JWKThumbprint() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("JWKThumbprint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestJWKThumbprintECDSA checks the EC canonical form member order and that
+// coordinates are zero-padded the same way the DPoP proof's jwk header is.
+func TestJWKThumbprintECDSA(t *testing.T) {
+	t.Parallel()
+
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			t.Parallel()
+			priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+			if err != nil {
+				t.Fatalf("generating key: %v", err)
+			}
+			jwk, ok := ecdsaPublicKeyToJWK(&priv.PublicKey).(*ecdsaJWK)
+			if !ok {
+				t.Fatal("ecdsaPublicKeyToJWK did not return *ecdsaJWK")
+			}
+			canonical := `{"crv":"` + jwk.Crv + `","kty":"EC","x":"` + jwk.X + `","y":"` + jwk.Y + `"}`
+			sum := sha256.Sum256([]byte(canonical))
+			want := base64.RawURLEncoding.EncodeToString(sum[:])
+
+			got, err := JWKThumbprint(&priv.PublicKey)
+			if err != nil {
+				t.Fatalf("JWKThumbprint() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("JWKThumbprint() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestJWKThumbprintUnsupportedKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		key  any
+	}{
+		{"nil", nil},
+		{"dsa", &dsa.PublicKey{}},
+		{"private key", ed25519.PrivateKey(make([]byte, ed25519.PrivateKeySize))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := JWKThumbprint(tt.key); err == nil {
+				t.Error("JWKThumbprint() error = nil, want unsupported key error")
+			}
+		})
+	}
+}
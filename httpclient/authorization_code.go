@@ -1,11 +1,14 @@
 package httpclient
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/jentz/oidc-cli/webflow"
@@ -17,10 +20,14 @@ const (
 )
 
 type AuthorizationCodeRequest struct {
+	// ResponseType defaults to code; the implicit and hybrid response types
+	// such as "id_token token" and "code id_token" are supported too.
+	ResponseType        string
 	ClientID            string
 	RedirectURI         string
 	Scope               string
 	State               string
+	Nonce               string
 	Prompt              string
 	AcrValues           string
 	LoginHint           string
@@ -29,18 +36,64 @@ type AuthorizationCodeRequest struct {
 	CodeChallengeMethod string
 	CodeChallenge       string
 	RequestURI          string
-	CustomArgs          *CustomArgs
+	// Request is a signed (and optionally encrypted) request object carrying
+	// the authorization parameters (RFC 9101).
+	Request string
+	// ResponseMode selects how the authorization response is returned, such
+	// as jwt for a JWT-secured authorization response (JARM).
+	ResponseMode string
+	DPoPJKT      string
+	// AuthorizationDetails is a JSON array of RFC 9396 authorization details.
+	AuthorizationDetails string
+	// Claims is the claims request parameter (OpenID Connect Core §5.5) as
+	// JSON.
+	Claims string
+	// Resources are resource indicators (RFC 8707), each sent as a resource
+	// parameter.
+	Resources  []string
+	CustomArgs *CustomArgs
+	// Expect is not sent; it is what the response is checked against, kept
+	// apart so it survives the parameters moving into a request object.
+	// Without it, the response is checked against the request's own
+	// response type and state.
+	Expect *ExpectedResponse
+}
+
+// ExpectedResponse is what an authorization response must match.
+type ExpectedResponse struct {
+	ResponseType string
+	State        string
+	// Nonce is the nonce an ID token from the authorization endpoint must
+	// carry.
+	Nonce string
+	// Issuer is the iss parameter the response must carry, if any (RFC 9207).
+	Issuer string
+}
+
+// expected returns what the response to req must match.
+func (req *AuthorizationCodeRequest) expected() ExpectedResponse {
+	if req.Expect != nil {
+		return *req.Expect
+	}
+	return ExpectedResponse{ResponseType: req.ResponseType, State: req.State, Nonce: req.Nonce}
 }
 
 type AuthorizationCodeResponse struct {
 	Code  string
 	State string
+	// Tokens returned from the authorization endpoint by the implicit and
+	// hybrid response types.
+	IDToken     string
+	AccessToken string
+	TokenType   string
+	ExpiresIn   string
+	Scope       string
 }
 
 // CreateAuthorizationCodeRequestValues builds the authorization request URI.
 func CreateAuthorizationCodeRequestValues(req *AuthorizationCodeRequest) (*url.Values, error) {
 	values := &url.Values{}
-	values.Set("response_type", "code")
+	values.Set("response_type", responseType(req.ResponseType))
 
 	// Add required parameters
 	if req.ClientID == "" {
@@ -52,6 +105,9 @@ func CreateAuthorizationCodeRequestValues(req *AuthorizationCodeRequest) (*url.V
 	if req.State != "" {
 		values.Set("state", req.State)
 	}
+	if req.Nonce != "" {
+		values.Set("nonce", req.Nonce)
+	}
 	if req.RedirectURI != "" {
 		values.Set("redirect_uri", req.RedirectURI)
 	}
@@ -82,6 +138,24 @@ func CreateAuthorizationCodeRequestValues(req *AuthorizationCodeRequest) (*url.V
 	if req.RequestURI != "" {
 		values.Set("request_uri", req.RequestURI)
 	}
+	if req.Request != "" {
+		values.Set("request", req.Request)
+	}
+	if req.ResponseMode != "" {
+		values.Set("response_mode", req.ResponseMode)
+	}
+	if req.AuthorizationDetails != "" {
+		values.Set("authorization_details", req.AuthorizationDetails)
+	}
+	if req.Claims != "" {
+		values.Set("claims", req.Claims)
+	}
+	if req.DPoPJKT != "" {
+		values.Set("dpop_jkt", req.DPoPJKT)
+	}
+	for _, resource := range req.Resources {
+		values.Add("resource", resource)
+	}
 
 	// Add custom args
 	if req.CustomArgs != nil {
@@ -110,8 +184,9 @@ func CreateAuthorizationCodeRequestURL(endpoint string, values *url.Values) (str
 
 // ExecuteAuthorizationCodeRequest starts the callback server, opens the
 // authorization URL, waits for the redirect, and validates code and state.
-func (c *Client) ExecuteAuthorizationCodeRequest(ctx context.Context, endpoint, callback string, req *AuthorizationCodeRequest) (*AuthorizationCodeResponse, error) {
-	server, err := c.startCallbackServer(ctx, callback)
+// Options configure the callback server, such as webflow.WithJARM.
+func (c *Client) ExecuteAuthorizationCodeRequest(ctx context.Context, endpoint, callback string, req *AuthorizationCodeRequest, opts ...webflow.Option) (*AuthorizationCodeResponse, error) {
+	server, err := c.startCallbackServer(ctx, callback, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -134,9 +209,102 @@ func (c *Client) ExecuteAuthorizationCodeRequest(ctx context.Context, endpoint,
 	return validateCallbackResponse(req, callbackResp)
 }
 
+// ExecutePastedAuthorizationCodeRequest is ExecuteAuthorizationCodeRequest
+// for hosts where neither a browser nor the loopback port is reachable: it
+// prints the authorization URL and reads the redirect URL the user pastes
+// from in, validating it like a redirect received on the callback server.
+// A JWT-secured response is decoded with decode when it is set.
+func (c *Client) ExecutePastedAuthorizationCodeRequest(ctx context.Context, endpoint string, req *AuthorizationCodeRequest, in io.Reader, decode webflow.JARMDecoder) (*AuthorizationCodeResponse, error) {
+	requestURL, err := buildAuthorizationURL(endpoint, req)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Errorf("open this URL in a browser to continue:\n\n  %s\n\n", requestURL)
+	c.logger.Errorf("then paste the URL you were redirected to, or just the code: ")
+
+	line, err := readPastedLine(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	params, err := parsePastedRedirect(line)
+	if err != nil {
+		return nil, err
+	}
+	if params == nil {
+		// A bare code carries no state or iss to check; that is only as safe
+		// as the user's copying from the browser they just logged in with.
+		expect := req.expected()
+		if responseType(expect.ResponseType) != "code" || decode != nil {
+			return nil, errors.New("paste the full redirect URL, the response carries more than a code")
+		}
+		return &AuthorizationCodeResponse{Code: line, State: expect.State}, nil
+	}
+	return validateCallbackResponse(req, webflow.ParseCallbackResponse(ctx, params, decode))
+}
+
+// readPastedLine reads one line from in, giving up when ctx is done.
+func readPastedLine(ctx context.Context, in io.Reader) (string, error) {
+	if in == nil {
+		return "", errors.New("no input to read the redirect URL from")
+	}
+	type result struct {
+		line string
+		err  error
+	}
+	lines := make(chan result, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		lines <- result{strings.TrimSpace(line), err}
+	}()
+	select {
+	case r := <-lines:
+		if r.line == "" {
+			if r.err != nil && !errors.Is(r.err, io.EOF) {
+				return "", fmt.Errorf("failed to read the redirect URL: %w", r.err)
+			}
+			return "", errors.New("no redirect URL was pasted")
+		}
+		return r.line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// parsePastedRedirect returns the response parameters of a pasted redirect
+// URL, taken from its query and fragment, or of a bare query string. It
+// returns nil parameters for a bare code.
+func parsePastedRedirect(pasted string) (url.Values, error) {
+	if strings.Contains(pasted, "://") {
+		u, err := url.Parse(pasted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect URL: %w", err)
+		}
+		params := u.Query()
+		if u.Fragment != "" {
+			fragment, err := url.ParseQuery(u.Fragment)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redirect URL fragment: %w", err)
+			}
+			for name, values := range fragment {
+				params[name] = append(params[name], values...)
+			}
+		}
+		return params, nil
+	}
+	if !strings.Contains(pasted, "=") {
+		return nil, nil
+	}
+	params, err := url.ParseQuery(strings.TrimLeft(pasted, "?#"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect parameters: %w", err)
+	}
+	return params, nil
+}
+
 // startCallbackServer starts the callback server in the background, returning
-// once it is listening or failing fast on a startup error or timeout.
-func (c *Client) startCallbackServer(ctx context.Context, callback string) (*webflow.CallbackServer, error) {
+// once it is listening or failing fast on a startup error or timeout. Options
+// are applied after the client's listen function.
+func (c *Client) startCallbackServer(ctx context.Context, callback string, opts ...webflow.Option) (*webflow.CallbackServer, error) {
 	if callback == "" {
 		return nil, errors.New("callback URL is required")
 	}
@@ -144,7 +312,7 @@ func (c *Client) startCallbackServer(ctx context.Context, callback string) (*web
 		return nil, ctx.Err()
 	}
 
-	server, err := webflow.NewCallbackServer(callback, c.logger, webflow.WithListenFunc(c.listen))
+	server, err := webflow.NewCallbackServer(callback, c.logger, append([]webflow.Option{webflow.WithListenFunc(c.listen)}, opts...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create callback server: %w", err)
 	}
@@ -192,8 +360,8 @@ func buildAuthorizationURL(endpoint string, req *AuthorizationCodeRequest) (stri
 	return requestURL, nil
 }
 
-// validateCallbackResponse checks the redirect's state (CSRF defense) and that
-// an authorization code is present.
+// validateCallbackResponse checks the redirect's state (CSRF defense) and
+// issuer (mix-up defense) and that an authorization code is present.
 func validateCallbackResponse(req *AuthorizationCodeRequest, resp *webflow.CallbackResponse) (*AuthorizationCodeResponse, error) {
 	if req == nil {
 		return nil, errors.New("request cannot be nil")
@@ -202,17 +370,47 @@ func validateCallbackResponse(req *AuthorizationCodeRequest, resp *webflow.Callb
 		return nil, errors.New("response cannot be nil")
 	}
 
-	// Reject a mismatched state to prevent CSRF.
-	if req.State != "" && resp.State != req.State {
-		return nil, fmt.Errorf("state mismatch: expected %q but got %q", req.State, resp.State)
+	expect := req.expected()
+	// Reject a mismatched state to prevent CSRF. An error without a state,
+	// such as a response JWT that failed verification, is reported as is:
+	// it carries no code to redeem.
+	stateless := resp.State == "" && resp.ErrorMsg != ""
+	if expect.State != "" && resp.State != expect.State && !stateless {
+		return nil, fmt.Errorf("state mismatch: expected %q but got %q", expect.State, resp.State)
+	}
+	// A response naming another issuer is a mix-up attack (RFC 9207 §2.4)
+	if expect.Issuer != "" && resp.Issuer != "" && resp.Issuer != expect.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: expected %q but got %q", expect.Issuer, resp.Issuer)
 	}
 
-	if resp.Code == "" {
+	if resp.ErrorMsg != "" || (resp.Code == "" && resp.IDToken == "" && resp.AccessToken == "") {
 		return nil, fmt.Errorf("authorization failed with error %s and description %s", resp.ErrorMsg, resp.ErrorDescription)
 	}
 
+	// Every part of the response type must be present (OAuth 2.0 Multiple
+	// Response Type Encoding Practices §3).
+	returned := map[string]bool{"code": resp.Code != "", "id_token": resp.IDToken != "", "token": resp.AccessToken != ""}
+	for _, part := range strings.Fields(responseType(expect.ResponseType)) {
+		if !returned[part] {
+			return nil, fmt.Errorf("authorization response for response_type %q lacks %s", responseType(expect.ResponseType), part)
+		}
+	}
+
 	return &AuthorizationCodeResponse{
-		Code:  resp.Code,
-		State: req.State,
+		Code:        resp.Code,
+		State:       expect.State,
+		IDToken:     resp.IDToken,
+		AccessToken: resp.AccessToken,
+		TokenType:   resp.TokenType,
+		ExpiresIn:   resp.ExpiresIn,
+		Scope:       resp.Scope,
 	}, nil
 }
+
+// responseType returns the response_type of a request, code unless set.
+func responseType(rt string) string {
+	if rt == "" {
+		return "code"
+	}
+	return rt
+}
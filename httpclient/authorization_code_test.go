@@ -3,10 +3,13 @@ package httpclient
 import (
 	"context"
 	"errors"
+	"io"
 	"net/url"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/jentz/oidc-cli/log"
 	"github.com/jentz/oidc-cli/webflow"
 )
 
@@ -29,21 +32,52 @@ func TestCreateAuthorizationCodeRequestValues(t *testing.T) {
 				"client_id":     "test-client",
 			},
 		},
+		{
+			name: "request object",
+			req: &AuthorizationCodeRequest{
+				ClientID: "test-client",
+				Scope:    "openid",
+				Request:  "eyJ.request.object",
+			},
+			wantParams: map[string]string{
+				"response_type": "code",
+				"client_id":     "test-client",
+				"scope":         "openid",
+				"request":       "eyJ.request.object",
+			},
+		},
+		{
+			name: "hybrid response type with nonce",
+			req: &AuthorizationCodeRequest{
+				ResponseType: "code id_token",
+				ClientID:     "test-client",
+				Nonce:        "nonce-123",
+			},
+			wantParams: map[string]string{
+				"response_type": "code id_token",
+				"client_id":     "test-client",
+				"nonce":         "nonce-123",
+			},
+		},
 		{
 			name: "all standard fields",
 			req: &AuthorizationCodeRequest{
-				ClientID:            "test-client",
-				RedirectURI:         "https://example.com/callback",
-				Scope:               "openid profile email",
-				State:               "random-state-123",
-				Prompt:              "consent",
-				AcrValues:           "level1 level2",
-				LoginHint:           "user@example.com",
-				MaxAge:              "3600",
-				UILocales:           "en-US",
-				CodeChallengeMethod: "S256",
-				CodeChallenge:       "challenge123",
-				RequestURI:          "urn:ietf:params:oauth:request_uri:example",
+				ClientID:             "test-client",
+				RedirectURI:          "https://example.com/callback",
+				Scope:                "openid profile email",
+				State:                "random-state-123",
+				Prompt:               "consent",
+				AcrValues:            "level1 level2",
+				LoginHint:            "user@example.com",
+				MaxAge:               "3600",
+				UILocales:            "en-US",
+				CodeChallengeMethod:  "S256",
+				CodeChallenge:        "challenge123",
+				RequestURI:           "urn:ietf:params:oauth:request_uri:example",
+				ResponseMode:         "jwt",
+				AuthorizationDetails: `[{"type":"payment_initiation"}]`,
+				Claims:               `{"id_token":{"acr":{"essential":true}}}`,
+				DPoPJKT:              "jkt-thumbprint",
 			},
 			wantErr: false,
 			wantParams: map[string]string{
@@ -60,6 +94,10 @@ func TestCreateAuthorizationCodeRequestValues(t *testing.T) {
 				"code_challenge_method": "S256",
 				"code_challenge":        "challenge123",
 				"request_uri":           "urn:ietf:params:oauth:request_uri:example",
+				"response_mode":         "jwt",
+				"authorization_details": `[{"type":"payment_initiation"}]`,
+				"claims":                `{"id_token":{"acr":{"essential":true}}}`,
+				"dpop_jkt":              "jkt-thumbprint",
 			},
 		},
 		{
@@ -79,6 +117,17 @@ func TestCreateAuthorizationCodeRequestValues(t *testing.T) {
 				"another_param": "another_value",
 			},
 		},
+		{
+			name: "with resource indicators",
+			req: &AuthorizationCodeRequest{
+				ClientID:  "test-client",
+				Resources: []string{"https://api.example.com", "https://other.example.com"},
+			},
+			wantParams: map[string]string{
+				"response_type": "code",
+				"client_id":     "test-client",
+			},
+		},
 		{
 			name: "missing client_id",
 			req: &AuthorizationCodeRequest{
@@ -120,6 +169,9 @@ func TestCreateAuthorizationCodeRequestValues(t *testing.T) {
 					t.Errorf("got param %s=%q, want %q", key, got, want)
 				}
 			}
+			if got := (*values)["resource"]; !slices.Equal(got, tt.req.Resources) {
+				t.Errorf("got resource params %q, want %q", got, tt.req.Resources)
+			}
 
 			// Check that unexpected parameters are not set
 			if values.Get("redirect_uri") != tt.req.RedirectURI {
@@ -321,12 +373,14 @@ func TestBuildAuthorizationURL(t *testing.T) {
 func TestValidateCallbackResponse(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name      string
-		reqState  string
-		resp      *webflow.CallbackResponse
-		wantCode  string
-		wantState string
-		wantErr   string
+		name       string
+		reqType    string
+		reqState   string
+		resp       *webflow.CallbackResponse
+		wantCode   string
+		wantState  string
+		wantTokens *AuthorizationCodeResponse
+		wantErr    string
 	}{
 		{
 			name:      "state matches",
@@ -358,12 +412,60 @@ func TestValidateCallbackResponse(t *testing.T) {
 			},
 			wantErr: "authorization failed with error access_denied and description User denied access",
 		},
+		{
+			name:     "implicit response returns the tokens",
+			reqType:  "id_token token",
+			reqState: "test-state-123",
+			resp: &webflow.CallbackResponse{
+				State:       "test-state-123",
+				IDToken:     "eyJ.id.token",
+				AccessToken: "at-123",
+				TokenType:   "Bearer",
+			},
+			wantState: "test-state-123",
+			wantTokens: &AuthorizationCodeResponse{
+				State:       "test-state-123",
+				IDToken:     "eyJ.id.token",
+				AccessToken: "at-123",
+				TokenType:   "Bearer",
+			},
+		},
+		{
+			name:     "hybrid response lacking the id_token is rejected",
+			reqType:  "code id_token",
+			reqState: "test-state-123",
+			resp:     &webflow.CallbackResponse{Code: "auth-code-123", State: "test-state-123"},
+			wantErr:  `authorization response for response_type "code id_token" lacks id_token`,
+		},
+		{
+			name:      "matching issuer is accepted",
+			reqState:  "test-state-123",
+			resp:      &webflow.CallbackResponse{Code: "auth-code-123", State: "test-state-123", Issuer: "https://issuer.example.com"},
+			wantCode:  "auth-code-123",
+			wantState: "test-state-123",
+		},
+		{
+			name:     "issuer mismatch is rejected",
+			reqState: "test-state-123",
+			resp:     &webflow.CallbackResponse{Code: "auth-code-123", State: "test-state-123", Issuer: "https://attacker.example.com"},
+			wantErr:  `issuer mismatch: expected "https://issuer.example.com" but got "https://attacker.example.com"`,
+		},
+		{
+			name:     "error without a state reports the authorization error",
+			reqState: "test-state-123",
+			resp: &webflow.CallbackResponse{
+				ErrorMsg:         "invalid_request",
+				ErrorDescription: "invalid response: token is expired",
+			},
+			wantErr: "authorization failed with error invalid_request and description invalid response: token is expired",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			req := &AuthorizationCodeRequest{ClientID: "test-client", State: tt.reqState}
+			req := &AuthorizationCodeRequest{ResponseType: tt.reqType, ClientID: "test-client", State: tt.reqState}
+			req.Expect = &ExpectedResponse{ResponseType: tt.reqType, State: tt.reqState, Issuer: "https://issuer.example.com"}
 			got, err := validateCallbackResponse(req, tt.resp)
 
 			if tt.wantErr != "" {
@@ -388,6 +490,9 @@ func TestValidateCallbackResponse(t *testing.T) {
 			if got.State != tt.wantState {
 				t.Errorf("state = %q, want %q", got.State, tt.wantState)
 			}
+			if tt.wantTokens != nil && *got != *tt.wantTokens {
+				t.Errorf("response = %+v, want %+v", got, tt.wantTokens)
+			}
 		})
 	}
 
@@ -400,6 +505,140 @@ func TestValidateCallbackResponse(t *testing.T) {
 			t.Errorf("nil response: error = %v, want \"response cannot be nil\"", err)
 		}
 	})
+
+	t.Run("request state is checked without an expectation", func(t *testing.T) {
+		t.Parallel()
+		req := &AuthorizationCodeRequest{ClientID: "test-client", State: "test-state-123"}
+		_, err := validateCallbackResponse(req, &webflow.CallbackResponse{Code: "auth-code-123", State: "forged"})
+		if err == nil || !strings.Contains(err.Error(), "state mismatch") {
+			t.Errorf("error = %v, want a state mismatch", err)
+		}
+	})
+
+	t.Run("expectation is checked when the request carries no state", func(t *testing.T) {
+		t.Parallel()
+		req := &AuthorizationCodeRequest{ClientID: "test-client", Request: "signed.request.object"}
+		req.Expect = &ExpectedResponse{State: "test-state-123"}
+		_, err := validateCallbackResponse(req, &webflow.CallbackResponse{Code: "auth-code-123", State: "forged"})
+		if err == nil || !strings.Contains(err.Error(), "state mismatch") {
+			t.Errorf("error = %v, want a state mismatch", err)
+		}
+	})
+}
+
+func TestExecutePastedAuthorizationCodeRequest(t *testing.T) {
+	t.Parallel()
+
+	const endpoint = "https://issuer.example.com/authorize"
+	decode := func(_ context.Context, response string) (url.Values, error) {
+		if response != "signed.response.jwt" {
+			return nil, errors.New("invalid response")
+		}
+		return url.Values{"code": {"jarm-code"}, "state": {"test-state-123"}}, nil
+	}
+	tests := []struct {
+		name     string
+		reqType  string
+		pasted   string
+		decode   webflow.JARMDecoder
+		wantCode string
+		wantErr  string
+	}{
+		{
+			name:     "redirect URL",
+			pasted:   "http://localhost:9555/callback?code=auth-code-123&state=test-state-123&iss=https%3A%2F%2Fissuer.example.com\n",
+			wantCode: "auth-code-123",
+		},
+		{
+			name:     "query string",
+			pasted:   "?code=auth-code-123&state=test-state-123",
+			wantCode: "auth-code-123",
+		},
+		{
+			name:     "bare code",
+			pasted:   "  auth-code-123\n",
+			wantCode: "auth-code-123",
+		},
+		{
+			name:     "fragment response",
+			reqType:  "code id_token",
+			pasted:   "http://localhost:9555/callback#code=auth-code-123&id_token=eyJ.id.token&state=test-state-123",
+			wantCode: "auth-code-123",
+		},
+		{
+			name:     "JWT-secured response",
+			pasted:   "http://localhost:9555/callback?response=signed.response.jwt",
+			decode:   decode,
+			wantCode: "jarm-code",
+		},
+		{
+			name:    "state mismatch",
+			pasted:  "http://localhost:9555/callback?code=auth-code-123&state=other-state",
+			wantErr: "state mismatch",
+		},
+		{
+			name:    "issuer mismatch",
+			pasted:  "http://localhost:9555/callback?code=auth-code-123&state=test-state-123&iss=https%3A%2F%2Fattacker.example.com",
+			wantErr: "issuer mismatch",
+		},
+		{
+			name:    "authorization error",
+			pasted:  "http://localhost:9555/callback?error=access_denied&state=test-state-123",
+			wantErr: "authorization failed with error access_denied",
+		},
+		{
+			name:    "bare code for a hybrid response type",
+			reqType: "code id_token",
+			pasted:  "auth-code-123",
+			wantErr: "paste the full redirect URL",
+		},
+		{
+			name:    "nothing pasted",
+			pasted:  "\n",
+			wantErr: "no redirect URL was pasted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stderr strings.Builder
+			client := NewClient(&Config{Logger: log.New(log.WithStderr(&stderr))})
+			req := &AuthorizationCodeRequest{
+				ResponseType: tt.reqType,
+				ClientID:     "test-client",
+				State:        "test-state-123",
+				Expect:       &ExpectedResponse{ResponseType: tt.reqType, State: "test-state-123", Issuer: "https://issuer.example.com"},
+			}
+			got, err := client.ExecutePastedAuthorizationCodeRequest(context.Background(), endpoint, req, strings.NewReader(tt.pasted), tt.decode)
+			if !strings.Contains(stderr.String(), endpoint+"?client_id=test-client") {
+				t.Errorf("stderr = %q, want the authorization URL", stderr.String())
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecutePastedAuthorizationCodeRequest() error = %v", err)
+			}
+			if got.Code != tt.wantCode || got.State != "test-state-123" {
+				t.Errorf("response = %+v, want code %q", got, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("cancelled context is honored", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		reader, writer := io.Pipe()
+		defer writer.Close()
+		_, err := NewClient(nil).ExecutePastedAuthorizationCodeRequest(ctx, endpoint, &AuthorizationCodeRequest{ClientID: "test-client"}, reader, nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	})
 }
 
 // TestStartCallbackServerGuards covers the early returns that reject a request
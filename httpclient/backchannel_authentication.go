@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// BackchannelAuthenticationRequest is a CIBA authentication request (OpenID
+// Connect CIBA Core §7.1). Exactly one of the hints identifies the end-user.
+type BackchannelAuthenticationRequest struct {
+	ClientID        string
+	ClientSecret    string
+	AuthMethod      AuthMethod
+	Scope           string
+	LoginHint       string
+	IDTokenHint     string
+	LoginHintToken  string
+	BindingMessage  string
+	ACRValues       string
+	RequestedExpiry int
+}
+
+type BackchannelAuthenticationResponse struct {
+	AuthReqID string `json:"auth_req_id"`
+	ExpiresIn int    `json:"expires_in"`
+	Interval  int    `json:"interval,omitempty"`
+}
+
+// ExecuteBackchannelAuthenticationRequest sends a CIBA authentication request to the specified endpoint
+func (c *Client) ExecuteBackchannelAuthenticationRequest(ctx context.Context, endpoint string, req *BackchannelAuthenticationRequest) (*Response, error) {
+	params := url.Values{}
+	params.Set("scope", req.Scope)
+	for name, value := range map[string]string{
+		"login_hint":       req.LoginHint,
+		"id_token_hint":    req.IDTokenHint,
+		"login_hint_token": req.LoginHintToken,
+		"binding_message":  req.BindingMessage,
+		"acr_values":       req.ACRValues,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	if req.RequestedExpiry > 0 {
+		params.Set("requested_expiry", fmt.Sprint(req.RequestedExpiry))
+	}
+
+	headers := make(map[string]string)
+
+	// Apply authentication method
+	applyClientAuth(params, headers, req.AuthMethod, req.ClientID, req.ClientSecret)
+
+	return c.PostForm(ctx, endpoint, params, headers)
+}
+
+// ParseBackchannelAuthenticationResponse parses the CIBA authentication response
+func ParseBackchannelAuthenticationResponse(resp *Response) (*BackchannelAuthenticationResponse, error) {
+	if !resp.IsSuccess() {
+		oauth2Err := &Error{
+			StatusCode: resp.StatusCode,
+			RawBody:    resp.String(),
+		}
+		var mapResp map[string]any
+
+		if err := json.Unmarshal(resp.Body, &mapResp); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrParsingJSON, err)
+		}
+
+		// Extract standard OAuth2 error fields if present
+		if errStr, ok := mapResp["error"].(string); ok {
+			oauth2Err.ErrorType = errStr
+			if desc, ok := mapResp["error_description"].(string); ok {
+				oauth2Err.ErrorDescription = desc
+			}
+			return nil, fmt.Errorf("%w: %w", ErrOAuthError, oauth2Err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrHTTPFailure, oauth2Err)
+	}
+
+	var cibaResp BackchannelAuthenticationResponse
+	if err := json.Unmarshal(resp.Body, &cibaResp); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParsingJSON, err)
+	}
+	return &cibaResp, nil
+}
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExecuteBackchannelAuthenticationRequest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		req      *BackchannelAuthenticationRequest
+		wantAuth string
+		wantForm url.Values
+	}{
+		{
+			name: "basic auth with login hint",
+			req: &BackchannelAuthenticationRequest{
+				ClientID:        "test-client-id",
+				ClientSecret:    "test-secret",
+				AuthMethod:      AuthMethodBasic,
+				Scope:           "openid",
+				LoginHint:       "alice@example.com",
+				BindingMessage:  "W4SCT",
+				ACRValues:       "urn:example:mfa",
+				RequestedExpiry: 120,
+			},
+			wantAuth: "Basic dGVzdC1jbGllbnQtaWQ6dGVzdC1zZWNyZXQ=",
+			wantForm: url.Values{
+				"scope":            {"openid"},
+				"login_hint":       {"alice@example.com"},
+				"binding_message":  {"W4SCT"},
+				"acr_values":       {"urn:example:mfa"},
+				"requested_expiry": {"120"},
+			},
+		},
+		{
+			name: "post auth with id token hint",
+			req: &BackchannelAuthenticationRequest{
+				ClientID:     "test-client-id",
+				ClientSecret: "test-secret",
+				AuthMethod:   AuthMethodPost,
+				Scope:        "openid profile",
+				IDTokenHint:  "id-token",
+			},
+			wantForm: url.Values{
+				"scope":         {"openid profile"},
+				"id_token_hint": {"id-token"},
+				"client_id":     {"test-client-id"},
+				"client_secret": {"test-secret"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != tt.wantAuth {
+					t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("parsing form: %v", err)
+				}
+				if !reflect.DeepEqual(r.PostForm, tt.wantForm) {
+					t.Errorf("form = %v, want %v", r.PostForm, tt.wantForm)
+				}
+				_, _ = w.Write([]byte(`{"auth_req_id":"auth-req-1","expires_in":120,"interval":2}`))
+			}))
+			defer ts.Close()
+
+			resp, err := NewClient(nil).ExecuteBackchannelAuthenticationRequest(context.Background(), ts.URL, tt.req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := ParseBackchannelAuthenticationResponse(resp)
+			if err != nil {
+				t.Fatalf("ParseBackchannelAuthenticationResponse() error = %v", err)
+			}
+			want := &BackchannelAuthenticationResponse{AuthReqID: "auth-req-1", ExpiresIn: 120, Interval: 2}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("response = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseBackchannelAuthenticationResponseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		resp     *Response
+		wantErr  error
+		wantType string
+	}{
+		{
+			name:     "unknown user",
+			resp:     &Response{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":"unknown_user_id","error_description":"no such user"}`)},
+			wantErr:  ErrOAuthError,
+			wantType: "unknown_user_id",
+		},
+		{
+			name:    "error without oauth fields",
+			resp:    &Response{StatusCode: http.StatusBadGateway, Body: []byte(`{}`)},
+			wantErr: ErrHTTPFailure,
+		},
+		{
+			name:    "malformed success",
+			resp:    &Response{StatusCode: http.StatusOK, Body: []byte("not json")},
+			wantErr: ErrParsingJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseBackchannelAuthenticationResponse(tt.resp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var oauthErr *Error
+			if tt.wantType != "" && (!errors.As(err, &oauthErr) || oauthErr.ErrorType != tt.wantType) {
+				t.Errorf("error = %v, want type %q", err, tt.wantType)
+			}
+		})
+	}
+}
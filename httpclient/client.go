@@ -39,6 +39,7 @@ type Client struct {
 	listen    func(network, addr string) (net.Listener, error)
 	sleepFunc SleepFunc // nil falls back to sleepWithContext
 	logger    *log.Logger
+	nonces    dpopNonces
 }
 
 // Response represents an HTTP response with convenience methods
@@ -107,6 +108,12 @@ func (c *Client) OpenURL(rawURL string) error {
 	return c.browser.Open(rawURL)
 }
 
+// Listen creates a network listener with the client's listen function, so
+// servers a command runs can be bound the same way the callback server is.
+func (c *Client) Listen(network, addr string) (net.Listener, error) {
+	return c.listen(network, addr)
+}
+
 // SetSleepFunc sets a custom sleep function, primarily for testing.
 // Pass nil to reset to default sleepWithContext behavior.
 func (c *Client) SetSleepFunc(fn SleepFunc) {
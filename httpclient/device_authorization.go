@@ -12,6 +12,9 @@ type DeviceAuthorizationRequest struct {
 	Scope               string
 	CodeChallenge       string
 	CodeChallengeMethod string
+	// Resources are resource indicators (RFC 8707), each sent as a resource
+	// parameter.
+	Resources []string
 }
 
 type DeviceAuthorizationResponse struct {
@@ -36,6 +39,9 @@ func (c *Client) ExecuteDeviceAuthorizationRequest(ctx context.Context, endpoint
 	if req.CodeChallengeMethod != "" {
 		params.Set("code_challenge_method", req.CodeChallengeMethod)
 	}
+	for _, resource := range req.Resources {
+		params.Add("resource", resource)
+	}
 
 	// Execute the request
 	return c.PostForm(ctx, endpoint, params, nil)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -56,6 +57,19 @@ func TestExecuteDeviceAuthorizationRequest(t *testing.T) {
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name: "successful device authorization with resource indicators",
+			req: &DeviceAuthorizationRequest{
+				ClientID:  "test-client-id",
+				Scope:     "openid",
+				Resources: []string{"https://api.example.com", "https://other.example.com"},
+			},
+			resp: &Response{
+				StatusCode: http.StatusOK,
+				Body:       []byte(`{"device_code":"test-device-code","user_code":"test-user-code","verification_uri":"https://example.com/verify","expires_in":600}`),
+			},
+			wantStatus: http.StatusOK,
+		},
 		{
 			name: "server error",
 			req: &DeviceAuthorizationRequest{
@@ -102,6 +116,10 @@ func TestExecuteDeviceAuthorizationRequest(t *testing.T) {
 					t.Errorf("scope = %v, want %v", got, tt.req.Scope)
 				}
 
+				if got := r.Form["resource"]; !slices.Equal(got, tt.req.Resources) {
+					t.Errorf("resource = %v, want %v", got, tt.req.Resources)
+				}
+
 				w.WriteHeader(tt.resp.StatusCode)
 				_, err := w.Write(tt.resp.Body)
 				if err != nil {
@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// DPoPNonceHeader carries the server-provided nonce a DPoP proof must echo
+// (RFC 9449 §8 and §9).
+const DPoPNonceHeader = "DPoP-Nonce"
+
+// DPoPProofFunc generates a DPoP proof for the given HTTP method and URL,
+// carrying nonce when the server has issued one. It is called once per
+// request, ensuring each request carries a fresh proof.
+type DPoPProofFunc func(method, requestURL, nonce string) (string, error)
+
+// dpopNonces remembers the most recent DPoP-Nonce each server handed out, keyed
+// by origin, so the next proof sent to that server can carry it. The zero value
+// is ready to use.
+type dpopNonces struct {
+	mu     sync.Mutex
+	nonces map[string]string
+}
+
+func (n *dpopNonces) get(rawURL string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.nonces[nonceOrigin(rawURL)]
+}
+
+// remember records the nonce a response's headers carry, if any.
+func (n *dpopNonces) remember(rawURL string, header http.Header) {
+	nonce := header.Get(DPoPNonceHeader)
+	if nonce == "" {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.nonces == nil {
+		n.nonces = make(map[string]string)
+	}
+	n.nonces[nonceOrigin(rawURL)] = nonce
+}
+
+// nonceOrigin reduces a URL to the scheme and host that scope a DPoP nonce.
+func nonceOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// DPoPNonce returns the most recent DPoP nonce the server at rawURL issued, or
+// "" when it has not issued one.
+func (c *Client) DPoPNonce(rawURL string) string {
+	return c.nonces.get(rawURL)
+}
+
+// sendWithDPoP sends a request through send, first minting a proof with dpop
+// when it is set. The server's latest nonce is remembered from every response,
+// and a use_dpop_nonce rejection is retried once with a fresh proof carrying
+// the new nonce. With no proof function the request is sent once, unchanged.
+func (c *Client) sendWithDPoP(method, rawURL string, dpop DPoPProofFunc, send func(proof string) (*Response, error)) (*Response, error) {
+	attempt := func() (*Response, error) {
+		var proof string
+		if dpop != nil {
+			var err error
+			proof, err = dpop(method, rawURL, c.nonces.get(rawURL))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate DPoP proof: %w", err)
+			}
+		}
+		resp, err := send(proof)
+		if err != nil {
+			return nil, err
+		}
+		c.nonces.remember(rawURL, resp.Headers)
+		return resp, nil
+	}
+
+	resp, err := attempt()
+	if err != nil || dpop == nil || !IsDPoPNonceChallenge(resp) {
+		return resp, err
+	}
+	c.logger.Printf("server requires a DPoP nonce, retrying %s %s\n", method, rawURL)
+	return attempt()
+}
+
+// IsDPoPNonceChallenge reports whether resp rejects a DPoP proof for lacking a
+// current nonce and supplies one to retry with: an authorization server
+// answers 400 with error use_dpop_nonce (RFC 9449 §8), a resource server 401
+// with a DPoP WWW-Authenticate challenge carrying that error (RFC 9449 §9).
+func IsDPoPNonceChallenge(resp *Response) bool {
+	if resp == nil || resp.Headers.Get(DPoPNonceHeader) == "" {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		var body struct {
+			Error string `json:"error"`
+		}
+		return json.Unmarshal(resp.Body, &body) == nil && body.Error == "use_dpop_nonce"
+	case http.StatusUnauthorized:
+		challenge, ok := FindChallenge(resp.Headers.Get("WWW-Authenticate"), "DPoP")
+		return ok && challenge.Param("error") == "use_dpop_nonce"
+	default:
+		return false
+	}
+}
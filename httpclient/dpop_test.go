@@ -0,0 +1,275 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nonceRecordingProof returns a proof function that encodes the nonce it was
+// given into the proof, so the server side can see which nonce each attempt
+// carried, and records the nonces for the client side.
+func nonceRecordingProof(mu *sync.Mutex, nonces *[]string) DPoPProofFunc {
+	return func(_, _, nonce string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		*nonces = append(*nonces, nonce)
+		return "proof-with-nonce-" + nonce, nil
+	}
+}
+
+func TestExecuteTokenRequest_DPoPNonceRetry(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("DPoP"))
+		if r.Header.Get("DPoP") != "proof-with-nonce-server-nonce" {
+			w.Header().Set(DPoPNonceHeader, "server-nonce")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"use_dpop_nonce","error_description":"Authorization server requires nonce in DPoP proof"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"access_token":"token123","token_type":"DPoP"}`))
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var nonces []string
+	client := NewClient(nil)
+	newRequest := func() *TokenRequest {
+		return &TokenRequest{
+			GrantType:  "client_credentials",
+			ClientID:   "test-client",
+			AuthMethod: AuthMethodNone,
+			DPoP:       nonceRecordingProof(&mu, &nonces),
+		}
+	}
+
+	resp, err := client.ExecuteTokenRequest(context.Background(), ts.URL, newRequest())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.IsSuccess() {
+		t.Fatalf("Expected successful response after nonce retry, got status %d", resp.StatusCode)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2 (rejected proof + retry with nonce)", len(requests))
+	}
+
+	// A later request to the same server carries the remembered nonce up front.
+	if _, err := client.ExecuteTokenRequest(context.Background(), ts.URL, newRequest()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3 (the remembered nonce avoids another retry)", len(requests))
+	}
+	wantNonces := []string{"", "server-nonce", "server-nonce"}
+	if fmt.Sprint(nonces) != fmt.Sprint(wantNonces) {
+		t.Errorf("proof nonces = %q, want %q", nonces, wantNonces)
+	}
+	if got := client.DPoPNonce(ts.URL + "/token"); got != "server-nonce" {
+		t.Errorf("DPoPNonce() = %q, want the nonce remembered for the server's origin", got)
+	}
+}
+
+// TestExecuteTokenRequest_DPoPNonceRetriesOnce pins that a server that keeps
+// rejecting the proof gets exactly one retry, and the final rejection surfaces
+// as ErrUseDPoPNonce.
+func TestExecuteTokenRequest_DPoPNonceRetriesOnce(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.Header().Set(DPoPNonceHeader, fmt.Sprintf("nonce-%d", attempts))
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var nonces []string
+	client := NewClient(nil)
+	resp, err := client.ExecuteTokenRequest(context.Background(), ts.URL, &TokenRequest{
+		GrantType:  "client_credentials",
+		ClientID:   "test-client",
+		AuthMethod: AuthMethodNone,
+		DPoP:       nonceRecordingProof(&mu, &nonces),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+	if _, err := ParseTokenResponse(resp); !errors.Is(err, ErrUseDPoPNonce) {
+		t.Errorf("ParseTokenResponse() error = %v, want ErrUseDPoPNonce", err)
+	}
+}
+
+// TestExecuteTokenRequest_NoNonceRetryWithoutDPoP pins that only DPoP requests
+// are retried, and only when the server actually supplies a nonce.
+func TestExecuteTokenRequest_NoNonceRetryWithoutDPoP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		dpop        bool
+		nonceHeader string
+	}{
+		{"no dpop", false, "server-nonce"},
+		{"dpop but no nonce header", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			attempts := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts++
+				if tt.nonceHeader != "" {
+					w.Header().Set(DPoPNonceHeader, tt.nonceHeader)
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+			}))
+			defer ts.Close()
+
+			req := &TokenRequest{GrantType: "client_credentials", ClientID: "test-client", AuthMethod: AuthMethodNone}
+			if tt.dpop {
+				req.DPoP = func(_, _, _ string) (string, error) { return "proof", nil }
+			}
+			if _, err := NewClient(nil).ExecuteTokenRequest(context.Background(), ts.URL, req); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if attempts != 1 {
+				t.Errorf("got %d attempts, want 1", attempts)
+			}
+		})
+	}
+}
+
+// TestExecutePollingTokenRequest_DPoPNonce checks the device polling loop picks
+// up a nonce issued mid-poll and carries it on every later attempt.
+func TestExecutePollingTokenRequest_DPoPNonce(t *testing.T) {
+	t.Parallel()
+
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("DPoP"))
+		switch len(seen) {
+		case 1:
+			w.Header().Set(DPoPNonceHeader, "nonce-a")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+		case 2:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"authorization_pending"}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"access_token":"token123","token_type":"DPoP"}`))
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient(nil)
+	client.SetSleepFunc(func(context.Context, time.Duration) error { return nil })
+
+	var mu sync.Mutex
+	var nonces []string
+	resp, err := client.ExecutePollingTokenRequest(context.Background(), ts.URL, &TokenRequest{
+		GrantType:  "urn:ietf:params:oauth:grant-type:device_code",
+		ClientID:   "device-client",
+		AuthMethod: AuthMethodNone,
+		Params:     url.Values{"device_code": []string{"device123"}},
+		DPoP:       nonceRecordingProof(&mu, &nonces),
+	}, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.IsSuccess() {
+		t.Fatalf("Expected successful response, got status %d", resp.StatusCode)
+	}
+	wantNonces := []string{"", "nonce-a", "nonce-a"}
+	if fmt.Sprint(nonces) != fmt.Sprint(wantNonces) {
+		t.Errorf("proof nonces = %q, want %q", nonces, wantNonces)
+	}
+}
+
+func TestIsDPoPNonceChallenge(t *testing.T) {
+	t.Parallel()
+	// http.Header literals bypass key canonicalization, so build them with Set.
+	header := func(kv ...string) http.Header {
+		h := make(http.Header)
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	withNonce := header(DPoPNonceHeader, "n")
+
+	tests := []struct {
+		name string
+		resp *Response
+		want bool
+	}{
+		{
+			name: "authorization server rejection",
+			resp: &Response{StatusCode: http.StatusBadRequest, Headers: withNonce, Body: []byte(`{"error":"use_dpop_nonce"}`)},
+			want: true,
+		},
+		{
+			name: "resource server challenge",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: header(
+				DPoPNonceHeader, "n",
+				"WWW-Authenticate", `DPoP error="use_dpop_nonce", error_description="Resource server requires nonce in DPoP proof"`,
+			)},
+			want: true,
+		},
+		{
+			name: "other oauth error",
+			resp: &Response{StatusCode: http.StatusBadRequest, Headers: withNonce, Body: []byte(`{"error":"invalid_grant"}`)},
+			want: false,
+		},
+		{
+			name: "bearer challenge",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: header(
+				DPoPNonceHeader, "n",
+				"WWW-Authenticate", `Bearer error="use_dpop_nonce"`,
+			)},
+			want: false,
+		},
+		{
+			name: "missing nonce header",
+			resp: &Response{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":"use_dpop_nonce"}`)},
+			want: false,
+		},
+		{
+			name: "success with nonce header",
+			resp: &Response{StatusCode: http.StatusOK, Headers: withNonce, Body: []byte(`{}`)},
+			want: false,
+		},
+		{
+			name: "nil response",
+			resp: nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsDPoPNonceChallenge(tt.resp); got != tt.want {
+				t.Errorf("IsDPoPNonceChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
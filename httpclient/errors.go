@@ -13,6 +13,7 @@ var (
 	ErrIssuerInvalid        = errors.New("issuer does not match")
 	ErrAuthorizationPending = errors.New("authorization pending")
 	ErrSlowDown             = errors.New("slow down")
+	ErrUseDPoPNonce         = errors.New("dpop nonce required")
 )
 
 // Error represents a standard OAuth2 error response
@@ -45,6 +46,8 @@ func WrapError(err error, operation string) error {
 		return fmt.Errorf("authorization pending during %s: %w", operation, err)
 	case errors.Is(err, ErrSlowDown):
 		return fmt.Errorf("slow down signal received during %s: %w", operation, err)
+	case errors.Is(err, ErrUseDPoPNonce):
+		return fmt.Errorf("DPoP nonce still rejected during %s: %w", operation, err)
 	default:
 		return fmt.Errorf("%s error: %w", operation, err)
 	}
@@ -2,7 +2,6 @@ package httpclient
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"net/url"
 )
@@ -41,21 +40,7 @@ func (c *Client) ExecuteIntrospectionRequest(ctx context.Context, endpoint strin
 	}
 
 	// Apply authentication method
-	switch req.AuthMethod {
-	case AuthMethodBasic:
-		// Use HTTP Basic Auth
-		auth := base64.StdEncoding.EncodeToString([]byte(req.ClientID + ":" + req.ClientSecret))
-		headers["Authorization"] = "Basic " + auth
-	case AuthMethodPost:
-		// Include credentials in request body
-		params.Set("client_id", req.ClientID)
-		if req.ClientSecret != "" {
-			params.Set("client_secret", req.ClientSecret)
-		}
-	case AuthMethodNone:
-		// Just include client_id in request body
-		params.Set("client_id", req.ClientID)
-	}
+	applyClientAuth(params, headers, req.AuthMethod, req.ClientID, req.ClientSecret)
 
 	// Set the Accept header if specified
 	if req.AcceptMediaType != "" {
@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/jentz/oidc-cli/webflow"
+)
+
+// LogoutRequest is an RP-initiated logout request (OpenID Connect RP-Initiated
+// Logout 1.0 §2). Every parameter is optional; the OP identifies the session
+// from its own cookies.
+type LogoutRequest struct {
+	IDTokenHint           string
+	ClientID              string
+	LogoutHint            string
+	State                 string
+	PostLogoutRedirectURI string
+}
+
+type LogoutResponse struct {
+	State string
+}
+
+// CreateLogoutRequestURL renders the logout request as a URL at the end-session
+// endpoint, keeping any query the endpoint already carries.
+func CreateLogoutRequestURL(endpoint string, req *LogoutRequest) (string, error) {
+	if endpoint == "" {
+		return "", errors.New("endpoint is required")
+	}
+	if req == nil {
+		return "", errors.New("request cannot be nil")
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse endpoint URL: %w", err)
+	}
+
+	values := endpointURL.Query()
+	for name, value := range map[string]string{
+		"id_token_hint":            req.IDTokenHint,
+		"client_id":                req.ClientID,
+		"logout_hint":              req.LogoutHint,
+		"state":                    req.State,
+		"post_logout_redirect_uri": req.PostLogoutRedirectURI,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	endpointURL.RawQuery = values.Encode()
+	return endpointURL.String(), nil
+}
+
+// ExecuteLogoutRequest opens the logout URL in the browser. With a callback it
+// also waits for the OP to redirect to the post_logout_redirect_uri, which
+// confirms the logout, and validates the returned state. Without one the OP
+// has nowhere to report back to, so the request is fire-and-forget.
+func (c *Client) ExecuteLogoutRequest(ctx context.Context, endpoint, callback string, req *LogoutRequest) (*LogoutResponse, error) {
+	requestURL, err := CreateLogoutRequestURL(endpoint, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logout request URL: %w", err)
+	}
+
+	if callback == "" {
+		c.logger.Printf("logout request: %s\n", requestURL)
+		if err := c.OpenURL(requestURL); err != nil {
+			c.logger.Errorf("unable to open browser because %v, visit %s to log out\n", err, requestURL)
+		}
+		return &LogoutResponse{}, nil
+	}
+
+	server, err := c.startCallbackServer(ctx, callback, webflow.WithLogout())
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Printf("logout request: %s\n", requestURL)
+	if err := c.OpenURL(requestURL); err != nil {
+		c.logger.Errorf("unable to open browser because %v, visit %s to continue\n", err, requestURL)
+	}
+
+	callbackResp, err := server.WaitForCallback(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("callback failed: %w", err)
+	}
+
+	return validateLogoutCallback(req, callbackResp)
+}
+
+// validateLogoutCallback checks the post-logout redirect's state and that the
+// OP reported no error.
+func validateLogoutCallback(req *LogoutRequest, resp *webflow.CallbackResponse) (*LogoutResponse, error) {
+	if req.State != "" && resp.State != req.State {
+		return nil, fmt.Errorf("state mismatch: expected %q but got %q", req.State, resp.State)
+	}
+	if resp.ErrorMsg != "" {
+		return nil, fmt.Errorf("logout failed with error %s and description %s", resp.ErrorMsg, resp.ErrorDescription)
+	}
+	return &LogoutResponse{State: resp.State}, nil
+}
@@ -0,0 +1,201 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/webflow"
+)
+
+// logoutBrowser records the URL it opens and, when set, fires the post-logout
+// redirect the callback server is waiting on.
+type logoutBrowser struct {
+	openedURL string
+	redirect  string
+}
+
+func (b *logoutBrowser) Open(rawURL string) error {
+	b.openedURL = rawURL
+	if b.redirect == "" {
+		return nil
+	}
+	resp, err := http.Get(b.redirect) //nolint:noctx // test-local loopback request
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestCreateLogoutRequestURL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		endpoint string
+		req      *LogoutRequest
+		want     map[string]string
+		wantErr  string
+	}{
+		{
+			name:     "all parameters",
+			endpoint: "https://op.example.com/logout",
+			req: &LogoutRequest{
+				IDTokenHint:           "id-token",
+				ClientID:              "client",
+				LogoutHint:            "user@example.com",
+				State:                 "state-123",
+				PostLogoutRedirectURI: "http://localhost:9555/logout",
+			},
+			want: map[string]string{
+				"id_token_hint":            "id-token",
+				"client_id":                "client",
+				"logout_hint":              "user@example.com",
+				"state":                    "state-123",
+				"post_logout_redirect_uri": "http://localhost:9555/logout",
+			},
+		},
+		{
+			name:     "empty parameters are omitted and the endpoint query is kept",
+			endpoint: "https://op.example.com/logout?tenant=a",
+			req:      &LogoutRequest{ClientID: "client"},
+			want:     map[string]string{"tenant": "a", "client_id": "client"},
+		},
+		{
+			name:    "missing endpoint",
+			req:     &LogoutRequest{},
+			wantErr: "endpoint is required",
+		},
+		{
+			name:     "nil request",
+			endpoint: "https://op.example.com/logout",
+			wantErr:  "request cannot be nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := CreateLogoutRequestURL(tt.endpoint, tt.req)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("CreateLogoutRequestURL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateLogoutRequestURL() error = %v", err)
+			}
+			u, err := url.Parse(got)
+			if err != nil {
+				t.Fatalf("parsing %q: %v", got, err)
+			}
+			query := u.Query()
+			if len(query) != len(tt.want) {
+				t.Errorf("query = %v, want %v", query, tt.want)
+			}
+			for key, want := range tt.want {
+				if query.Get(key) != want {
+					t.Errorf("%s = %q, want %q", key, query.Get(key), want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLogoutCallback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		reqState string
+		resp     *webflow.CallbackResponse
+		wantErr  string
+	}{
+		{
+			name:     "state matches",
+			reqState: "state-123",
+			resp:     &webflow.CallbackResponse{State: "state-123"},
+		},
+		{
+			name:     "state mismatch is rejected",
+			reqState: "state-123",
+			resp:     &webflow.CallbackResponse{State: "other"},
+			wantErr:  `state mismatch: expected "state-123" but got "other"`,
+		},
+		{
+			name:     "error is reported",
+			reqState: "state-123",
+			resp:     &webflow.CallbackResponse{State: "state-123", ErrorMsg: "access_denied", ErrorDescription: "no session"},
+			wantErr:  "logout failed with error access_denied and description no session",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := validateLogoutCallback(&LogoutRequest{State: tt.reqState}, tt.resp)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("validateLogoutCallback() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateLogoutCallback() error = %v", err)
+			}
+			if got.State != tt.reqState {
+				t.Errorf("state = %q, want %q", got.State, tt.reqState)
+			}
+		})
+	}
+}
+
+func TestExecuteLogoutRequest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without a callback the URL is only opened", func(t *testing.T) {
+		t.Parallel()
+		browser := &logoutBrowser{}
+		client := NewClient(&Config{Browser: browser})
+
+		_, err := client.ExecuteLogoutRequest(context.Background(), "https://op.example.com/logout", "",
+			&LogoutRequest{ClientID: "client"})
+		if err != nil {
+			t.Fatalf("ExecuteLogoutRequest() error = %v", err)
+		}
+		if want := "https://op.example.com/logout?client_id=client"; browser.openedURL != want {
+			t.Errorf("opened URL = %q, want %q", browser.openedURL, want)
+		}
+	})
+
+	t.Run("with a callback the post-logout redirect is awaited", func(t *testing.T) {
+		t.Parallel()
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("binding loopback listener: %v", err)
+		}
+		browser := &logoutBrowser{redirect: fmt.Sprintf("http://%s/logout?state=state-123", ln.Addr())}
+		client := NewClient(&Config{
+			Browser: browser,
+			Listen:  func(_, _ string) (net.Listener, error) { return ln, nil },
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		got, err := client.ExecuteLogoutRequest(ctx, "https://op.example.com/logout", "http://localhost/logout",
+			&LogoutRequest{State: "state-123", PostLogoutRedirectURI: "http://localhost/logout"})
+		if err != nil {
+			t.Fatalf("ExecuteLogoutRequest() error = %v", err)
+		}
+		if got.State != "state-123" {
+			t.Errorf("state = %q, want %q", got.State, "state-123")
+		}
+		if !strings.Contains(browser.openedURL, "post_logout_redirect_uri=") {
+			t.Errorf("opened URL = %q, want it to carry post_logout_redirect_uri", browser.openedURL)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// ListenCallback binds the callback server's listener ahead of the flow, so
+// the port it got can go into the redirect_uri (RFC 8252 §7.3). The callback
+// URI's own port is tried first, then each of ports in turn; port 0, with no
+// ports to fall back on, lets the operating system pick one. It returns the
+// listener and the callback URI with the bound port.
+func (c *Client) ListenCallback(callback string, ports []int) (net.Listener, string, error) {
+	u, err := url.Parse(callback)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid callback URI: %w", err)
+	}
+	if !IsLoopbackHost(u.Hostname()) {
+		return nil, "", fmt.Errorf("callback URI %q must be on a loopback host to pick its port", callback)
+	}
+
+	var candidates []int
+	if port := u.Port(); port != "" && port != "0" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid callback URI port %q", port)
+		}
+		candidates = append(candidates, n)
+	}
+	candidates = append(candidates, ports...)
+	if len(candidates) == 0 {
+		candidates = []int{0}
+	}
+
+	var errs []error
+	for _, port := range candidates {
+		ln, err := c.listen("tcp", net.JoinHostPort(u.Hostname(), strconv.Itoa(port)))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		addr, ok := ln.Addr().(*net.TCPAddr)
+		if !ok {
+			_ = ln.Close()
+			return nil, "", fmt.Errorf("callback listener has a non-TCP address %s", ln.Addr())
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(addr.Port))
+		c.logger.Printf("callback server bound to port %d\n", addr.Port)
+		return ln, u.String(), nil
+	}
+	return nil, "", fmt.Errorf("no callback port could be bound: %w", errors.Join(errs...))
+}
+
+// IsLoopbackHost reports whether host is localhost or a loopback IP address,
+// the hosts a native client may listen on (RFC 8252 §7.3).
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestListenCallback(t *testing.T) {
+	t.Parallel()
+
+	busy := func(t *testing.T) int {
+		t.Helper()
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("binding busy port: %v", err)
+		}
+		t.Cleanup(func() { _ = ln.Close() })
+		return ln.Addr().(*net.TCPAddr).Port
+	}
+
+	t.Run("any port", func(t *testing.T) {
+		t.Parallel()
+		ln, callback, err := NewClient(nil).ListenCallback("http://127.0.0.1:0/callback", nil)
+		if err != nil {
+			t.Fatalf("ListenCallback() error = %v", err)
+		}
+		defer ln.Close()
+		port := ln.Addr().(*net.TCPAddr).Port
+		if want := "http://127.0.0.1:" + strconv.Itoa(port) + "/callback"; callback != want {
+			t.Errorf("callback = %q, want %q", callback, want)
+		}
+	})
+
+	t.Run("falls back over the port range", func(t *testing.T) {
+		t.Parallel()
+		taken := busy(t)
+		// Listen on a fixed port once the range gets to it
+		var tried []string
+		client := NewClient(&Config{Listen: func(network, addr string) (net.Listener, error) {
+			tried = append(tried, addr)
+			if len(tried) < 3 {
+				return net.Listen(network, "127.0.0.1:"+strconv.Itoa(taken))
+			}
+			return net.Listen(network, "127.0.0.1:0")
+		}})
+		ln, callback, err := client.ListenCallback("http://localhost:"+strconv.Itoa(taken)+"/callback", []int{9556, 9557})
+		if err != nil {
+			t.Fatalf("ListenCallback() error = %v", err)
+		}
+		defer ln.Close()
+		want := []string{"localhost:" + strconv.Itoa(taken), "localhost:9556", "localhost:9557"}
+		if strings.Join(tried, " ") != strings.Join(want, " ") {
+			t.Errorf("tried %q, want %q", tried, want)
+		}
+		if want := "http://localhost:" + strconv.Itoa(ln.Addr().(*net.TCPAddr).Port) + "/callback"; callback != want {
+			t.Errorf("callback = %q, want %q", callback, want)
+		}
+	})
+
+	t.Run("every port taken", func(t *testing.T) {
+		t.Parallel()
+		taken := busy(t)
+		_, _, err := NewClient(nil).ListenCallback("http://127.0.0.1:"+strconv.Itoa(taken)+"/callback", []int{taken})
+		if err == nil || !strings.Contains(err.Error(), "no callback port could be bound") {
+			t.Errorf("error = %v, want no port bound", err)
+		}
+	})
+
+	t.Run("not a loopback host", func(t *testing.T) {
+		t.Parallel()
+		client := NewClient(&Config{Listen: func(_, _ string) (net.Listener, error) {
+			return nil, errors.New("must not listen")
+		}})
+		_, _, err := client.ListenCallback("http://rp.example.com:0/callback", nil)
+		if err == nil || !strings.Contains(err.Error(), "must be on a loopback host") {
+			t.Errorf("error = %v, want a loopback host required", err)
+		}
+	})
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	t.Parallel()
+	tests := map[string]bool{
+		"localhost":      true,
+		"127.0.0.1":      true,
+		"127.0.0.2":      true,
+		"::1":            true,
+		"rp.example.com": false,
+		"192.0.2.1":      false,
+		"":               false,
+	}
+	for host, want := range tests {
+		if got := IsLoopbackHost(host); got != want {
+			t.Errorf("IsLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package httpclient
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -55,3 +57,23 @@ func (a *AuthMethod) Set(value string) error {
 	*a = method
 	return nil
 }
+
+// applyClientAuth authenticates the client with authMethod: in the
+// Authorization header for client_secret_basic, and in params otherwise.
+func applyClientAuth(params url.Values, headers map[string]string, authMethod AuthMethod, clientID, clientSecret string) {
+	switch authMethod {
+	case AuthMethodBasic:
+		// Use HTTP Basic Auth
+		auth := base64.StdEncoding.EncodeToString([]byte(clientID + ":" + clientSecret))
+		headers["Authorization"] = "Basic " + auth
+	case AuthMethodPost:
+		// Include credentials in request body
+		params.Set("client_id", clientID)
+		if clientSecret != "" {
+			params.Set("client_secret", clientSecret)
+		}
+	case AuthMethodNone:
+		// Just include client_id in request body
+		params.Set("client_id", clientID)
+	}
+}
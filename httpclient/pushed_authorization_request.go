@@ -2,9 +2,10 @@ package httpclient
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
 )
 
@@ -13,6 +14,7 @@ type PushedAuthorizationRequest struct {
 	ClientSecret string
 	AuthMethod   AuthMethod
 	Params       *url.Values
+	DPoP         DPoPProofFunc
 }
 
 type PushedAuthorizationResponse struct {
@@ -24,24 +26,16 @@ func (c *Client) ExecutePushedAuthorizationRequest(ctx context.Context, endpoint
 	headers := make(map[string]string)
 
 	// Apply authentication method
-	switch req.AuthMethod {
-	case AuthMethodBasic:
-		// Use HTTP Basic Auth
-		auth := base64.StdEncoding.EncodeToString([]byte(req.ClientID + ":" + req.ClientSecret))
-		headers["Authorization"] = "Basic " + auth
-	case AuthMethodPost:
-		// Include credentials in request body
-		req.Params.Set("client_id", req.ClientID)
-		if req.ClientSecret != "" {
-			req.Params.Set("client_secret", req.ClientSecret)
-		}
-	case AuthMethodNone:
-		// Just include client_id in request body
-		req.Params.Set("client_id", req.ClientID)
-	}
+	applyClientAuth(*req.Params, headers, req.AuthMethod, req.ClientID, req.ClientSecret)
 
-	// Execute the request
-	return c.PostForm(ctx, endpoint, *req.Params, headers)
+	// Attach a fresh DPoP proof if configured, retrying once on a nonce challenge
+	return c.sendWithDPoP(http.MethodPost, endpoint, req.DPoP, func(proof string) (*Response, error) {
+		attemptHeaders := maps.Clone(headers)
+		if proof != "" {
+			attemptHeaders["DPoP"] = proof
+		}
+		return c.PostForm(ctx, endpoint, *req.Params, attemptHeaders)
+	})
 }
 
 func ParsePushedAuthorizationResponse(resp *Response) (*PushedAuthorizationResponse, error) {
@@ -146,6 +146,45 @@ func TestExecutePushedAuthorizationRequest(t *testing.T) {
 	}
 }
 
+// TestExecutePushedAuthorizationRequest_DPoP checks the PAR request carries a
+// proof for the PAR endpoint and answers a nonce challenge with one retry.
+func TestExecutePushedAuthorizationRequest_DPoP(t *testing.T) {
+	t.Parallel()
+
+	var proofs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proofs = append(proofs, r.Header.Get("DPoP"))
+		if len(proofs) == 1 {
+			w.Header().Set(DPoPNonceHeader, "par-nonce")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"request_uri":"urn:example:par","expires_in":60}`))
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(nil).ExecutePushedAuthorizationRequest(context.Background(), ts.URL, &PushedAuthorizationRequest{
+		ClientID:   "test-client",
+		AuthMethod: AuthMethodNone,
+		Params:     &url.Values{"response_type": []string{"code"}},
+		DPoP: func(method, requestURL, nonce string) (string, error) {
+			return method + " " + requestURL + " nonce=" + nonce, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ParsePushedAuthorizationResponse(resp); err != nil {
+		t.Fatalf("ParsePushedAuthorizationResponse() error = %v", err)
+	}
+	want := []string{"POST " + ts.URL + " nonce=", "POST " + ts.URL + " nonce=par-nonce"}
+	if strings.Join(proofs, "|") != strings.Join(want, "|") {
+		t.Errorf("proofs = %q, want %q", proofs, want)
+	}
+}
+
 func TestParsePushedAuthorizationResponse(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
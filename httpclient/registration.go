@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ClientRegistrationRequest is a dynamic client registration request
+// (RFC 7591), or a client configuration request managing a registered client
+// (RFC 7592): POST to the registration endpoint registers a client, GET, PUT
+// and DELETE on its registration_client_uri read, update and delete it.
+type ClientRegistrationRequest struct {
+	Method string
+	URL    string
+	// AccessToken is the initial access token when registering, or the
+	// registration access token when managing a client. It is sent as a
+	// bearer token when set.
+	AccessToken string
+	// Metadata is the client metadata sent as the JSON body, if any.
+	Metadata map[string]any
+}
+
+func (c *Client) ExecuteClientRegistrationRequest(ctx context.Context, req *ClientRegistrationRequest) (*Response, error) {
+	headers := map[string]string{"Accept": "application/json"}
+	if req.AccessToken != "" {
+		headers["Authorization"] = "Bearer " + req.AccessToken
+	}
+
+	var body io.Reader
+	if req.Metadata != nil {
+		jsonData, err := json.Marshal(req.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal client metadata: %w", err)
+		}
+		body = bytes.NewReader(jsonData)
+		headers["Content-Type"] = "application/json"
+	}
+	return c.Do(ctx, req.Method, req.URL, body, headers)
+}
+
+// ParseClientRegistrationResponse parses the client information a
+// registration or client configuration request returned. A deleted client
+// (204 No Content) has none, so nil is returned.
+func ParseClientRegistrationResponse(resp *Response) (map[string]any, error) {
+	if !resp.IsSuccess() {
+		oauth2Err := &Error{
+			StatusCode: resp.StatusCode,
+			RawBody:    resp.String(),
+		}
+		var mapResp map[string]any
+		// Registration errors such as invalid_redirect_uri share the OAuth 2.0
+		// error format (RFC 7591 §3.2.2); a 401 from RFC 7592 may have no body.
+		if err := json.Unmarshal(resp.Body, &mapResp); err == nil {
+			if errStr, ok := mapResp["error"].(string); ok {
+				oauth2Err.ErrorType = errStr
+				if desc, ok := mapResp["error_description"].(string); ok {
+					oauth2Err.ErrorDescription = desc
+				}
+				return nil, fmt.Errorf("%w: %w", ErrOAuthError, oauth2Err)
+			}
+		}
+		return nil, fmt.Errorf("%w: %w", ErrHTTPFailure, oauth2Err)
+	}
+
+	if resp.StatusCode == http.StatusNoContent || len(resp.Body) == 0 {
+		return nil, nil
+	}
+	var clientInfo map[string]any
+	if err := resp.JSON(&clientInfo); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParsingJSON, err)
+	}
+	return clientInfo, nil
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExecuteClientRegistrationRequest(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer initial-123" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer initial-123")
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want %q", got, "application/json")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"client_name":"demo"}` {
+			t.Errorf("body = %q, want the metadata", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"client_id":"c-1","client_secret":"s-1"}`))
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(nil).ExecuteClientRegistrationRequest(context.Background(), &ClientRegistrationRequest{
+		Method:      http.MethodPost,
+		URL:         ts.URL,
+		AccessToken: "initial-123",
+		Metadata:    map[string]any{"client_name": "demo"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := ParseClientRegistrationResponse(resp)
+	if err != nil {
+		t.Fatalf("ParseClientRegistrationResponse() error = %v", err)
+	}
+	want := map[string]any{"client_id": "c-1", "client_secret": "s-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("client information = %v, want %v", got, want)
+	}
+}
+
+func TestExecuteClientRegistrationRequestWithoutBody(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("method = %s, want DELETE", r.Method)
+		}
+		if got := r.Header.Get("Content-Type"); got != "" {
+			t.Errorf("Content-Type = %q, want none", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(nil).ExecuteClientRegistrationRequest(context.Background(), &ClientRegistrationRequest{
+		Method:      http.MethodDelete,
+		URL:         ts.URL,
+		AccessToken: "registration-123",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := ParseClientRegistrationResponse(resp)
+	if err != nil || got != nil {
+		t.Errorf("ParseClientRegistrationResponse() = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestParseClientRegistrationResponseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		resp     *Response
+		wantErr  error
+		wantType string
+	}{
+		{
+			name:     "registration error",
+			resp:     &Response{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":"invalid_redirect_uri","error_description":"must use https"}`)},
+			wantErr:  ErrOAuthError,
+			wantType: "invalid_redirect_uri",
+		},
+		{
+			name:    "unauthorized without body",
+			resp:    &Response{StatusCode: http.StatusUnauthorized},
+			wantErr: ErrHTTPFailure,
+		},
+		{
+			name:    "malformed success",
+			resp:    &Response{StatusCode: http.StatusCreated, Body: []byte("not json")},
+			wantErr: ErrParsingJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseClientRegistrationResponse(tt.resp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var oauthErr *Error
+			if tt.wantType != "" && (!errors.As(err, &oauthErr) || oauthErr.ErrorType != tt.wantType) {
+				t.Errorf("error = %v, want type %q", err, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+)
+
+// ResourceRequest is a call to a protected resource with an access token.
+type ResourceRequest struct {
+	Method      string
+	URL         string
+	AccessToken string
+	Headers     map[string]string
+	Body        []byte
+	// DPoP, when set, presents the access token under the DPoP scheme with a
+	// proof per attempt; otherwise the token is sent as a Bearer token. The
+	// proof must be bound to AccessToken through its ath claim.
+	DPoP DPoPProofFunc
+}
+
+// ExecuteResourceRequest calls a protected resource, attaching the access token
+// and, for DPoP, a fresh proof. A DPoP nonce challenge is retried once.
+func (c *Client) ExecuteResourceRequest(ctx context.Context, req *ResourceRequest) (*Response, error) {
+	scheme := "Bearer"
+	if req.DPoP != nil {
+		scheme = "DPoP"
+	}
+
+	return c.sendWithDPoP(req.Method, req.URL, req.DPoP, func(proof string) (*Response, error) {
+		headers := maps.Clone(req.Headers)
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		if req.AccessToken != "" {
+			headers["Authorization"] = scheme + " " + req.AccessToken
+		}
+		if proof != "" {
+			headers["DPoP"] = proof
+		}
+		var body io.Reader
+		if req.Body != nil {
+			body = bytes.NewReader(req.Body)
+		}
+		return c.Do(ctx, req.Method, req.URL, body, headers)
+	})
+}
+
+// ParseResourceResponse returns the body of a successful resource response. A
+// rejected request carries its error in the WWW-Authenticate header rather than
+// the body (RFC 6750 §3), so that is where the error fields are read from.
+func ParseResourceResponse(resp *Response) ([]byte, error) {
+	if resp.IsSuccess() {
+		return resp.Body, nil
+	}
+	oauth2Err := &Error{
+		StatusCode: resp.StatusCode,
+		RawBody:    resp.String(),
+	}
+	for _, challenge := range ParseWWWAuthenticate(resp.Headers.Get("WWW-Authenticate")) {
+		if errStr := challenge.Param("error"); errStr != "" {
+			oauth2Err.ErrorType = errStr
+			oauth2Err.ErrorDescription = challenge.Param("error_description")
+			return nil, fmt.Errorf("%w: %w", ErrOAuthError, oauth2Err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %w", ErrHTTPFailure, oauth2Err)
+}
+
+// IsInvalidTokenChallenge reports whether resp rejects the presented access
+// token as expired, revoked or otherwise invalid (RFC 6750 §3.1), the one
+// rejection a new token can fix.
+func IsInvalidTokenChallenge(resp *Response) bool {
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	for _, challenge := range ParseWWWAuthenticate(resp.Headers.Get("WWW-Authenticate")) {
+		if challenge.Param("error") == "invalid_token" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExecuteResourceRequest(t *testing.T) {
+	t.Parallel()
+
+	var proofURL string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "DPoP access-123" {
+			t.Errorf("Authorization = %q, want %q", got, "DPoP access-123")
+		}
+		if got := r.Header.Get("DPoP"); got != "proof-POST" {
+			t.Errorf("DPoP = %q, want %q", got, "proof-POST")
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want %q", got, "application/json")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"a":1}` {
+			t.Errorf("body = %q, want %q", body, `{"a":1}`)
+		}
+		_, _ = w.Write([]byte("created"))
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(nil).ExecuteResourceRequest(context.Background(), &ResourceRequest{
+		Method:      http.MethodPost,
+		URL:         ts.URL + "/items",
+		AccessToken: "access-123",
+		Headers:     map[string]string{"Content-Type": "application/json"},
+		Body:        []byte(`{"a":1}`),
+		DPoP: func(method, requestURL, _ string) (string, error) {
+			proofURL = requestURL
+			return "proof-" + method, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, err := ParseResourceResponse(resp)
+	if err != nil {
+		t.Fatalf("ParseResourceResponse() error = %v", err)
+	}
+	if string(body) != "created" {
+		t.Errorf("body = %q, want %q", body, "created")
+	}
+	if proofURL != ts.URL+"/items" {
+		t.Errorf("proof htu = %q, want %q", proofURL, ts.URL+"/items")
+	}
+}
+
+func TestParseResourceResponseErrors(t *testing.T) {
+	t.Parallel()
+
+	header := func(challenge string) http.Header {
+		h := make(http.Header)
+		if challenge != "" {
+			h.Set("WWW-Authenticate", challenge)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name     string
+		resp     *Response
+		wantErr  error
+		wantType string
+	}{
+		{
+			name:     "bearer challenge",
+			resp:     &Response{StatusCode: http.StatusUnauthorized, Headers: header(`Bearer error="invalid_token", error_description="expired"`)},
+			wantErr:  ErrOAuthError,
+			wantType: "invalid_token",
+		},
+		{
+			name:     "dpop challenge after bearer without error",
+			resp:     &Response{StatusCode: http.StatusUnauthorized, Headers: header(`Bearer realm="api", DPoP error="invalid_dpop_proof"`)},
+			wantErr:  ErrOAuthError,
+			wantType: "invalid_dpop_proof",
+		},
+		{
+			name:    "no challenge",
+			resp:    &Response{StatusCode: http.StatusInternalServerError, Headers: header(""), Body: []byte("boom")},
+			wantErr: ErrHTTPFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseResourceResponse(tt.resp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var oauth2Err *Error
+			if !errors.As(err, &oauth2Err) {
+				t.Fatalf("error %v does not wrap *Error", err)
+			}
+			if oauth2Err.ErrorType != tt.wantType {
+				t.Errorf("ErrorType = %q, want %q", oauth2Err.ErrorType, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestIsInvalidTokenChallenge(t *testing.T) {
+	t.Parallel()
+
+	response := func(status int, challenge string) *Response {
+		h := make(http.Header)
+		h.Set("WWW-Authenticate", challenge)
+		return &Response{StatusCode: status, Headers: h}
+	}
+
+	tests := []struct {
+		name string
+		resp *Response
+		want bool
+	}{
+		{"bearer invalid_token", response(http.StatusUnauthorized, `Bearer error="invalid_token"`), true},
+		{"dpop invalid_token", response(http.StatusUnauthorized, `DPoP algs="ES256", error="invalid_token"`), true},
+		{"insufficient scope", response(http.StatusForbidden, `Bearer error="insufficient_scope"`), false},
+		{"nonce challenge", response(http.StatusUnauthorized, `DPoP error="use_dpop_nonce"`), false},
+		{"no error", response(http.StatusUnauthorized, `Bearer realm="api"`), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsInvalidTokenChallenge(tt.resp); got != tt.want {
+				t.Errorf("IsInvalidTokenChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package httpclient
+
+// StepUpChallenge returns the challenge in a WWW-Authenticate header value
+// that rejects an access token with insufficient_user_authentication (RFC
+// 9470 §3), carrying the acr_values and max_age the resource server demands.
+// It reports false when the header carries no such challenge.
+func StepUpChallenge(header string) (Challenge, bool) {
+	for _, challenge := range ParseWWWAuthenticate(header) {
+		if challenge.Param("error") == "insufficient_user_authentication" {
+			return challenge, true
+		}
+	}
+	return Challenge{}, false
+}
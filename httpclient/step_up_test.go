@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"testing"
+)
+
+func TestStepUpChallenge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		header        string
+		wantOK        bool
+		wantAcrValues string
+		wantMaxAge    string
+	}{
+		{
+			name:          "acr_values and max_age",
+			header:        `Bearer error="insufficient_user_authentication", error_description="A different authentication level is required", acr_values="myACR", max_age="5"`,
+			wantOK:        true,
+			wantAcrValues: "myACR",
+			wantMaxAge:    "5",
+		},
+		{
+			name:       "after another challenge",
+			header:     `DPoP algs="ES256", Bearer error="insufficient_user_authentication", max_age=0`,
+			wantOK:     true,
+			wantMaxAge: "0",
+		},
+		{
+			name:   "other error",
+			header: `Bearer error="invalid_token"`,
+		},
+		{
+			name: "empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			challenge, ok := StepUpChallenge(tt.header)
+			if ok != tt.wantOK {
+				t.Fatalf("StepUpChallenge() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got := challenge.Param("acr_values"); got != tt.wantAcrValues {
+				t.Errorf("acr_values = %q, want %q", got, tt.wantAcrValues)
+			}
+			if got := challenge.Param("max_age"); got != tt.wantMaxAge {
+				t.Errorf("max_age = %q, want %q", got, tt.wantMaxAge)
+			}
+		})
+	}
+}
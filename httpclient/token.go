@@ -2,10 +2,12 @@ package httpclient
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
+	"slices"
 	"time"
 )
 
@@ -23,10 +25,6 @@ func sleepWithContext(ctx context.Context, d time.Duration) error {
 	}
 }
 
-// DPoPProofFunc generates a DPoP proof for the given HTTP method and URL.
-// It is called once per request, ensuring each token request carries a fresh proof.
-type DPoPProofFunc func(method, requestURL string) (string, error)
-
 // TokenRequest represents an OAuth2 token request
 type TokenRequest struct {
 	GrantType    string
@@ -34,13 +32,16 @@ type TokenRequest struct {
 	ClientSecret string
 	AuthMethod   AuthMethod
 	Params       url.Values
-	DPoP         DPoPProofFunc
+	// Resources are resource indicators (RFC 8707), each sent as a resource
+	// parameter.
+	Resources []string
+	DPoP      DPoPProofFunc
 }
 
 // TokenExchangeInput is used to construct the parameters of a token exchange request
 type TokenExchangeInput struct {
 	GrantType          string
-	Resource           string
+	Resources          []string
 	Audience           string
 	Scope              string
 	RequestedTokenType string
@@ -60,35 +61,21 @@ func (c *Client) ExecuteTokenRequest(ctx context.Context, tokenEndpoint string,
 
 	// Set grant type
 	req.Params.Set("grant_type", req.GrantType)
+	if len(req.Resources) > 0 {
+		req.Params["resource"] = slices.Clone(req.Resources)
+	}
 
 	// Apply authentication method
-	switch req.AuthMethod {
-	case AuthMethodBasic:
-		// Use HTTP Basic Auth
-		auth := base64.StdEncoding.EncodeToString([]byte(req.ClientID + ":" + req.ClientSecret))
-		headers["Authorization"] = "Basic " + auth
-	case AuthMethodPost:
-		// Include credentials in request body
-		req.Params.Set("client_id", req.ClientID)
-		if req.ClientSecret != "" {
-			req.Params.Set("client_secret", req.ClientSecret)
-		}
-	case AuthMethodNone:
-		// Just include client_id in request body
-		req.Params.Set("client_id", req.ClientID)
-	}
+	applyClientAuth(req.Params, headers, req.AuthMethod, req.ClientID, req.ClientSecret)
 
-	// Generate and attach a fresh DPoP proof if configured
-	if req.DPoP != nil {
-		proof, err := req.DPoP("POST", tokenEndpoint)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate DPoP proof: %w", err)
+	// Attach a fresh DPoP proof if configured, retrying once on a nonce challenge
+	return c.sendWithDPoP(http.MethodPost, tokenEndpoint, req.DPoP, func(proof string) (*Response, error) {
+		attemptHeaders := maps.Clone(headers)
+		if proof != "" {
+			attemptHeaders["DPoP"] = proof
 		}
-		headers["DPoP"] = proof
-	}
-
-	// Execute the request
-	return c.PostForm(ctx, tokenEndpoint, req.Params, headers)
+		return c.PostForm(ctx, tokenEndpoint, req.Params, attemptHeaders)
+	})
 }
 
 // ExecutePollingTokenRequest sends a token request to the specified endpoint, polling at the specified interval until a successful response is received
@@ -97,6 +84,10 @@ func (c *Client) ExecutePollingTokenRequest(ctx context.Context, tokenEndpoint s
 		interval = 5 // Default polling interval in seconds
 	}
 
+	// A poll that still ends in use_dpop_nonce after ExecuteTokenRequest's
+	// own retry is polled again once; a server rejecting every nonce ends
+	// polling rather than spinning until the context does
+	nonceRetried := false
 	for {
 		resp, err := c.ExecuteTokenRequest(ctx, tokenEndpoint, req)
 		if err != nil {
@@ -109,18 +100,28 @@ func (c *Client) ExecutePollingTokenRequest(ctx context.Context, tokenEndpoint s
 
 		_, err = ParseTokenResponse(resp)
 		if errors.Is(err, ErrAuthorizationPending) {
+			nonceRetried = false
 			// Wait and poll again
 			if err := c.sleep(ctx, time.Duration(interval)*time.Second); err != nil {
 				return nil, err
 			}
 			continue
 		} else if errors.Is(err, ErrSlowDown) {
+			nonceRetried = false
 			// Increase interval and poll again
 			interval += 5
 			if err := c.sleep(ctx, time.Duration(interval)*time.Second); err != nil {
 				return nil, err
 			}
 			continue
+		} else if errors.Is(err, ErrUseDPoPNonce) && !nonceRetried {
+			// The nonce rotated between the retry and its response; the newest
+			// one is remembered, so wait and poll again with it
+			nonceRetried = true
+			if err := c.sleep(ctx, time.Duration(interval)*time.Second); err != nil {
+				return nil, err
+			}
+			continue
 		}
 		return nil, err
 	}
@@ -177,6 +178,24 @@ func CreateClientCredentialsRequest(clientID, clientSecret string, authMethod Au
 	}
 }
 
+// CreatePasswordTokenRequest creates a token request for the resource owner password credentials grant
+func CreatePasswordTokenRequest(clientID, clientSecret string, authMethod AuthMethod, username, password, scope string) *TokenRequest {
+	params := url.Values{}
+	params.Set("username", username)
+	params.Set("password", password)
+	if scope != "" {
+		params.Set("scope", scope)
+	}
+
+	return &TokenRequest{
+		GrantType:    "password",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthMethod:   authMethod,
+		Params:       params,
+	}
+}
+
 // CreateDeviceCodeTokenRequest creates a token request for the device code grant
 func CreateDeviceCodeTokenRequest(clientID, clientSecret string, authMethod AuthMethod, deviceCode, codeVerifier string) *TokenRequest {
 	params := url.Values{}
@@ -195,6 +214,46 @@ func CreateDeviceCodeTokenRequest(clientID, clientSecret string, authMethod Auth
 	}
 }
 
+// CreateCIBATokenRequest creates a token request for the CIBA grant
+func CreateCIBATokenRequest(clientID, clientSecret string, authMethod AuthMethod, authReqID string) *TokenRequest {
+	params := url.Values{}
+	params.Set("auth_req_id", authReqID)
+
+	return &TokenRequest{
+		GrantType:    "urn:openid:params:grant-type:ciba",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthMethod:   authMethod,
+		Params:       params,
+	}
+}
+
+// CreateJWTBearerTokenRequest creates a token request for the JWT bearer grant (RFC 7523 §2.1)
+func CreateJWTBearerTokenRequest(clientID, clientSecret string, authMethod AuthMethod, assertion, scope string) *TokenRequest {
+	return createAssertionTokenRequest("urn:ietf:params:oauth:grant-type:jwt-bearer", clientID, clientSecret, authMethod, assertion, scope)
+}
+
+// CreateSAML2BearerTokenRequest creates a token request for the SAML 2.0 bearer grant (RFC 7522 §2.1)
+func CreateSAML2BearerTokenRequest(clientID, clientSecret string, authMethod AuthMethod, assertion, scope string) *TokenRequest {
+	return createAssertionTokenRequest("urn:ietf:params:oauth:grant-type:saml2-bearer", clientID, clientSecret, authMethod, assertion, scope)
+}
+
+func createAssertionTokenRequest(grantType, clientID, clientSecret string, authMethod AuthMethod, assertion, scope string) *TokenRequest {
+	params := url.Values{}
+	params.Set("assertion", assertion)
+	if scope != "" {
+		params.Set("scope", scope)
+	}
+
+	return &TokenRequest{
+		GrantType:    grantType,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthMethod:   authMethod,
+		Params:       params,
+	}
+}
+
 // CreateTokenExchangeRequest creates a token request for the token exchange grant
 func CreateTokenExchangeRequest(clientID, clientSecret string, authMethod AuthMethod, input *TokenExchangeInput) *TokenRequest {
 	params := url.Values{}
@@ -204,9 +263,6 @@ func CreateTokenExchangeRequest(clientID, clientSecret string, authMethod AuthMe
 	params.Set("subject_token_type", input.SubjectTokenType)
 
 	// Optional parameters
-	if input.Resource != "" {
-		params.Set("resource", input.Resource)
-	}
 	if input.Audience != "" {
 		params.Set("audience", input.Audience)
 	}
@@ -229,6 +285,26 @@ func CreateTokenExchangeRequest(clientID, clientSecret string, authMethod AuthMe
 		ClientSecret: clientSecret,
 		AuthMethod:   authMethod,
 		Params:       params,
+		Resources:    input.Resources,
+	}
+}
+
+// CreateCustomGrantTokenRequest creates a token request for an extension grant
+// identified by grantType, sending customArgs as its parameters
+func CreateCustomGrantTokenRequest(grantType, clientID, clientSecret string, authMethod AuthMethod, customArgs *CustomArgs) *TokenRequest {
+	params := url.Values{}
+	if customArgs != nil {
+		for k, v := range *customArgs {
+			params.Set(k, v)
+		}
+	}
+
+	return &TokenRequest{
+		GrantType:    grantType,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthMethod:   authMethod,
+		Params:       params,
 	}
 }
 
@@ -260,6 +336,8 @@ func ParseTokenResponse(resp *Response) (map[string]any, error) {
 				return tokenResp, fmt.Errorf("%w: %w", ErrAuthorizationPending, oauth2Err)
 			case "slow_down":
 				return tokenResp, fmt.Errorf("%w: %w", ErrSlowDown, oauth2Err)
+			case "use_dpop_nonce":
+				return tokenResp, fmt.Errorf("%w: %w", ErrUseDPoPNonce, oauth2Err)
 			default:
 				return tokenResp, fmt.Errorf("%w: %w", ErrOAuthError, oauth2Err)
 			}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -15,10 +17,11 @@ import (
 func TestExecuteTokenRequest(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name       string
-		req        *TokenRequest
-		wantParams map[string]string
-		wantAuth   string
+		name          string
+		req           *TokenRequest
+		wantParams    map[string]string
+		wantResources []string
+		wantAuth      string
 	}{
 		{
 			name: "basic auth method",
@@ -63,6 +66,19 @@ func TestExecuteTokenRequest(t *testing.T) {
 				"code":       "auth-code",
 			},
 		},
+		{
+			name: "resource indicators",
+			req: &TokenRequest{
+				GrantType:  "client_credentials",
+				ClientID:   "public-client",
+				AuthMethod: AuthMethodNone,
+				Resources:  []string{"https://api.example.com", "https://other.example.com"},
+			},
+			wantParams: map[string]string{
+				"grant_type": "client_credentials",
+			},
+			wantResources: []string{"https://api.example.com", "https://other.example.com"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -89,6 +105,9 @@ func TestExecuteTokenRequest(t *testing.T) {
 						t.Errorf("got param %s=%q, want %q", key, got, want)
 					}
 				}
+				if got := r.Form["resource"]; !slices.Equal(got, tt.wantResources) {
+					t.Errorf("got resource params %q, want %q", got, tt.wantResources)
+				}
 
 				w.WriteHeader(http.StatusOK)
 				_, _ = w.Write([]byte(`{"access_token":"token123","token_type":"Bearer"}`))
@@ -129,7 +148,7 @@ func TestExecuteTokenRequest_DPoPFunctionCalledWithMethodAndURL(t *testing.T) {
 		ClientID:     "test-client",
 		ClientSecret: "test-secret",
 		AuthMethod:   AuthMethodPost,
-		DPoP: func(method, requestURL string) (string, error) {
+		DPoP: func(method, requestURL, _ string) (string, error) {
 			gotMethod = method
 			gotURL = requestURL
 			return "proof-123", nil
@@ -168,7 +187,7 @@ func TestExecuteTokenRequest_DPoPGenerationError(t *testing.T) {
 		GrantType:  "client_credentials",
 		ClientID:   "test-client",
 		AuthMethod: AuthMethodPost,
-		DPoP: func(_, _ string) (string, error) {
+		DPoP: func(_, _, _ string) (string, error) {
 			return "", errors.New("dpop generation failure")
 		},
 	}
@@ -308,7 +327,7 @@ func TestExecutePollingTokenRequest_DPoPFreshPerAttempt(t *testing.T) {
 		ClientSecret: "device-secret",
 		AuthMethod:   AuthMethodBasic,
 		Params:       url.Values{"device_code": []string{"device123"}},
-		DPoP: func(_, _ string) (string, error) {
+		DPoP: func(_, _, _ string) (string, error) {
 			proofCounter++
 			return fmt.Sprintf("proof-%d", proofCounter), nil
 		},
@@ -333,6 +352,41 @@ func TestExecutePollingTokenRequest_DPoPFreshPerAttempt(t *testing.T) {
 	}
 }
 
+func TestExecutePollingTokenRequest_DPoPNonceRejectedEveryTime(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.Header().Set("DPoP-Nonce", fmt.Sprintf("nonce-%d", attempts))
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"use_dpop_nonce"}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(nil)
+	client.SetSleepFunc(func(ctx context.Context, _ time.Duration) error {
+		return sleepWithContext(ctx, 1*time.Millisecond)
+	})
+	req := &TokenRequest{
+		GrantType: "urn:ietf:params:oauth:grant-type:device_code",
+		ClientID:  "device-client",
+		Params:    url.Values{"device_code": []string{"device123"}},
+		DPoP: func(_, _, _ string) (string, error) {
+			return "proof", nil
+		},
+	}
+
+	_, err := client.ExecutePollingTokenRequest(context.Background(), ts.URL, req, 1)
+	if !errors.Is(err, ErrUseDPoPNonce) {
+		t.Fatalf("error = %v, want ErrUseDPoPNonce", err)
+	}
+	// Two polls, each retried once with the new nonce
+	if attempts != 4 {
+		t.Errorf("attempts = %d, want 4", attempts)
+	}
+}
+
 func TestExecutePollingTokenRequest_ContextCancellation(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -622,6 +676,78 @@ func TestCreateClientCredentialsRequest(t *testing.T) {
 	}
 }
 
+func TestCreatePasswordTokenRequest(t *testing.T) {
+	t.Parallel()
+	req := CreatePasswordTokenRequest("legacy-client", "", AuthMethodNone, "alice", "s3cret", "openid")
+
+	if req.GrantType != "password" {
+		t.Errorf("got GrantType %q, want %q", req.GrantType, "password")
+	}
+
+	if req.ClientID != "legacy-client" || req.ClientSecret != "" || req.AuthMethod != AuthMethodNone {
+		t.Errorf("got client %q/%q/%v, want legacy-client with no secret and %v", req.ClientID, req.ClientSecret, req.AuthMethod, AuthMethodNone)
+	}
+
+	want := url.Values{
+		"username": {"alice"},
+		"password": {"s3cret"},
+		"scope":    {"openid"},
+	}
+	if !reflect.DeepEqual(req.Params, want) {
+		t.Errorf("got Params %v, want %v", req.Params, want)
+	}
+}
+
+func TestCreateAssertionTokenRequests(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		create        func(clientID, clientSecret string, authMethod AuthMethod, assertion, scope string) *TokenRequest
+		wantGrantType string
+	}{
+		{"jwt bearer", CreateJWTBearerTokenRequest, "urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		{"saml2 bearer", CreateSAML2BearerTokenRequest, "urn:ietf:params:oauth:grant-type:saml2-bearer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := tt.create("bearer-client", "bearer-secret", AuthMethodBasic, "assertion-1", "read")
+
+			if req.GrantType != tt.wantGrantType {
+				t.Errorf("got GrantType %q, want %q", req.GrantType, tt.wantGrantType)
+			}
+			if req.ClientID != "bearer-client" || req.ClientSecret != "bearer-secret" || req.AuthMethod != AuthMethodBasic {
+				t.Errorf("got client %q/%q/%v, want bearer-client/bearer-secret/%v", req.ClientID, req.ClientSecret, req.AuthMethod, AuthMethodBasic)
+			}
+			want := url.Values{"assertion": {"assertion-1"}, "scope": {"read"}}
+			if !reflect.DeepEqual(req.Params, want) {
+				t.Errorf("got Params %v, want %v", req.Params, want)
+			}
+		})
+	}
+}
+
+func TestCreateCustomGrantTokenRequest(t *testing.T) {
+	t.Parallel()
+	req := CreateCustomGrantTokenRequest("urn:example:grant-type:otp", "otp-client", "otp-secret", AuthMethodPost, &CustomArgs{"otp": "123456", "username": "alice"})
+
+	if req.GrantType != "urn:example:grant-type:otp" {
+		t.Errorf("got GrantType %q, want %q", req.GrantType, "urn:example:grant-type:otp")
+	}
+	if req.ClientID != "otp-client" || req.ClientSecret != "otp-secret" || req.AuthMethod != AuthMethodPost {
+		t.Errorf("got client %q/%q/%v, want otp-client/otp-secret/%v", req.ClientID, req.ClientSecret, req.AuthMethod, AuthMethodPost)
+	}
+	want := url.Values{"otp": {"123456"}, "username": {"alice"}}
+	if !reflect.DeepEqual(req.Params, want) {
+		t.Errorf("got Params %v, want %v", req.Params, want)
+	}
+
+	if req := CreateCustomGrantTokenRequest("urn:example:grant-type:otp", "otp-client", "", AuthMethodNone, nil); len(req.Params) != 0 {
+		t.Errorf("got Params %v, want none without custom arguments", req.Params)
+	}
+}
+
 func TestCreateDeviceCodeTokenRequest(t *testing.T) {
 	t.Parallel()
 	req := CreateDeviceCodeTokenRequest("device-client", "device-secret", AuthMethodBasic, "device123", "test-code-verifier")
@@ -656,6 +782,24 @@ func TestCreateDeviceCodeTokenRequest(t *testing.T) {
 	}
 }
 
+func TestCreateCIBATokenRequest(t *testing.T) {
+	t.Parallel()
+	req := CreateCIBATokenRequest("ciba-client", "ciba-secret", AuthMethodPost, "auth-req-1")
+
+	wantGrantType := "urn:openid:params:grant-type:ciba"
+	if req.GrantType != wantGrantType {
+		t.Errorf("got GrantType %q, want %q", req.GrantType, wantGrantType)
+	}
+
+	if req.ClientID != "ciba-client" || req.ClientSecret != "ciba-secret" || req.AuthMethod != AuthMethodPost {
+		t.Errorf("got client %q/%q/%v, want ciba-client/ciba-secret/%v", req.ClientID, req.ClientSecret, req.AuthMethod, AuthMethodPost)
+	}
+
+	if got := req.Params.Get("auth_req_id"); got != "auth-req-1" {
+		t.Errorf("got auth_req_id %q, want %q", got, "auth-req-1")
+	}
+}
+
 func TestCreateTokenExchangeRequest(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -679,7 +823,6 @@ func TestCreateTokenExchangeRequest(t *testing.T) {
 				"subject_token":      "subject-token",
 				"subject_token_type": "urn:ietf:params:oauth:token-type:access_token",
 				// Check that optional params are not set
-				"resource":             "",
 				"audience":             "",
 				"scope":                "",
 				"requested_token_type": "",
@@ -695,7 +838,7 @@ func TestCreateTokenExchangeRequest(t *testing.T) {
 			input: TokenExchangeInput{
 				SubjectToken:       "subject-token",
 				SubjectTokenType:   "urn:ietf:params:oauth:token-type:access_token",
-				Resource:           "https://api.example.com",
+				Resources:          []string{"https://api.example.com", "https://other.example.com"},
 				Audience:           "https://api.example.com",
 				Scope:              "read write",
 				RequestedTokenType: "urn:ietf:params:oauth:token-type:access_token",
@@ -705,7 +848,6 @@ func TestCreateTokenExchangeRequest(t *testing.T) {
 			wantParams: map[string]string{
 				"subject_token":        "subject-token",
 				"subject_token_type":   "urn:ietf:params:oauth:token-type:access_token",
-				"resource":             "https://api.example.com",
 				"audience":             "https://api.example.com",
 				"scope":                "read write",
 				"requested_token_type": "urn:ietf:params:oauth:token-type:access_token",
@@ -737,6 +879,10 @@ func TestCreateTokenExchangeRequest(t *testing.T) {
 				t.Errorf("got GrantType %q, want %q", req.GrantType, "urn:ietf:params:oauth:grant-type:token-exchange")
 			}
 
+			if !slices.Equal(req.Resources, tt.input.Resources) {
+				t.Errorf("got Resources %q, want %q", req.Resources, tt.input.Resources)
+			}
+
 			// Check params
 			for key, want := range tt.wantParams {
 				got := req.Params.Get(key)
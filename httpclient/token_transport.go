@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// AccessTokenFunc returns the access token to present. When rejected is not
+// empty, the resource server refused that token and a new one is wanted.
+type AccessTokenFunc func(ctx context.Context, rejected string) (string, error)
+
+// TokenTransport is an http.RoundTripper that presents an access token on each
+// request it forwards, for relaying requests to a protected resource. A DPoP
+// nonce challenge is answered by retrying with the new nonce, and an
+// invalid_token rejection by retrying once with a new token. Request bodies are
+// buffered so a retry can replay them.
+type TokenTransport struct {
+	Client *Client
+	Token  AccessTokenFunc
+	// DPoP, when set, returns the proof function bound to an access token, and
+	// the token is presented under the DPoP scheme; otherwise it is sent as a
+	// Bearer token.
+	DPoP func(accessToken string) DPoPProofFunc
+}
+
+func (t *TokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+	}
+
+	token, err := t.Token(req.Context(), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	resp, err := t.send(req, body, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.DPoP != nil && IsDPoPNonceChallenge(headersOnly(resp)) {
+		t.Client.logger.Printf("server requires a DPoP nonce, retrying %s %s\n", req.Method, req.URL)
+		discard(resp)
+		if resp, err = t.send(req, body, token); err != nil {
+			return nil, err
+		}
+	}
+
+	if IsInvalidTokenChallenge(headersOnly(resp)) {
+		newToken, err := t.Token(req.Context(), token)
+		if err != nil {
+			// Relay the rejection itself rather than a gateway error
+			t.Client.logger.Printf("access token rejected and could not be replaced: %v\n", err)
+			return resp, nil
+		}
+		discard(resp)
+		return t.send(req, body, newToken)
+	}
+	return resp, nil
+}
+
+// send forwards one attempt of req with token, and a fresh proof for DPoP.
+func (t *TokenTransport) send(req *http.Request, body []byte, token string) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+	}
+
+	rawURL := out.URL.String()
+	scheme := "Bearer"
+	if t.DPoP != nil {
+		scheme = "DPoP"
+		proof, err := t.DPoP(token)(out.Method, rawURL, t.Client.nonces.get(rawURL))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate DPoP proof: %w", err)
+		}
+		out.Header.Set("DPoP", proof)
+	}
+	out.Header.Set("Authorization", scheme+" "+token)
+
+	resp, err := t.Client.client.Transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	t.Client.nonces.remember(rawURL, resp.Header)
+	return resp, nil
+}
+
+// headersOnly exposes the status and headers of a streamed response to the
+// challenge checks, which need no body for a resource server's 401.
+func headersOnly(resp *http.Response) *Response {
+	return &Response{StatusCode: resp.StatusCode, Headers: resp.Header}
+}
+
+// discard drains and closes a response that is being retried, so its
+// connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// staticTokens hands out tokens in turn, recording which token each request
+// for a new one reported as rejected.
+type staticTokens struct {
+	tokens   []string
+	rejected []string
+}
+
+func (s *staticTokens) token(_ context.Context, rejected string) (string, error) {
+	if rejected != "" {
+		s.rejected = append(s.rejected, rejected)
+		if len(s.tokens) < 2 {
+			return "", errors.New("no new token")
+		}
+		s.tokens = s.tokens[1:]
+	}
+	return s.tokens[0], nil
+}
+
+func proxyRequest(t *testing.T, transport http.RoundTripper, method, rawURL, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, rawURL, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer caller-supplied")
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestTokenTransportRefreshesOnInvalidToken(t *testing.T) {
+	t.Parallel()
+
+	var auths, bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer at-2" {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	tokens := &staticTokens{tokens: []string{"at-1", "at-2"}}
+	transport := &TokenTransport{Client: NewClient(nil), Token: tokens.token}
+	resp := proxyRequest(t, transport, http.MethodPost, ts.URL+"/items", "payload")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if want := []string{"Bearer at-1", "Bearer at-2"}; !slices.Equal(auths, want) {
+		t.Errorf("Authorization headers = %v, want %v", auths, want)
+	}
+	if want := []string{"payload", "payload"}; !slices.Equal(bodies, want) {
+		t.Errorf("bodies = %v, want the body replayed %v", bodies, want)
+	}
+	if want := []string{"at-1"}; !slices.Equal(tokens.rejected, want) {
+		t.Errorf("rejected = %v, want %v", tokens.rejected, want)
+	}
+}
+
+func TestTokenTransportRelaysRejectionWithoutNewToken(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("expired"))
+	}))
+	defer ts.Close()
+
+	tokens := &staticTokens{tokens: []string{"static"}}
+	transport := &TokenTransport{Client: NewClient(nil), Token: tokens.token}
+	resp := proxyRequest(t, transport, http.MethodGet, ts.URL, "")
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 relayed", resp.StatusCode)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "expired" {
+		t.Errorf("body = %q, want the upstream body", body)
+	}
+}
+
+func TestTokenTransportDPoPNonceChallenge(t *testing.T) {
+	t.Parallel()
+
+	var proofs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proofs = append(proofs, r.Header.Get("DPoP"))
+		if got := r.Header.Get("Authorization"); got != "DPoP at-1" {
+			t.Errorf("Authorization = %q, want %q", got, "DPoP at-1")
+		}
+		if r.Header.Get("DPoP") != "at-1 GET nonce=rs-nonce" {
+			w.Header().Set(DPoPNonceHeader, "rs-nonce")
+			w.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	tokens := &staticTokens{tokens: []string{"at-1"}}
+	transport := &TokenTransport{
+		Client: NewClient(nil),
+		Token:  tokens.token,
+		DPoP: func(accessToken string) DPoPProofFunc {
+			return func(method, _, nonce string) (string, error) {
+				return accessToken + " " + method + " nonce=" + nonce, nil
+			}
+		},
+	}
+	resp := proxyRequest(t, transport, http.MethodGet, ts.URL+"/items?page=2", "")
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if want := []string{"at-1 GET nonce=", "at-1 GET nonce=rs-nonce"}; !slices.Equal(proofs, want) {
+		t.Errorf("proofs = %v, want %v", proofs, want)
+	}
+	if len(tokens.rejected) != 0 {
+		t.Errorf("rejected = %v, want no token replaced for a nonce challenge", tokens.rejected)
+	}
+}
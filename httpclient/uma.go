@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// UMATicketInput is used to construct the parameters of an UMA 2.0 grant
+// request (UMA 2.0 Grant §3.3.1)
+type UMATicketInput struct {
+	Ticket           string
+	ClaimToken       string
+	ClaimTokenFormat string
+	PCT              string
+	RPT              string
+	Scope            string
+}
+
+// CreateUMATicketTokenRequest creates a token request for the UMA 2.0 permission ticket grant
+func CreateUMATicketTokenRequest(clientID, clientSecret string, authMethod AuthMethod, input *UMATicketInput) *TokenRequest {
+	params := url.Values{}
+
+	// Required parameters
+	params.Set("ticket", input.Ticket)
+
+	// Optional parameters
+	if input.ClaimToken != "" {
+		params.Set("claim_token", input.ClaimToken)
+		params.Set("claim_token_format", input.ClaimTokenFormat)
+	}
+	if input.PCT != "" {
+		params.Set("pct", input.PCT)
+	}
+	if input.RPT != "" {
+		params.Set("rpt", input.RPT)
+	}
+	if input.Scope != "" {
+		params.Set("scope", input.Scope)
+	}
+
+	return &TokenRequest{
+		GrantType:    "urn:ietf:params:oauth:grant-type:uma-ticket",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthMethod:   authMethod,
+		Params:       params,
+	}
+}
+
+// UMAPermissionChallenge returns the UMA challenge a resource server answers
+// an unauthorized request with (UMA 2.0 Grant §3.2): the permission ticket
+// and the as_uri of the authorization server that accepts it. It reports
+// false when resp carries no UMA challenge with a ticket.
+func UMAPermissionChallenge(resp *Response) (Challenge, bool) {
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return Challenge{}, false
+	}
+	challenge, ok := FindChallenge(resp.Headers.Get("WWW-Authenticate"), "UMA")
+	if !ok || challenge.Param("ticket") == "" {
+		return Challenge{}, false
+	}
+	return challenge, true
+}
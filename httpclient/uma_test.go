@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCreateUMATicketTokenRequest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input *UMATicketInput
+		want  url.Values
+	}{
+		{
+			name:  "ticket only",
+			input: &UMATicketInput{Ticket: "ticket-1"},
+			want:  url.Values{"ticket": {"ticket-1"}},
+		},
+		{
+			name: "all parameters",
+			input: &UMATicketInput{
+				Ticket:           "ticket-1",
+				ClaimToken:       "id-token",
+				ClaimTokenFormat: "http://openid.net/specs/openid-connect-core-1_0.html#IDToken",
+				PCT:              "pct-1",
+				RPT:              "rpt-1",
+				Scope:            "read",
+			},
+			want: url.Values{
+				"ticket":             {"ticket-1"},
+				"claim_token":        {"id-token"},
+				"claim_token_format": {"http://openid.net/specs/openid-connect-core-1_0.html#IDToken"},
+				"pct":                {"pct-1"},
+				"rpt":                {"rpt-1"},
+				"scope":              {"read"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := CreateUMATicketTokenRequest("uma-client", "uma-secret", AuthMethodBasic, tt.input)
+			if req.GrantType != "urn:ietf:params:oauth:grant-type:uma-ticket" {
+				t.Errorf("got GrantType %q, want the uma-ticket grant", req.GrantType)
+			}
+			if req.ClientID != "uma-client" || req.ClientSecret != "uma-secret" || req.AuthMethod != AuthMethodBasic {
+				t.Errorf("got client %q/%q/%v, want uma-client/uma-secret/%v", req.ClientID, req.ClientSecret, req.AuthMethod, AuthMethodBasic)
+			}
+			if !reflect.DeepEqual(req.Params, tt.want) {
+				t.Errorf("got Params %v, want %v", req.Params, tt.want)
+			}
+		})
+	}
+}
+
+func TestUMAPermissionChallenge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		resp       *Response
+		wantOK     bool
+		wantTicket string
+		wantASURI  string
+	}{
+		{
+			name: "uma challenge",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: http.Header{
+				"Www-Authenticate": {`UMA realm="example", as_uri="https://as.example.com", ticket="016f84e8-f9b9-11e0-bd6f-0021cc6004de"`},
+			}},
+			wantOK:     true,
+			wantTicket: "016f84e8-f9b9-11e0-bd6f-0021cc6004de",
+			wantASURI:  "https://as.example.com",
+		},
+		{
+			name: "uma after bearer",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: http.Header{
+				"Www-Authenticate": {`Bearer realm="example", UMA as_uri="https://as.example.com", ticket=t-1`},
+			}},
+			wantOK:     true,
+			wantTicket: "t-1",
+			wantASURI:  "https://as.example.com",
+		},
+		{
+			name: "bearer only",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: http.Header{
+				"Www-Authenticate": {`Bearer realm="example"`},
+			}},
+		},
+		{
+			name: "uma without ticket",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: http.Header{
+				"Www-Authenticate": {`UMA realm="example"`},
+			}},
+		},
+		{
+			name: "forbidden",
+			resp: &Response{StatusCode: http.StatusForbidden, Headers: http.Header{
+				"Www-Authenticate": {`UMA ticket="t-1"`},
+			}},
+		},
+		{
+			name: "nil response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			challenge, ok := UMAPermissionChallenge(tt.resp)
+			if ok != tt.wantOK {
+				t.Fatalf("UMAPermissionChallenge() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got := challenge.Param("ticket"); got != tt.wantTicket {
+				t.Errorf("ticket = %q, want %q", got, tt.wantTicket)
+			}
+			if got := challenge.Param("as_uri"); got != tt.wantASURI {
+				t.Errorf("as_uri = %q, want %q", got, tt.wantASURI)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type UserinfoRequest struct {
+	AccessToken string
+	// DPoP, when set, presents the access token under the DPoP scheme with a
+	// proof per request; otherwise the token is sent as a Bearer token. The
+	// proof must be bound to AccessToken through its ath claim.
+	DPoP DPoPProofFunc
+}
+
+// ExecuteUserinfoRequest calls the userinfo endpoint with the access token,
+// retrying once when a DPoP-protected endpoint demands a fresh nonce.
+func (c *Client) ExecuteUserinfoRequest(ctx context.Context, endpoint string, req *UserinfoRequest) (*Response, error) {
+	return c.ExecuteResourceRequest(ctx, &ResourceRequest{
+		Method:      http.MethodGet,
+		URL:         endpoint,
+		AccessToken: req.AccessToken,
+		Headers:     map[string]string{"Accept": "application/json"},
+		DPoP:        req.DPoP,
+	})
+}
+
+// ParseUserinfoResponse parses the userinfo response into a map, reading a
+// rejection from the WWW-Authenticate header as ParseResourceResponse does.
+func ParseUserinfoResponse(resp *Response) (map[string]any, error) {
+	body, err := ParseResourceResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var userinfo map[string]any
+	if err := json.Unmarshal(body, &userinfo); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParsingJSON, err)
+	}
+	return userinfo, nil
+}
@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExecuteUserinfoRequest(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		dpop      DPoPProofFunc
+		wantAuth  string
+		wantProof string
+	}{
+		{
+			name:     "bearer token",
+			wantAuth: "Bearer access-123",
+		},
+		{
+			name:      "dpop-bound token",
+			dpop:      func(_, _, _ string) (string, error) { return "proof-123", nil },
+			wantAuth:  "DPoP access-123",
+			wantProof: "proof-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("method = %s, want GET", r.Method)
+				}
+				if got := r.Header.Get("Authorization"); got != tt.wantAuth {
+					t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+				}
+				if got := r.Header.Get("DPoP"); got != tt.wantProof {
+					t.Errorf("DPoP = %q, want %q", got, tt.wantProof)
+				}
+				_, _ = w.Write([]byte(`{"sub":"alice"}`))
+			}))
+			defer ts.Close()
+
+			resp, err := NewClient(nil).ExecuteUserinfoRequest(context.Background(), ts.URL, &UserinfoRequest{
+				AccessToken: "access-123",
+				DPoP:        tt.dpop,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			userinfo, err := ParseUserinfoResponse(resp)
+			if err != nil {
+				t.Fatalf("ParseUserinfoResponse() error = %v", err)
+			}
+			if userinfo["sub"] != "alice" {
+				t.Errorf("sub = %v, want alice", userinfo["sub"])
+			}
+		})
+	}
+}
+
+// TestExecuteUserinfoRequest_DPoPNonceChallenge checks a resource server's
+// WWW-Authenticate nonce challenge is answered with one retry.
+func TestExecuteUserinfoRequest_DPoPNonceChallenge(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.Header.Get("DPoP") != "proof-rs-nonce" {
+			w.Header().Set(DPoPNonceHeader, "rs-nonce")
+			w.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce", error_description="Resource server requires nonce in DPoP proof"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"sub":"alice"}`))
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(nil).ExecuteUserinfoRequest(context.Background(), ts.URL, &UserinfoRequest{
+		AccessToken: "access-123",
+		DPoP:        func(_, _, nonce string) (string, error) { return "proof-" + nonce, nil },
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.IsSuccess() {
+		t.Errorf("status = %d, want success after the nonce retry", resp.StatusCode)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+}
+
+func TestParseUserinfoResponseError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		resp    *Response
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name: "error in www-authenticate",
+			resp: &Response{StatusCode: http.StatusUnauthorized, Headers: http.Header{
+				"Www-Authenticate": []string{`Bearer error="invalid_token", error_description="expired"`},
+			}},
+			wantErr: ErrOAuthError,
+			wantMsg: "invalid_token",
+		},
+		{
+			name:    "bare http failure",
+			resp:    &Response{StatusCode: http.StatusInternalServerError, Body: []byte("boom")},
+			wantErr: ErrHTTPFailure,
+		},
+		{
+			name:    "invalid json",
+			resp:    &Response{StatusCode: http.StatusOK, Body: []byte("not json")},
+			wantErr: ErrParsingJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseUserinfoResponse(tt.resp)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			var oauth2Err *Error
+			if tt.wantMsg != "" && (!errors.As(err, &oauth2Err) || oauth2Err.ErrorType != tt.wantMsg) {
+				t.Errorf("error = %v, want error type %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
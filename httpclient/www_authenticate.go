@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"strings"
+)
+
+// Challenge is a single authentication challenge from a WWW-Authenticate
+// response header: the scheme (eg. Bearer or DPoP) and its auth-params. Param
+// names are lower-cased; values are unquoted.
+type Challenge struct {
+	Scheme string
+	Params map[string]string
+}
+
+// Param returns the value of the named auth-param, or "" when absent.
+func (c Challenge) Param(name string) string {
+	return c.Params[strings.ToLower(name)]
+}
+
+// ParseWWWAuthenticate parses a WWW-Authenticate header value into its
+// challenges (RFC 9110 §11.6.1). A header may carry several challenges, eg.
+// `DPoP algs="ES256", error="use_dpop_nonce", Bearer realm="api"`. Malformed
+// input is skipped rather than rejected, since the header is only advisory.
+func ParseWWWAuthenticate(header string) []Challenge {
+	challenges := make([]Challenge, 0, 1)
+	p := &headerParser{s: header}
+	for {
+		p.skip(" \t,")
+		if p.done() {
+			return challenges
+		}
+		scheme := p.token()
+		if scheme == "" {
+			// Not a token character; drop it and resynchronize.
+			p.pos++
+			continue
+		}
+		challenge := Challenge{Scheme: scheme, Params: make(map[string]string)}
+		for {
+			p.skip(" \t")
+			start := p.pos
+			name := p.token()
+			p.skip(" \t")
+			if name == "" || !p.consume('=') {
+				// Either the end of the header or the next challenge's scheme.
+				p.pos = start
+				break
+			}
+			p.skip(" \t")
+			challenge.Params[strings.ToLower(name)] = p.value()
+			p.skip(" \t")
+			if !p.consume(',') {
+				break
+			}
+		}
+		challenges = append(challenges, challenge)
+	}
+}
+
+// FindChallenge returns the first challenge in a WWW-Authenticate header with
+// the given scheme, compared case-insensitively.
+func FindChallenge(header, scheme string) (Challenge, bool) {
+	for _, challenge := range ParseWWWAuthenticate(header) {
+		if strings.EqualFold(challenge.Scheme, scheme) {
+			return challenge, true
+		}
+	}
+	return Challenge{}, false
+}
+
+// headerParser is a cursor over a header value.
+type headerParser struct {
+	s   string
+	pos int
+}
+
+func (p *headerParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *headerParser) skip(chars string) {
+	for !p.done() && strings.IndexByte(chars, p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *headerParser) consume(c byte) bool {
+	if !p.done() && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// token reads an RFC 9110 token, returning "" when the cursor is not on one.
+func (p *headerParser) token() string {
+	start := p.pos
+	for !p.done() && isTokenChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// value reads an auth-param value, either a token or a quoted-string.
+func (p *headerParser) value() string {
+	if !p.consume('"') {
+		return p.token()
+	}
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == '"':
+			return b.String()
+		case c == '\\' && !p.done():
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+	}
+}
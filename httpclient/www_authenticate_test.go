@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWWWAuthenticate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		header string
+		want   []Challenge
+	}{
+		{
+			name:   "empty header",
+			header: "",
+			want:   []Challenge{},
+		},
+		{
+			name:   "scheme only",
+			header: "Bearer",
+			want:   []Challenge{{Scheme: "Bearer", Params: map[string]string{}}},
+		},
+		{
+			name:   "quoted and token params",
+			header: `Bearer realm="example", error=invalid_token, error_description="The access token expired"`,
+			want: []Challenge{{Scheme: "Bearer", Params: map[string]string{
+				"realm":             "example",
+				"error":             "invalid_token",
+				"error_description": "The access token expired",
+			}}},
+		},
+		{
+			name:   "multiple challenges",
+			header: `DPoP algs="ES256 PS256", error="use_dpop_nonce", Bearer realm="api"`,
+			want: []Challenge{
+				{Scheme: "DPoP", Params: map[string]string{"algs": "ES256 PS256", "error": "use_dpop_nonce"}},
+				{Scheme: "Bearer", Params: map[string]string{"realm": "api"}},
+			},
+		},
+		{
+			name:   "escaped quotes and commas inside values",
+			header: `UMA realm="a, \"b\"", as_uri="https://as.example.com", ticket="016f84e8"`,
+			want: []Challenge{{Scheme: "UMA", Params: map[string]string{
+				"realm":  `a, "b"`,
+				"as_uri": "https://as.example.com",
+				"ticket": "016f84e8",
+			}}},
+		},
+		{
+			name:   "param names are case-insensitive",
+			header: `Bearer Error="insufficient_user_authentication", ACR_Values="urn:mace:incommon:iap:silver"`,
+			want: []Challenge{{Scheme: "Bearer", Params: map[string]string{
+				"error":      "insufficient_user_authentication",
+				"acr_values": "urn:mace:incommon:iap:silver",
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := ParseWWWAuthenticate(tt.header)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWWWAuthenticate(%q) = %+v, want %+v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindChallenge(t *testing.T) {
+	t.Parallel()
+	header := `Bearer realm="api", dpop error="use_dpop_nonce"`
+
+	challenge, ok := FindChallenge(header, "DPoP")
+	if !ok {
+		t.Fatal("FindChallenge(DPoP) ok = false, want true")
+	}
+	if got := challenge.Param("error"); got != "use_dpop_nonce" {
+		t.Errorf("Param(error) = %q, want use_dpop_nonce", got)
+	}
+
+	if _, ok := FindChallenge(header, "UMA"); ok {
+		t.Error("FindChallenge(UMA) ok = true, want false")
+	}
+}
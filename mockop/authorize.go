@@ -0,0 +1,312 @@
+package mockop
+
+import (
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// parRequest is a pushed authorization request (RFC 9126).
+type parRequest struct {
+	params url.Values
+	expiry time.Time
+}
+
+type deviceStatus int
+
+const (
+	devicePending deviceStatus = iota
+	deviceApproved
+	deviceDenied
+)
+
+// deviceAuthorization is a device authorization request (RFC 8628) awaiting,
+// or holding, the user's decision.
+type deviceAuthorization struct {
+	auth     *authorization
+	userCode string
+	status   deviceStatus
+}
+
+// handleAuthorize serves the authorization endpoint. Requests are approved
+// straight away, or once the user approves them on the sign-in page, which
+// posts the request parameters back here along with the decision.
+func (p *Provider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		p.renderMessage(w, http.StatusBadRequest, "Invalid request", "The authorization request is malformed.")
+		return
+	}
+	params, errMessage := p.authorizationParams(r.Form)
+	if errMessage != "" {
+		// Without a trustworthy redirect_uri the error cannot go back to the
+		// client, so it is shown to the user (RFC 6749 §4.1.2.1).
+		p.renderMessage(w, http.StatusBadRequest, "Invalid request", errMessage)
+		return
+	}
+
+	redirectURI, state := params.Get("redirect_uri"), params.Get("state")
+	if errCode := p.takeFault("authorize"); errCode != "" {
+		p.redirect(w, r, redirectURI, state, url.Values{"error": {errCode}, "error_description": {"injected fault"}})
+		return
+	}
+	if params.Get("response_type") != "code" {
+		p.redirect(w, r, redirectURI, state, url.Values{"error": {"unsupported_response_type"}})
+		return
+	}
+	if method := params.Get("code_challenge_method"); params.Get("code_challenge") != "" && method != "" && method != "S256" && method != "plain" {
+		p.redirect(w, r, redirectURI, state, url.Values{"error": {"invalid_request"}, "error_description": {"unsupported code_challenge_method"}})
+		return
+	}
+
+	decision := r.PostForm.Get("decision")
+	if p.cfg.LoginPage && decision == "" {
+		hidden := make(map[string]string, len(params))
+		for name := range params {
+			hidden[name] = params.Get(name)
+		}
+		p.renderPage(w, http.StatusOK, "login.html", map[string]any{
+			"ClientID": params.Get("client_id"),
+			"Scope":    params.Get("scope"),
+			"Action":   p.endpoint(AuthorizationPath),
+			"Params":   hidden,
+			"Subject":  p.cfg.Subject,
+		})
+		return
+	}
+	if decision == "deny" {
+		p.redirect(w, r, redirectURI, state, url.Values{"error": {"access_denied"}, "error_description": {"the user denied the request"}})
+		return
+	}
+
+	now := time.Now()
+	code := randomString(32)
+	p.mu.Lock()
+	p.codes[code] = &authorization{
+		clientID:            params.Get("client_id"),
+		subject:             p.subject(r.PostForm.Get("username")),
+		scope:               params.Get("scope"),
+		audience:            params.Get("resource"),
+		redirectURI:         redirectURI,
+		nonce:               params.Get("nonce"),
+		codeChallenge:       params.Get("code_challenge"),
+		codeChallengeMethod: params.Get("code_challenge_method"),
+		dpopJKT:             params.Get("dpop_jkt"),
+		authTime:            now,
+		expiry:              now.Add(codeLifetime),
+	}
+	p.mu.Unlock()
+	p.redirect(w, r, redirectURI, state, url.Values{"code": {code}})
+}
+
+// authorizationParams resolves the parameters of an authorization request,
+// replacing a request_uri with the pushed request it names, and checks the
+// client and redirect_uri. A non-empty message means the request is rejected.
+func (p *Provider) authorizationParams(form url.Values) (url.Values, string) {
+	params := form
+	if requestURI := form.Get("request_uri"); requestURI != "" {
+		p.mu.Lock()
+		pushed, ok := p.requestURIs[requestURI]
+		delete(p.requestURIs, requestURI)
+		p.mu.Unlock()
+		if !ok || time.Now().After(pushed.expiry) {
+			return nil, "The request_uri is unknown or has expired."
+		}
+		if pushed.params.Get("client_id") != form.Get("client_id") {
+			return nil, "The request_uri was pushed by another client."
+		}
+		params = pushed.params
+	}
+
+	clientID := params.Get("client_id")
+	if clientID == "" || (p.cfg.ClientID != "" && clientID != p.cfg.ClientID) {
+		return nil, "The client_id is missing or unknown."
+	}
+	redirectURI, err := url.Parse(params.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() || redirectURI.Fragment != "" {
+		return nil, "The redirect_uri is missing or not an absolute URL."
+	}
+	return params, ""
+}
+
+// redirect sends the user agent back to the client with the authorization
+// response, including iss as RFC 9207 recommends.
+func (p *Provider) redirect(w http.ResponseWriter, r *http.Request, redirectURI, state string, values url.Values) {
+	u, _ := url.Parse(redirectURI) // checked by authorizationParams
+	query := u.Query()
+	for name, value := range values {
+		query[name] = value
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	query.Set("iss", p.cfg.Issuer)
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// subject returns the user the sign-in page named, or the configured one.
+func (p *Provider) subject(username string) string {
+	if username = strings.TrimSpace(username); username != "" {
+		return username
+	}
+	return p.cfg.Subject
+}
+
+func (p *Provider) handlePAR(w http.ResponseWriter, r *http.Request) {
+	if errCode := p.takeFault("par"); errCode != "" {
+		p.writeError(w, faultError(errCode))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		p.writeError(w, invalidRequest("malformed form body"))
+		return
+	}
+	c, oauthErr := p.authenticateClient(r)
+	if oauthErr != nil {
+		p.writeError(w, oauthErr)
+		return
+	}
+	if r.PostForm.Has("request_uri") {
+		p.writeError(w, invalidRequest("request_uri cannot be pushed"))
+		return
+	}
+
+	params := make(url.Values, len(r.PostForm))
+	for name, value := range r.PostForm {
+		if name != "client_secret" {
+			params[name] = value
+		}
+	}
+	params.Set("client_id", c.id)
+	// A proof sent with the pushed request binds the code like dpop_jkt
+	// (RFC 9449 §10.1).
+	if r.Header.Get("DPoP") != "" {
+		jkt, oauthErr := p.verifyDPoPProof(r, p.endpoint(PARPath), "")
+		if oauthErr != nil {
+			p.writeError(w, oauthErr)
+			return
+		}
+		if params.Has("dpop_jkt") && params.Get("dpop_jkt") != jkt {
+			p.writeError(w, &oauthError{status: http.StatusBadRequest, code: "invalid_dpop_proof", description: "dpop_jkt does not match the DPoP proof"})
+			return
+		}
+		params.Set("dpop_jkt", jkt)
+	}
+
+	requestURI := requestURIPrefix + randomString(32)
+	p.mu.Lock()
+	p.requestURIs[requestURI] = &parRequest{params: params, expiry: time.Now().Add(codeLifetime)}
+	p.mu.Unlock()
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"request_uri": requestURI,
+		"expires_in":  int(codeLifetime.Seconds()),
+	})
+}
+
+func (p *Provider) handleDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if errCode := p.takeFault("device_authorization"); errCode != "" {
+		p.writeError(w, faultError(errCode))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		p.writeError(w, invalidRequest("malformed form body"))
+		return
+	}
+	c, oauthErr := p.authenticateClient(r)
+	if oauthErr != nil {
+		p.writeError(w, oauthErr)
+		return
+	}
+
+	now := time.Now()
+	device := &deviceAuthorization{
+		auth: &authorization{
+			clientID:            c.id,
+			scope:               r.PostForm.Get("scope"),
+			audience:            r.PostForm.Get("resource"),
+			codeChallenge:       r.PostForm.Get("code_challenge"),
+			codeChallengeMethod: r.PostForm.Get("code_challenge_method"),
+			expiry:              now.Add(codeLifetime),
+		},
+		userCode: userCode(),
+	}
+	if !p.cfg.LoginPage {
+		device.approve(p.cfg.Subject, now)
+	}
+	deviceCode := randomString(32)
+	p.mu.Lock()
+	p.deviceCodes[deviceCode] = device
+	p.mu.Unlock()
+
+	verificationURI := p.endpoint(DeviceVerificationPath)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"device_code":               deviceCode,
+		"user_code":                 device.userCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": verificationURI + "?" + url.Values{"user_code": {device.userCode}}.Encode(),
+		"expires_in":                int(codeLifetime.Seconds()),
+		"interval":                  1,
+	})
+}
+
+func (d *deviceAuthorization) approve(subject string, now time.Time) {
+	d.status = deviceApproved
+	d.auth.subject = subject
+	d.auth.authTime = now
+}
+
+// handleDeviceVerification serves the page where the user enters the user
+// code and approves or denies the device.
+func (p *Provider) handleDeviceVerification(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		p.renderMessage(w, http.StatusBadRequest, "Invalid request", "The form is malformed.")
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(r.Form.Get("user_code")))
+	decision := r.PostForm.Get("decision")
+	if r.Method != http.MethodPost || decision == "" {
+		p.renderPage(w, http.StatusOK, "device.html", map[string]string{
+			"Action":   p.endpoint(DeviceVerificationPath),
+			"UserCode": code,
+			"Subject":  p.cfg.Subject,
+		})
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, device := range p.deviceCodes {
+		if device.userCode != code || device.status != devicePending {
+			continue
+		}
+		if decision == "deny" {
+			device.status = deviceDenied
+			p.renderMessage(w, http.StatusOK, "Device denied", "The device was denied access.")
+			return
+		}
+		device.approve(p.subject(r.PostForm.Get("username")), time.Now())
+		p.renderMessage(w, http.StatusOK, "Device approved", "You can return to your device.")
+		return
+	}
+	p.renderMessage(w, http.StatusBadRequest, "Unknown code", "The code is unknown or has already been used.")
+}
+
+// userCode returns a user code such as WDJB-MJHT, drawn from consonants only
+// so that it is easy to type and cannot spell words (RFC 8628 §6.1).
+func userCode() string {
+	const charset = "BCDFGHJKLMNPQRSTVWXZ"
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	code := make([]byte, 0, 9)
+	for i, c := range b {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		code = append(code, charset[int(c)%len(charset)])
+	}
+	return string(code)
+}
@@ -0,0 +1,249 @@
+package mockop
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizationCodeWithPKCE(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{Subject: "alice"})
+
+	params := authorizationParams()
+	params.Set("nonce", "nonce-1")
+	params.Set("code_challenge", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+	params.Set("code_challenge_method", "S256")
+	query := authorize(t, server, params)
+	if query.Get("state") != "state-1" || query.Get("iss") != server.URL {
+		t.Errorf("redirect query = %v, want state and iss", query)
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {query.Get("code")},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {"dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"},
+	}
+	resp, tokens := requestToken(t, server, form, "")
+	if resp.status != http.StatusOK {
+		t.Fatalf("token status = %d: %s", resp.status, resp.body)
+	}
+	if tokens["token_type"] != "Bearer" || tokens["refresh_token"] == nil || tokens["scope"] != "openid profile" {
+		t.Errorf("token response = %v", tokens)
+	}
+	idClaims := parseJWT(t, p, tokens["id_token"].(string))
+	if idClaims["sub"] != "alice" || idClaims["aud"] != testClientID || idClaims["nonce"] != "nonce-1" || idClaims["iss"] != server.URL {
+		t.Errorf("id token claims = %v", idClaims)
+	}
+
+	// Codes are single use
+	if resp, _ := requestToken(t, server, form, ""); resp.status != http.StatusBadRequest {
+		t.Errorf("replayed code status = %d, want %d", resp.status, http.StatusBadRequest)
+	}
+}
+
+func TestAuthorizationCodeRejections(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		name   string
+		mutate func(url.Values)
+	}{
+		{"wrong verifier", func(f url.Values) { f.Set("code_verifier", "wrong") }},
+		{"missing verifier", func(f url.Values) { f.Del("code_verifier") }},
+		{"wrong redirect uri", func(f url.Values) { f.Set("redirect_uri", "http://localhost/other") }},
+		{"unknown code", func(f url.Values) { f.Set("code", "unknown") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server, _ := newTestProvider(t, Config{})
+			params := authorizationParams()
+			params.Set("code_challenge", "plain-verifier")
+			params.Set("code_challenge_method", "plain")
+			form := url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {authorize(t, server, params).Get("code")},
+				"redirect_uri":  {testRedirectURI},
+				"code_verifier": {"plain-verifier"},
+			}
+			tt.mutate(form)
+			resp, body := requestToken(t, server, form, "")
+			if resp.status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+				t.Errorf("token response = %d %v, want invalid_grant", resp.status, body)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRejectsUnknownClientWithoutRedirecting(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+
+	for _, params := range []url.Values{
+		{"response_type": {"code"}, "client_id": {"other"}, "redirect_uri": {testRedirectURI}},
+		{"response_type": {"code"}, "client_id": {testClientID}, "redirect_uri": {"/relative"}},
+	} {
+		resp := get(t, server.URL+AuthorizationPath+"?"+params.Encode(), nil)
+		if resp.status != http.StatusBadRequest || resp.header.Get("Location") != "" {
+			t.Errorf("authorize %v = %d, want an error page", params, resp.status)
+		}
+	}
+}
+
+func TestAuthorizeUnsupportedResponseType(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+
+	params := authorizationParams()
+	params.Set("response_type", "token")
+	if query := authorize(t, server, params); query.Get("error") != "unsupported_response_type" {
+		t.Errorf("redirect query = %v, want unsupported_response_type", query)
+	}
+}
+
+func TestLoginPage(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		decision  string
+		wantError string
+	}{
+		{"approve", ""},
+		{"deny", "access_denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.decision, func(t *testing.T) {
+			t.Parallel()
+			server, p := newTestProvider(t, Config{LoginPage: true})
+
+			page := get(t, server.URL+AuthorizationPath+"?"+authorizationParams().Encode(), nil)
+			if page.status != http.StatusOK || !strings.Contains(string(page.body), `name="redirect_uri" value="`+testRedirectURI+`"`) {
+				t.Fatalf("login page = %d %s, want a form carrying the request", page.status, page.body)
+			}
+
+			form := authorizationParams()
+			form.Set("username", "bob")
+			form.Set("decision", tt.decision)
+			resp := postForm(t, server.URL+AuthorizationPath, form, false, "")
+			location, err := url.Parse(resp.header.Get("Location"))
+			if resp.status != http.StatusFound || err != nil {
+				t.Fatalf("sign-in response = %d %q", resp.status, resp.header.Get("Location"))
+			}
+			query := location.Query()
+			if query.Get("error") != tt.wantError {
+				t.Fatalf("redirect query = %v, want error %q", query, tt.wantError)
+			}
+			if tt.wantError != "" {
+				return
+			}
+
+			_, tokens := requestToken(t, server, url.Values{
+				"grant_type":   {"authorization_code"},
+				"code":         {query.Get("code")},
+				"redirect_uri": {testRedirectURI},
+			}, "")
+			if claims := parseJWT(t, p, tokens["access_token"].(string)); claims["sub"] != "bob" {
+				t.Errorf("access token sub = %v, want the user who signed in", claims["sub"])
+			}
+		})
+	}
+}
+
+func TestPushedAuthorizationRequest(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+
+	pushed := authorizationParams()
+	pushed.Del("client_id")
+	resp := postForm(t, server.URL+PARPath, pushed, true, "")
+	if resp.status != http.StatusCreated {
+		t.Fatalf("PAR status = %d: %s", resp.status, resp.body)
+	}
+	requestURI, _ := resp.json(t)["request_uri"].(string)
+	if !strings.HasPrefix(requestURI, requestURIPrefix) {
+		t.Fatalf("request_uri = %q", requestURI)
+	}
+
+	params := url.Values{"client_id": {testClientID}, "request_uri": {requestURI}}
+	query := authorize(t, server, params)
+	if query.Get("code") == "" || query.Get("state") != "state-1" {
+		t.Errorf("redirect query = %v, want a code and the pushed state", query)
+	}
+
+	// Request URIs are single use
+	replay := get(t, server.URL+AuthorizationPath+"?"+params.Encode(), nil)
+	if replay.status != http.StatusBadRequest {
+		t.Errorf("replayed request_uri status = %d, want %d", replay.status, http.StatusBadRequest)
+	}
+}
+
+func TestPushedAuthorizationRequestRequiresClientAuthentication(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+
+	form := authorizationParams()
+	form.Set("client_secret", "wrong")
+	resp := postForm(t, server.URL+PARPath, form, false, "")
+	if resp.status != http.StatusUnauthorized || resp.json(t)["error"] != "invalid_client" {
+		t.Errorf("PAR response = %d %s, want invalid_client", resp.status, resp.body)
+	}
+}
+
+func TestDeviceAuthorizationApprovedImmediately(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+
+	resp := postForm(t, server.URL+DeviceAuthorizationPath, url.Values{"scope": {"openid"}}, true, "")
+	device := resp.json(t)
+	if resp.status != http.StatusOK || device["verification_uri"] != server.URL+DeviceVerificationPath || device["interval"] != float64(1) {
+		t.Fatalf("device authorization = %d %v", resp.status, device)
+	}
+
+	_, tokens := requestToken(t, server, url.Values{"grant_type": {deviceCodeGrantType}, "device_code": {device["device_code"].(string)}}, "")
+	if claims := parseJWT(t, p, tokens["id_token"].(string)); claims["sub"] != "mock-user" {
+		t.Errorf("id token sub = %v, want mock-user", claims["sub"])
+	}
+}
+
+func TestDeviceVerificationPage(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{LoginPage: true})
+
+	device := postForm(t, server.URL+DeviceAuthorizationPath, url.Values{}, true, "").json(t)
+	poll := url.Values{"grant_type": {deviceCodeGrantType}, "device_code": {device["device_code"].(string)}}
+	if _, body := requestToken(t, server, poll, ""); body["error"] != "authorization_pending" {
+		t.Fatalf("poll before approval = %v, want authorization_pending", body)
+	}
+
+	page := get(t, device["verification_uri_complete"].(string), nil)
+	if page.status != http.StatusOK || !strings.Contains(string(page.body), device["user_code"].(string)) {
+		t.Fatalf("verification page = %d %s, want the user code filled in", page.status, page.body)
+	}
+	approval := url.Values{"user_code": {strings.ToLower(device["user_code"].(string))}, "decision": {"approve"}}
+	if resp := postForm(t, server.URL+DeviceVerificationPath, approval, false, ""); resp.status != http.StatusOK {
+		t.Fatalf("approval status = %d: %s", resp.status, resp.body)
+	}
+	if resp, body := requestToken(t, server, poll, ""); resp.status != http.StatusOK {
+		t.Fatalf("poll after approval = %d %v", resp.status, body)
+	}
+	if resp := postForm(t, server.URL+DeviceVerificationPath, approval, false, ""); resp.status != http.StatusBadRequest {
+		t.Errorf("second approval status = %d, want %d", resp.status, http.StatusBadRequest)
+	}
+}
+
+func TestDeviceDenied(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{LoginPage: true})
+
+	device := postForm(t, server.URL+DeviceAuthorizationPath, url.Values{}, true, "").json(t)
+	denial := url.Values{"user_code": {device["user_code"].(string)}, "decision": {"deny"}}
+	postForm(t, server.URL+DeviceVerificationPath, denial, false, "")
+
+	poll := url.Values{"grant_type": {deviceCodeGrantType}, "device_code": {device["device_code"].(string)}}
+	if _, body := requestToken(t, server, poll, ""); body["error"] != "access_denied" {
+		t.Errorf("poll after denial = %v, want access_denied", body)
+	}
+}
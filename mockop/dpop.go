@@ -0,0 +1,95 @@
+package mockop
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// dpopProofMaxAge bounds how far a proof's iat may be from now.
+const dpopProofMaxAge = 5 * time.Minute
+
+var dpopAlgorithms = []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "EdDSA"}
+
+// currentNonce returns the DPoP nonce proofs must carry, "" before a
+// use_dpop_nonce fault has issued one.
+func (p *Provider) currentNonce() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dpopNonce
+}
+
+// verifyDPoPProof checks the DPoP proof of a request made to htu (RFC 9449
+// §4.3), including its ath claim when accessToken is set, and returns the
+// thumbprint of the key it was signed with.
+func (p *Provider) verifyDPoPProof(r *http.Request, htu, accessToken string) (string, *oauthError) {
+	invalid := func(description string) *oauthError {
+		return &oauthError{status: http.StatusBadRequest, code: "invalid_dpop_proof", description: description}
+	}
+	proofs := r.Header.Values("DPoP")
+	if len(proofs) != 1 {
+		return "", invalid("exactly one DPoP proof is required")
+	}
+
+	var jkt string
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(proofs[0], claims, func(token *jwt.Token) (any, error) {
+		if token.Header["typ"] != "dpop+jwt" {
+			return nil, errors.New("typ must be dpop+jwt")
+		}
+		raw, err := json.Marshal(token.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+		var jwk crypto.JWK
+		if err := json.Unmarshal(raw, &jwk); err != nil || jwk.Kty == "" {
+			return nil, errors.New("jwk header is missing or malformed")
+		}
+		if jwk.D != "" {
+			return nil, errors.New("jwk header must not contain a private key")
+		}
+		publicKey, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		if jkt, err = crypto.JWKThumbprint(publicKey); err != nil {
+			return nil, err
+		}
+		return publicKey, nil
+	}, jwt.WithValidMethods(dpopAlgorithms))
+	if err != nil {
+		return "", invalid(err.Error())
+	}
+
+	iat, err := claims.GetIssuedAt()
+	switch {
+	case claims["jti"] == nil || claims["jti"] == "":
+		return "", invalid("jti is required")
+	case claims["htm"] != r.Method:
+		return "", invalid("htm does not match the request method")
+	case claims["htu"] != stripQuery(htu):
+		return "", invalid("htu does not match the request URL")
+	case err != nil || iat == nil || time.Since(iat.Time).Abs() > dpopProofMaxAge:
+		return "", invalid("iat is missing or too far from the current time")
+	case accessToken != "" && claims["ath"] != crypto.AccessTokenHash(accessToken):
+		return "", invalid("ath does not match the access token")
+	}
+	if nonce := p.currentNonce(); nonce != "" && claims["nonce"] != nonce {
+		return "", &oauthError{status: http.StatusBadRequest, code: "use_dpop_nonce", description: "DPoP proof must carry the current nonce"}
+	}
+	return jkt, nil
+}
+
+func stripQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String()
+}
@@ -0,0 +1,115 @@
+package mockop
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestDPoPBoundAccessToken(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	key, jkt := newDPoPKey(t)
+
+	tokenURL := server.URL + TokenPath
+	resp, tokens := requestToken(t, server, url.Values{"grant_type": {"client_credentials"}}, dpopProof(t, key, http.MethodPost, tokenURL, "", ""))
+	if resp.status != http.StatusOK || tokens["token_type"] != "DPoP" {
+		t.Fatalf("token response = %d %v, want a DPoP token", resp.status, tokens)
+	}
+	cnf, _ := parseJWT(t, p, tokens["access_token"].(string))["cnf"].(map[string]any)
+	if cnf["jkt"] != jkt {
+		t.Errorf("cnf = %v, want jkt %s", cnf, jkt)
+	}
+}
+
+func TestDPoPProofRejections(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+	key, _ := newDPoPKey(t)
+	tokenURL := server.URL + TokenPath
+
+	for name, proof := range map[string]string{
+		"wrong method": dpopProof(t, key, http.MethodGet, tokenURL, "", ""),
+		"wrong url":    dpopProof(t, key, http.MethodPost, server.URL+"/other", "", ""),
+		"not a jwt":    "not-a-proof",
+	} {
+		resp, body := requestToken(t, server, url.Values{"grant_type": {"client_credentials"}}, proof)
+		if resp.status != http.StatusBadRequest || body["error"] != "invalid_dpop_proof" {
+			t.Errorf("%s: token response = %d %v, want invalid_dpop_proof", name, resp.status, body)
+		}
+	}
+}
+
+func TestDPoPNonceFault(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	p.Inject(Fault{Endpoint: "token", Error: "use_dpop_nonce", Count: 1})
+	key, _ := newDPoPKey(t)
+	tokenURL := server.URL + TokenPath
+	form := url.Values{"grant_type": {"client_credentials"}}
+
+	resp, body := requestToken(t, server, form, dpopProof(t, key, http.MethodPost, tokenURL, "", ""))
+	nonce := resp.header.Get("DPoP-Nonce")
+	if resp.status != http.StatusBadRequest || body["error"] != "use_dpop_nonce" || nonce == "" {
+		t.Fatalf("first response = %d %v, want use_dpop_nonce with a nonce", resp.status, body)
+	}
+
+	// From now on every proof must carry the nonce
+	if _, body := requestToken(t, server, form, dpopProof(t, key, http.MethodPost, tokenURL, "", "")); body["error"] != "use_dpop_nonce" {
+		t.Errorf("proof without nonce = %v, want use_dpop_nonce", body)
+	}
+	if resp, body := requestToken(t, server, form, dpopProof(t, key, http.MethodPost, tokenURL, "", nonce)); resp.status != http.StatusOK {
+		t.Errorf("proof with nonce = %d %v, want a token", resp.status, body)
+	}
+}
+
+func TestAuthorizationCodeBoundByDPoPJKT(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+	key, jkt := newDPoPKey(t)
+	otherKey, _ := newDPoPKey(t)
+	tokenURL := server.URL + TokenPath
+
+	params := authorizationParams()
+	params.Set("dpop_jkt", jkt)
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {authorize(t, server, params).Get("code")},
+		"redirect_uri": {testRedirectURI},
+	}
+	if resp, body := requestToken(t, server, form, dpopProof(t, otherKey, http.MethodPost, tokenURL, "", "")); body["error"] != "invalid_grant" {
+		t.Errorf("redeemed with another key = %d %v, want invalid_grant", resp.status, body)
+	}
+
+	form.Set("code", authorize(t, server, params).Get("code"))
+	if resp, body := requestToken(t, server, form, dpopProof(t, key, http.MethodPost, tokenURL, "", "")); resp.status != http.StatusOK {
+		t.Errorf("redeemed with the bound key = %d %v, want a token", resp.status, body)
+	}
+}
+
+func TestPublicClientRefreshTokenBoundToDPoPKey(t *testing.T) {
+	t.Parallel()
+	server, _, err := NewTestServer(Config{})
+	if err != nil {
+		t.Fatalf("NewTestServer() error = %v", err)
+	}
+	t.Cleanup(server.Close)
+	key, _ := newDPoPKey(t)
+	otherKey, _ := newDPoPKey(t)
+	tokenURL := server.URL + TokenPath
+
+	tokens := postForm(t, tokenURL, url.Values{
+		"grant_type":   {"authorization_code"},
+		"client_id":    {testClientID},
+		"code":         {authorize(t, server, authorizationParams()).Get("code")},
+		"redirect_uri": {testRedirectURI},
+	}, false, dpopProof(t, key, http.MethodPost, tokenURL, "", "")).json(t)
+
+	form := url.Values{"grant_type": {"refresh_token"}, "client_id": {testClientID}, "refresh_token": {tokens["refresh_token"].(string)}}
+	if resp := postForm(t, tokenURL, form, false, dpopProof(t, otherKey, http.MethodPost, tokenURL, "", "")); resp.status != http.StatusBadRequest {
+		t.Errorf("refresh with another key = %d %s, want rejected", resp.status, resp.body)
+	}
+	if resp := postForm(t, tokenURL, form, false, dpopProof(t, key, http.MethodPost, tokenURL, "", "")); resp.status != http.StatusOK {
+		t.Errorf("refresh with the bound key = %d %s, want a token", resp.status, resp.body)
+	}
+}
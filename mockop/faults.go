@@ -0,0 +1,97 @@
+package mockop
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FaultEndpoints are the endpoint names faults can be injected into.
+var FaultEndpoints = []string{"authorize", "par", "device_authorization", "token", "introspect", "userinfo", "revoke"}
+
+// Fault makes an endpoint answer with an OAuth error instead of handling the
+// request, eg. slow_down or authorization_pending from the token endpoint, or
+// use_dpop_nonce from the token or userinfo endpoint.
+type Fault struct {
+	Endpoint string
+	Error    string
+	// Count is how many requests fail before the endpoint recovers; zero
+	// fails every request.
+	Count int
+}
+
+// ParseFault parses a fault written as endpoint=error[:count], eg.
+// token=slow_down:2.
+func ParseFault(s string) (Fault, error) {
+	endpoint, spec, ok := strings.Cut(s, "=")
+	if !ok || spec == "" {
+		return Fault{}, fmt.Errorf("invalid fault %q, expected endpoint=error[:count]", s)
+	}
+	if !slices.Contains(FaultEndpoints, endpoint) {
+		return Fault{}, fmt.Errorf("invalid fault %q, endpoint must be one of %s", s, strings.Join(FaultEndpoints, ", "))
+	}
+	fault := Fault{Endpoint: endpoint, Error: spec}
+	if errCode, count, ok := strings.Cut(spec, ":"); ok {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			return Fault{}, fmt.Errorf("invalid fault %q, count must be a positive number", s)
+		}
+		fault.Error, fault.Count = errCode, n
+	}
+	if fault.Error == "" {
+		return Fault{}, fmt.Errorf("invalid fault %q, error is required", s)
+	}
+	return fault, nil
+}
+
+func (f Fault) String() string {
+	if f.Count == 0 {
+		return f.Endpoint + "=" + f.Error
+	}
+	return fmt.Sprintf("%s=%s:%d", f.Endpoint, f.Error, f.Count)
+}
+
+// Inject queues a fault behind any already injected into the same endpoint.
+func (p *Provider) Inject(f Fault) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.faults[f.Endpoint] = append(p.faults[f.Endpoint], &f)
+}
+
+// takeFault consumes one failure from the endpoint's next fault, returning
+// its error code, or "" when the endpoint should answer normally.
+func (p *Provider) takeFault(endpoint string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	queue := p.faults[endpoint]
+	if len(queue) == 0 {
+		return ""
+	}
+	fault := queue[0]
+	if fault.Count > 0 {
+		fault.Count--
+		if fault.Count == 0 {
+			p.faults[endpoint] = queue[1:]
+		}
+	}
+	if fault.Error == "use_dpop_nonce" {
+		p.dpopNonce = randomString(16)
+	}
+	return fault.Error
+}
+
+// faultError is the error response an injected fault produces.
+func faultError(errCode string) *oauthError {
+	status := http.StatusBadRequest
+	switch errCode {
+	case "invalid_client":
+		status = http.StatusUnauthorized
+	case "server_error":
+		status = http.StatusInternalServerError
+	case "temporarily_unavailable":
+		status = http.StatusServiceUnavailable
+	}
+	return &oauthError{status: status, code: errCode, description: "injected fault"}
+}
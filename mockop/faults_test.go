@@ -0,0 +1,92 @@
+package mockop
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseFault(t *testing.T) {
+	t.Parallel()
+	var tests = []struct {
+		in      string
+		want    Fault
+		wantErr bool
+	}{
+		{in: "token=slow_down", want: Fault{Endpoint: "token", Error: "slow_down"}},
+		{in: "token=invalid_grant:2", want: Fault{Endpoint: "token", Error: "invalid_grant", Count: 2}},
+		{in: "userinfo=use_dpop_nonce:1", want: Fault{Endpoint: "userinfo", Error: "use_dpop_nonce", Count: 1}},
+		{in: "token", wantErr: true},
+		{in: "token=", wantErr: true},
+		{in: "jwks=server_error", wantErr: true},
+		{in: "token=slow_down:0", wantErr: true},
+		{in: "token=slow_down:x", wantErr: true},
+		{in: "token=:1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseFault(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFault() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFault() = %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != tt.in {
+				t.Errorf("String() = %q, want %q", got.String(), tt.in)
+			}
+		})
+	}
+}
+
+func TestInjectedFaultsAreConsumedInOrder(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	p.Inject(Fault{Endpoint: "token", Error: "slow_down", Count: 2})
+	p.Inject(Fault{Endpoint: "token", Error: "temporarily_unavailable", Count: 1})
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	for i, want := range []struct {
+		status int
+		error  string
+	}{
+		{http.StatusBadRequest, "slow_down"},
+		{http.StatusBadRequest, "slow_down"},
+		{http.StatusServiceUnavailable, "temporarily_unavailable"},
+		{http.StatusOK, ""},
+	} {
+		resp, body := requestToken(t, server, form, "")
+		if resp.status != want.status || body["error"] != nil && body["error"] != want.error {
+			t.Errorf("request %d = %d %v, want %d %q", i+1, resp.status, body, want.status, want.error)
+		}
+	}
+}
+
+func TestPermanentFault(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	p.Inject(Fault{Endpoint: "introspect", Error: "server_error"})
+
+	for range 3 {
+		resp := postForm(t, server.URL+IntrospectionPath, url.Values{"token": {"x"}}, true, "")
+		if resp.status != http.StatusInternalServerError {
+			t.Errorf("status = %d, want %d", resp.status, http.StatusInternalServerError)
+		}
+	}
+}
+
+func TestAuthorizeFaultRedirects(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	p.Inject(Fault{Endpoint: "authorize", Error: "access_denied", Count: 1})
+
+	query := authorize(t, server, authorizationParams())
+	if query.Get("error") != "access_denied" || query.Get("state") != "state-1" || query.Get("iss") != server.URL {
+		t.Errorf("redirect query = %v, want access_denied with state and iss", query)
+	}
+	if query := authorize(t, server, authorizationParams()); query.Get("code") == "" {
+		t.Errorf("redirect query = %v, want a code once the fault is spent", query)
+	}
+}
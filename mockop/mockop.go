@@ -0,0 +1,262 @@
+// Package mockop is a mock OpenID Provider for trying oidc-cli, and testing
+// OAuth clients, without a real identity provider or a network. It serves
+// discovery, JWKS, authorization, PAR, device authorization, token,
+// introspection, userinfo and revocation endpoints, signs tokens with a key
+// generated at startup, and keeps all state in memory. Faults such as
+// slow_down or use_dpop_nonce can be injected per endpoint to exercise a
+// client's error handling.
+package mockop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+//go:embed html/*
+var content embed.FS
+
+// Endpoint paths, relative to the issuer.
+const (
+	DiscoveryPath           = "/.well-known/openid-configuration"
+	JWKSPath                = "/jwks"
+	AuthorizationPath       = "/authorize"
+	PARPath                 = "/par"
+	DeviceAuthorizationPath = "/device_authorization"
+	DeviceVerificationPath  = "/device"
+	TokenPath               = "/token"
+	IntrospectionPath       = "/introspect"
+	UserinfoPath            = "/userinfo"
+	RevocationPath          = "/revoke"
+)
+
+// Config configures a Provider.
+type Config struct {
+	// Issuer is the issuer URL every endpoint URL is built from.
+	Issuer string
+	// ClientID and ClientSecret, when set, are the only client credentials
+	// accepted; left empty, any client is accepted.
+	ClientID     string
+	ClientSecret string
+	// Subject is the user authorizations are granted for, "mock-user" when
+	// empty. The sign-in page lets it be changed per authorization.
+	Subject string
+	// LoginPage shows a sign-in page at the authorization and device
+	// verification endpoints instead of approving every request straight away.
+	LoginPage bool
+	// TokenLifetime is the lifetime of access and ID tokens, one hour when zero.
+	TokenLifetime time.Duration
+}
+
+// Provider is a mock OpenID Provider. It is safe for concurrent use.
+type Provider struct {
+	cfg       Config
+	key       *ecdsa.PrivateKey
+	jwk       crypto.JWK
+	templates *template.Template
+	grants    map[string]grantHandler
+
+	mu sync.Mutex
+	// faults are the injected failures per endpoint, consumed in order.
+	faults map[string][]*Fault
+	// dpopNonce is the nonce DPoP proofs must carry, once one has been issued.
+	dpopNonce     string
+	codes         map[string]*authorization
+	requestURIs   map[string]*parRequest
+	deviceCodes   map[string]*deviceAuthorization
+	refreshTokens map[string]*authorization
+	accessTokens  map[string]*issuedToken
+}
+
+// authorization is what a grant authorizes: the client, the user (empty for
+// client_credentials) and the scope, plus what the authorization request bound
+// the code to.
+type authorization struct {
+	clientID            string
+	subject             string
+	scope               string
+	audience            string
+	redirectURI         string
+	nonce               string
+	codeChallenge       string
+	codeChallengeMethod string
+	dpopJKT             string
+	authTime            time.Time
+	expiry              time.Time
+	// refreshToken is the refresh token this authorization is held under, set
+	// once one has been issued.
+	refreshToken string
+}
+
+// issuedToken is an access token the provider issued.
+type issuedToken struct {
+	auth   *authorization
+	jkt    string
+	expiry time.Time
+}
+
+// New returns a Provider with a freshly generated P-256 signing key.
+func New(cfg Config) (*Provider, error) {
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if cfg.Subject == "" {
+		cfg.Subject = "mock-user"
+	}
+	if cfg.TokenLifetime <= 0 {
+		cfg.TokenLifetime = time.Hour
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	jwk, err := crypto.NewJWK(key)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Use = "sig"
+
+	templates, err := template.ParseFS(content, "html/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	p := &Provider{
+		cfg:           cfg,
+		key:           key,
+		jwk:           jwk,
+		templates:     templates,
+		faults:        make(map[string][]*Fault),
+		codes:         make(map[string]*authorization),
+		requestURIs:   make(map[string]*parRequest),
+		deviceCodes:   make(map[string]*deviceAuthorization),
+		refreshTokens: make(map[string]*authorization),
+		accessTokens:  make(map[string]*issuedToken),
+	}
+	p.grants = map[string]grantHandler{
+		"authorization_code": p.authorizationCodeGrant,
+		"refresh_token":      p.refreshTokenGrant,
+		"client_credentials": p.clientCredentialsGrant,
+		deviceCodeGrantType:  p.deviceCodeGrant,
+		tokenExchangeGrant:   p.tokenExchangeGrant,
+	}
+	return p, nil
+}
+
+// NewTestServer starts a Provider on a loopback httptest.Server whose URL is
+// the issuer. The caller must Close the server.
+func NewTestServer(cfg Config) (*httptest.Server, *Provider, error) {
+	server := httptest.NewUnstartedServer(nil)
+	cfg.Issuer = "http://" + server.Listener.Addr().String()
+	p, err := New(cfg)
+	if err != nil {
+		server.Close()
+		return nil, nil, err
+	}
+	server.Config.Handler = p.Handler()
+	server.Start()
+	return server, p, nil
+}
+
+// Issuer returns the provider's issuer URL.
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// PublicKey returns the public key the provider signs tokens with.
+func (p *Provider) PublicKey() *ecdsa.PublicKey {
+	return &p.key.PublicKey
+}
+
+// Handler returns the HTTP handler serving every endpoint.
+func (p *Provider) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+DiscoveryPath, p.handleDiscovery)
+	mux.HandleFunc("GET "+JWKSPath, p.handleJWKS)
+	mux.HandleFunc(AuthorizationPath, p.handleAuthorize)
+	mux.HandleFunc("POST "+PARPath, p.handlePAR)
+	mux.HandleFunc("POST "+DeviceAuthorizationPath, p.handleDeviceAuthorization)
+	mux.HandleFunc(DeviceVerificationPath, p.handleDeviceVerification)
+	mux.HandleFunc("POST "+TokenPath, p.handleToken)
+	mux.HandleFunc("POST "+IntrospectionPath, p.handleIntrospection)
+	mux.HandleFunc(UserinfoPath, p.handleUserinfo)
+	mux.HandleFunc("POST "+RevocationPath, p.handleRevocation)
+	return mux
+}
+
+func (p *Provider) endpoint(path string) string {
+	return p.cfg.Issuer + path
+}
+
+func (p *Provider) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                         p.cfg.Issuer,
+		"authorization_endpoint":                         p.endpoint(AuthorizationPath),
+		"pushed_authorization_request_endpoint":          p.endpoint(PARPath),
+		"device_authorization_endpoint":                  p.endpoint(DeviceAuthorizationPath),
+		"token_endpoint":                                 p.endpoint(TokenPath),
+		"introspection_endpoint":                         p.endpoint(IntrospectionPath),
+		"userinfo_endpoint":                              p.endpoint(UserinfoPath),
+		"revocation_endpoint":                            p.endpoint(RevocationPath),
+		"jwks_uri":                                       p.endpoint(JWKSPath),
+		"response_types_supported":                       []string{"code"},
+		"grant_types_supported":                          slices.Sorted(maps.Keys(p.grants)),
+		"subject_types_supported":                        []string{"public"},
+		"scopes_supported":                               []string{"openid", "profile", "email", "offline_access"},
+		"token_endpoint_auth_methods_supported":          []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":               []string{"S256", "plain"},
+		"id_token_signing_alg_values_supported":          []string{p.jwk.Alg},
+		"dpop_signing_alg_values_supported":              []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "EdDSA"},
+		"authorization_response_iss_parameter_supported": true,
+	})
+}
+
+func (p *Provider) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, crypto.JWKS{Keys: []crypto.JWK{p.jwk.Public()}})
+}
+
+// renderPage writes one of the embedded HTML pages.
+func (p *Provider) renderPage(w http.ResponseWriter, status int, name string, data any) {
+	var buf strings.Builder
+	if err := p.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		http.Error(w, "failed to render page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(buf.String()))
+}
+
+func (p *Provider) renderMessage(w http.ResponseWriter, status int, title, message string) {
+	p.renderPage(w, status, "message.html", map[string]string{"Title": title, "Message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// randomString returns n random bytes, base64url-encoded.
+func randomString(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,250 @@
+package mockop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+const (
+	testClientID     = "client-id"
+	testClientSecret = "client-secret"
+	testRedirectURI  = "http://localhost:9555/callback"
+)
+
+// newTestProvider starts a provider that only accepts the test client.
+func newTestProvider(t *testing.T, cfg Config) (*httptest.Server, *Provider) {
+	t.Helper()
+	if cfg.ClientID == "" {
+		cfg.ClientID, cfg.ClientSecret = testClientID, testClientSecret
+	}
+	server, p, err := NewTestServer(cfg)
+	if err != nil {
+		t.Fatalf("NewTestServer() error = %v", err)
+	}
+	t.Cleanup(server.Close)
+	return server, p
+}
+
+// testResponse is a response with its body read.
+type testResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// json decodes the body into a map.
+func (r testResponse) json(t *testing.T) map[string]any {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal(r.body, &v); err != nil {
+		t.Fatalf("decoding %s: %v", r.body, err)
+	}
+	return v
+}
+
+// do sends a request without following redirects.
+func do(t *testing.T, req *http.Request) testResponse {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	return testResponse{status: resp.StatusCode, header: resp.Header, body: body}
+}
+
+// postForm posts form to rawURL. A non-empty dpopProof is sent as the DPoP
+// header.
+func postForm(t *testing.T, rawURL string, form url.Values, basicAuth bool, dpopProof string) testResponse {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, rawURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if basicAuth {
+		req.SetBasicAuth(testClientID, testClientSecret)
+	}
+	if dpopProof != "" {
+		req.Header.Set("DPoP", dpopProof)
+	}
+	return do(t, req)
+}
+
+func get(t *testing.T, rawURL string, header http.Header) testResponse {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return do(t, req)
+}
+
+// requestToken posts a token request authenticated with client_secret_basic
+// and returns the decoded response.
+func requestToken(t *testing.T, server *httptest.Server, form url.Values, dpopProof string) (testResponse, map[string]any) {
+	t.Helper()
+	resp := postForm(t, server.URL+TokenPath, form, true, dpopProof)
+	return resp, resp.json(t)
+}
+
+// authorize sends an authorization request and returns the redirect's query.
+func authorize(t *testing.T, server *httptest.Server, params url.Values) url.Values {
+	t.Helper()
+	resp := get(t, server.URL+AuthorizationPath+"?"+params.Encode(), nil)
+	if resp.status != http.StatusFound {
+		t.Fatalf("authorize status = %d, want %d: %s", resp.status, http.StatusFound, resp.body)
+	}
+	location, err := url.Parse(resp.header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parsing Location: %v", err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != testRedirectURI {
+		t.Fatalf("redirected to %s, want %s", got, testRedirectURI)
+	}
+	return location.Query()
+}
+
+func authorizationParams() url.Values {
+	return url.Values{
+		"response_type": {"code"},
+		"client_id":     {testClientID},
+		"redirect_uri":  {testRedirectURI},
+		"scope":         {"openid profile"},
+		"state":         {"state-1"},
+	}
+}
+
+// parseJWT verifies a token the provider signed and returns its claims.
+func parseJWT(t *testing.T, p *Provider, token string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) { return p.PublicKey(), nil }); err != nil {
+		t.Fatalf("verifying %q: %v", token, err)
+	}
+	return claims
+}
+
+func TestNewRequiresIssuer(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(Config{}); err == nil {
+		t.Error("New() error = nil, want error for a missing issuer")
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+
+	resp := get(t, server.URL+DiscoveryPath, nil)
+	if resp.status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.status, http.StatusOK)
+	}
+	doc := resp.json(t)
+	if doc["issuer"] != server.URL || p.Issuer() != server.URL {
+		t.Errorf("issuer = %v, want %s", doc["issuer"], server.URL)
+	}
+	for member, path := range map[string]string{
+		"authorization_endpoint":                AuthorizationPath,
+		"pushed_authorization_request_endpoint": PARPath,
+		"device_authorization_endpoint":         DeviceAuthorizationPath,
+		"token_endpoint":                        TokenPath,
+		"introspection_endpoint":                IntrospectionPath,
+		"userinfo_endpoint":                     UserinfoPath,
+		"revocation_endpoint":                   RevocationPath,
+		"jwks_uri":                              JWKSPath,
+	} {
+		if doc[member] != server.URL+path {
+			t.Errorf("%s = %v, want %s", member, doc[member], server.URL+path)
+		}
+	}
+	var grants []string
+	for _, g := range doc["grant_types_supported"].([]any) {
+		grants = append(grants, g.(string))
+	}
+	want := []string{"authorization_code", "client_credentials", "refresh_token", deviceCodeGrantType, tokenExchangeGrant}
+	if !slices.Equal(grants, want) {
+		t.Errorf("grant_types_supported = %v, want %v", grants, want)
+	}
+}
+
+func TestJWKSVerifiesIssuedTokens(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+
+	var jwks crypto.JWKS
+	if err := json.Unmarshal(get(t, server.URL+JWKSPath, nil).body, &jwks); err != nil {
+		t.Fatalf("decoding JWKS: %v", err)
+	}
+	if len(jwks.Keys) != 1 || jwks.Keys[0].D != "" {
+		t.Fatalf("JWKS = %+v, want one public key", jwks)
+	}
+
+	_, tokens := requestToken(t, server, url.Values{"grant_type": {"client_credentials"}}, "")
+	accessToken, _ := tokens["access_token"].(string)
+	_, err := jwt.Parse(accessToken, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwks.Key(kid)
+		if !ok {
+			return nil, errors.New("unknown kid " + kid)
+		}
+		return key.PublicKey()
+	})
+	if err != nil {
+		t.Errorf("verifying access token with the JWKS: %v", err)
+	}
+}
+
+// newDPoPKey returns a key pair for signing DPoP proofs and its thumbprint.
+func newDPoPKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	jkt, err := crypto.JWKThumbprint(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("computing thumbprint: %v", err)
+	}
+	return key, jkt
+}
+
+// dpopProof returns a proof for method and rawURL, bound to accessToken and
+// carrying nonce when they are set.
+func dpopProof(t *testing.T, key *ecdsa.PrivateKey, method, rawURL, accessToken, nonce string) string {
+	t.Helper()
+	proof, err := crypto.NewDPoPProofBuilder().
+		PublicKey(&key.PublicKey).
+		PrivateKey(key).
+		Method(method).
+		URL(rawURL).
+		AccessToken(accessToken).
+		Nonce(nonce).
+		Build()
+	if err != nil {
+		t.Fatalf("building DPoP proof: %v", err)
+	}
+	return proof.String()
+}
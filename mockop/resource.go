@@ -0,0 +1,177 @@
+package mockop
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handleIntrospection reports whether a token the provider issued is active,
+// with its claims (RFC 7662).
+func (p *Provider) handleIntrospection(w http.ResponseWriter, r *http.Request) {
+	if errCode := p.takeFault("introspect"); errCode != "" {
+		p.writeError(w, faultError(errCode))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		p.writeError(w, invalidRequest("malformed form body"))
+		return
+	}
+	if _, oauthErr := p.authenticateClient(r); oauthErr != nil {
+		p.writeError(w, oauthErr)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if issued, ok := p.accessTokens[token]; ok && time.Now().Before(issued.expiry) {
+		resp := p.introspectionClaims(issued.auth)
+		resp["token_type"] = "Bearer"
+		resp["exp"] = issued.expiry.Unix()
+		if issued.jkt != "" {
+			resp["token_type"] = "DPoP"
+			resp["cnf"] = map[string]string{"jkt": issued.jkt}
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	if auth, ok := p.refreshTokens[token]; ok {
+		resp := p.introspectionClaims(auth)
+		resp["token_type"] = "refresh_token"
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"active": false})
+}
+
+func (p *Provider) introspectionClaims(auth *authorization) map[string]any {
+	claims := map[string]any{
+		"active":    true,
+		"iss":       p.cfg.Issuer,
+		"client_id": auth.clientID,
+		"sub":       auth.clientID,
+	}
+	if auth.subject != "" {
+		claims["sub"] = auth.subject
+	}
+	if auth.scope != "" {
+		claims["scope"] = auth.scope
+	}
+	if auth.audience != "" {
+		claims["aud"] = auth.audience
+	}
+	return claims
+}
+
+// handleUserinfo returns claims about the user an access token was issued
+// for. DPoP-bound tokens must be presented with the DPoP scheme and a proof
+// for the token (RFC 9449 §7).
+func (p *Provider) handleUserinfo(w http.ResponseWriter, r *http.Request) {
+	if errCode := p.takeFault("userinfo"); errCode != "" {
+		scheme := "Bearer"
+		if errCode == "use_dpop_nonce" {
+			scheme = "DPoP"
+		}
+		p.writeChallenge(w, scheme, faultError(errCode))
+		return
+	}
+
+	scheme, accessToken, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "DPoP") {
+		scheme = "Bearer"
+	}
+	invalidToken := func(description string) *oauthError {
+		return &oauthError{status: http.StatusUnauthorized, code: "invalid_token", description: description}
+	}
+
+	p.mu.Lock()
+	issued, ok := p.accessTokens[accessToken]
+	p.mu.Unlock()
+	switch {
+	case !ok || time.Now().After(issued.expiry):
+		p.writeChallenge(w, scheme, invalidToken("the access token is unknown or has expired"))
+		return
+	case (issued.jkt != "") != (scheme == "DPoP"):
+		p.writeChallenge(w, scheme, invalidToken("the access token was presented with the wrong scheme"))
+		return
+	case issued.auth.subject == "":
+		p.writeChallenge(w, scheme, &oauthError{status: http.StatusForbidden, code: "insufficient_scope", description: "the access token was not issued for a user"})
+		return
+	}
+	if issued.jkt != "" {
+		jkt, oauthErr := p.verifyDPoPProof(r, p.endpoint(UserinfoPath), accessToken)
+		if oauthErr == nil && jkt != issued.jkt {
+			oauthErr = invalidToken("the DPoP proof was signed with another key")
+		}
+		if oauthErr != nil {
+			p.writeChallenge(w, scheme, oauthErr)
+			return
+		}
+	}
+
+	auth := issued.auth
+	claims := map[string]any{"sub": auth.subject}
+	if hasScope(auth.scope, "profile") {
+		claims["name"] = auth.subject
+		claims["preferred_username"] = auth.subject
+	}
+	if hasScope(auth.scope, "email") {
+		claims["email"] = auth.subject + "@example.com"
+		claims["email_verified"] = true
+	}
+	writeJSON(w, http.StatusOK, claims)
+}
+
+// writeChallenge rejects a resource request with a WWW-Authenticate challenge
+// carrying the error (RFC 6750 §3). Proof errors, which the token endpoint
+// answers with 400, are answered with 401 here (RFC 9449 §7.1).
+func (p *Provider) writeChallenge(w http.ResponseWriter, scheme string, e *oauthError) {
+	challenge := scheme + ` error="` + e.code + `", error_description="` + e.description + `"`
+	if scheme == "DPoP" {
+		challenge += ` algs="` + strings.Join(dpopAlgorithms, " ") + `"`
+	}
+	if e.code == "use_dpop_nonce" {
+		w.Header().Set("DPoP-Nonce", p.currentNonce())
+	}
+	status := e.status
+	if status == http.StatusBadRequest {
+		status = http.StatusUnauthorized
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.WriteHeader(status)
+}
+
+// handleRevocation revokes an access or refresh token (RFC 7009). Revoking a
+// refresh token also revokes the access tokens issued with it.
+func (p *Provider) handleRevocation(w http.ResponseWriter, r *http.Request) {
+	if errCode := p.takeFault("revoke"); errCode != "" {
+		p.writeError(w, faultError(errCode))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		p.writeError(w, invalidRequest("malformed form body"))
+		return
+	}
+	c, oauthErr := p.authenticateClient(r)
+	if oauthErr != nil {
+		p.writeError(w, oauthErr)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if issued, ok := p.accessTokens[token]; ok && issued.auth.clientID == c.id {
+		delete(p.accessTokens, token)
+	}
+	if auth, ok := p.refreshTokens[token]; ok && auth.clientID == c.id {
+		delete(p.refreshTokens, token)
+		for accessToken, issued := range p.accessTokens {
+			if issued.auth == auth {
+				delete(p.accessTokens, accessToken)
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,133 @@
+package mockop
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIntrospection(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{Subject: "dave"})
+	tokens := issueUserTokens(t, server)
+
+	var tests = []struct {
+		name  string
+		token string
+		want  map[string]any
+	}{
+		{"access token", tokens["access_token"].(string), map[string]any{"active": true, "sub": "dave", "client_id": testClientID, "scope": "openid profile", "token_type": "Bearer"}},
+		{"refresh token", tokens["refresh_token"].(string), map[string]any{"active": true, "sub": "dave", "token_type": "refresh_token"}},
+		{"unknown token", "unknown", map[string]any{"active": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := postForm(t, server.URL+IntrospectionPath, url.Values{"token": {tt.token}}, true, "").json(t)
+			for claim, want := range tt.want {
+				if got[claim] != want {
+					t.Errorf("%s = %v, want %v", claim, got[claim], want)
+				}
+			}
+		})
+	}
+}
+
+func TestUserinfo(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{Subject: "erin"})
+	tokens := issueUserTokens(t, server)
+
+	header := http.Header{"Authorization": {"Bearer " + tokens["access_token"].(string)}}
+	resp := get(t, server.URL+UserinfoPath, header)
+	claims := resp.json(t)
+	if resp.status != http.StatusOK || claims["sub"] != "erin" || claims["preferred_username"] != "erin" || claims["email"] != nil {
+		t.Errorf("userinfo = %d %v, want profile claims for erin", resp.status, claims)
+	}
+}
+
+func TestUserinfoRejections(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+	userToken := issueUserTokens(t, server)["access_token"].(string)
+	_, clientTokens := requestToken(t, server, url.Values{"grant_type": {"client_credentials"}}, "")
+
+	var tests = []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantError     string
+	}{
+		{"no token", "", http.StatusUnauthorized, "invalid_token"},
+		{"unknown token", "Bearer unknown", http.StatusUnauthorized, "invalid_token"},
+		{"bearer token as DPoP", "DPoP " + userToken, http.StatusUnauthorized, "invalid_token"},
+		{"client token", "Bearer " + clientTokens["access_token"].(string), http.StatusForbidden, "insufficient_scope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := get(t, server.URL+UserinfoPath, http.Header{"Authorization": {tt.authorization}})
+			if resp.status != tt.wantStatus || !strings.Contains(resp.header.Get("WWW-Authenticate"), `error="`+tt.wantError+`"`) {
+				t.Errorf("userinfo = %d %q, want %d %s", resp.status, resp.header.Get("WWW-Authenticate"), tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestUserinfoWithDPoPBoundToken(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	key, _ := newDPoPKey(t)
+	tokenURL, userinfoURL := server.URL+TokenPath, server.URL+UserinfoPath
+
+	_, tokens := requestToken(t, server, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {authorize(t, server, authorizationParams()).Get("code")},
+		"redirect_uri": {testRedirectURI},
+	}, dpopProof(t, key, http.MethodPost, tokenURL, "", ""))
+	accessToken := tokens["access_token"].(string)
+
+	if resp := get(t, userinfoURL, http.Header{"Authorization": {"Bearer " + accessToken}}); resp.status != http.StatusUnauthorized {
+		t.Errorf("bound token as Bearer = %d, want %d", resp.status, http.StatusUnauthorized)
+	}
+	noAth := http.Header{"Authorization": {"DPoP " + accessToken}, "Dpop": {dpopProof(t, key, http.MethodGet, userinfoURL, "", "")}}
+	if resp := get(t, userinfoURL, noAth); !strings.Contains(resp.header.Get("WWW-Authenticate"), "invalid_dpop_proof") {
+		t.Errorf("proof without ath = %d %q, want invalid_dpop_proof", resp.status, resp.header.Get("WWW-Authenticate"))
+	}
+
+	p.Inject(Fault{Endpoint: "userinfo", Error: "use_dpop_nonce", Count: 1})
+	header := http.Header{"Authorization": {"DPoP " + accessToken}, "Dpop": {dpopProof(t, key, http.MethodGet, userinfoURL, accessToken, "")}}
+	resp := get(t, userinfoURL, header)
+	nonce := resp.header.Get("DPoP-Nonce")
+	if resp.status != http.StatusUnauthorized || nonce == "" || !strings.HasPrefix(resp.header.Get("WWW-Authenticate"), `DPoP error="use_dpop_nonce"`) {
+		t.Fatalf("nonce challenge = %d %v", resp.status, resp.header)
+	}
+
+	header.Set("DPoP", dpopProof(t, key, http.MethodGet, userinfoURL, accessToken, nonce))
+	if resp := get(t, userinfoURL, header); resp.status != http.StatusOK {
+		t.Errorf("userinfo with proof = %d %q", resp.status, resp.header.Get("WWW-Authenticate"))
+	}
+}
+
+func TestRevocation(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+	tokens := issueUserTokens(t, server)
+
+	resp := postForm(t, server.URL+RevocationPath, url.Values{"token": {tokens["refresh_token"].(string)}}, true, "")
+	if resp.status != http.StatusOK {
+		t.Fatalf("revocation status = %d: %s", resp.status, resp.body)
+	}
+	for _, token := range []string{tokens["refresh_token"].(string), tokens["access_token"].(string)} {
+		if got := postForm(t, server.URL+IntrospectionPath, url.Values{"token": {token}}, true, "").json(t); got["active"] != false {
+			t.Errorf("introspection after revocation = %v, want inactive", got)
+		}
+	}
+	// Unknown tokens revoke successfully (RFC 7009 §2.2)
+	if resp := postForm(t, server.URL+RevocationPath, url.Values{"token": {"unknown"}}, true, ""); resp.status != http.StatusOK {
+		t.Errorf("revoking an unknown token = %d, want %d", resp.status, http.StatusOK)
+	}
+}
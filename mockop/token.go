@@ -0,0 +1,382 @@
+package mockop
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	tokenExchangeGrant  = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	accessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+	// codeLifetime bounds how long authorization codes, request URIs and
+	// device codes stay redeemable.
+	codeLifetime = 10 * time.Minute
+)
+
+// oauthError is an OAuth error response (RFC 6749 §5.2).
+type oauthError struct {
+	status      int
+	code        string
+	description string
+}
+
+func (e *oauthError) Error() string {
+	return e.code + ": " + e.description
+}
+
+func invalidRequest(description string) *oauthError {
+	return &oauthError{status: http.StatusBadRequest, code: "invalid_request", description: description}
+}
+
+func invalidGrant(description string) *oauthError {
+	return &oauthError{status: http.StatusBadRequest, code: "invalid_grant", description: description}
+}
+
+// writeError writes an OAuth error response, with the current DPoP nonce when
+// the error asks for one.
+func (p *Provider) writeError(w http.ResponseWriter, e *oauthError) {
+	if e.code == "use_dpop_nonce" {
+		w.Header().Set("DPoP-Nonce", p.currentNonce())
+	}
+	if e.status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="mockop"`)
+	}
+	writeJSON(w, e.status, map[string]string{"error": e.code, "error_description": e.description})
+}
+
+// client is an authenticated client. A public client presented no secret.
+type client struct {
+	id     string
+	public bool
+}
+
+// authenticateClient checks the client credentials of a request with a parsed
+// form, sent either with HTTP Basic authentication or in the body. When the
+// provider is configured with a client, only its credentials are accepted;
+// otherwise any client is.
+func (p *Provider) authenticateClient(r *http.Request) (client, *oauthError) {
+	id, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 §2.3.1 form-encodes the credentials before Basic encoding
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	unauthorized := &oauthError{status: http.StatusUnauthorized, code: "invalid_client", description: "client authentication failed"}
+	switch {
+	case id == "":
+		return client{}, unauthorized
+	case p.cfg.ClientID != "" && id != p.cfg.ClientID:
+		return client{}, unauthorized
+	case p.cfg.ClientSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(p.cfg.ClientSecret)) != 1:
+		return client{}, unauthorized
+	}
+	return client{id: id, public: secret == ""}, nil
+}
+
+// tokenRequest is a token request from an authenticated client.
+type tokenRequest struct {
+	form   url.Values
+	client client
+	// jkt is the thumbprint of the key the request's DPoP proof was signed
+	// with, or "" when it carried none.
+	jkt string
+}
+
+// grantHandler checks a token request for one grant type and returns what it
+// authorizes.
+type grantHandler func(req *tokenRequest) (*authorization, *oauthError)
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if errCode := p.takeFault("token"); errCode != "" {
+		p.writeError(w, faultError(errCode))
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		p.writeError(w, invalidRequest("malformed form body"))
+		return
+	}
+	c, oauthErr := p.authenticateClient(r)
+	if oauthErr != nil {
+		p.writeError(w, oauthErr)
+		return
+	}
+	req := &tokenRequest{form: r.PostForm, client: c}
+	if r.Header.Get("DPoP") != "" {
+		if req.jkt, oauthErr = p.verifyDPoPProof(r, p.endpoint(TokenPath), ""); oauthErr != nil {
+			p.writeError(w, oauthErr)
+			return
+		}
+	}
+
+	grantType := r.PostForm.Get("grant_type")
+	grant, ok := p.grants[grantType]
+	if !ok {
+		p.writeError(w, &oauthError{status: http.StatusBadRequest, code: "unsupported_grant_type", description: "grant_type " + grantType + " is not supported"})
+		return
+	}
+	auth, oauthErr := grant(req)
+	if oauthErr != nil {
+		p.writeError(w, oauthErr)
+		return
+	}
+
+	resp, err := p.issueTokens(req, auth, grantType)
+	if err != nil {
+		p.writeError(w, &oauthError{status: http.StatusInternalServerError, code: "server_error", description: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// issueTokens mints an access token for auth, bound to the request's DPoP key
+// when it carried a proof, plus a refresh token and an ID token when a user
+// granted the authorization.
+func (p *Provider) issueTokens(req *tokenRequest, auth *authorization, grantType string) (map[string]any, error) {
+	now := time.Now()
+	expiry := now.Add(p.cfg.TokenLifetime)
+	subject := auth.subject
+	if subject == "" {
+		subject = auth.clientID
+	}
+
+	claims := jwt.MapClaims{
+		"iss":       p.cfg.Issuer,
+		"sub":       subject,
+		"client_id": auth.clientID,
+		"iat":       now.Unix(),
+		"exp":       expiry.Unix(),
+		"jti":       randomString(16),
+	}
+	if auth.audience != "" {
+		claims["aud"] = auth.audience
+	}
+	if auth.scope != "" {
+		claims["scope"] = auth.scope
+	}
+	tokenType := "Bearer"
+	if req.jkt != "" {
+		claims["cnf"] = map[string]string{"jkt": req.jkt}
+		tokenType = "DPoP"
+	}
+	accessToken, err := crypto.SignJWT(p.key, claims, map[string]any{"typ": "at+jwt", "kid": p.jwk.Kid})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]any{
+		"access_token": accessToken,
+		"token_type":   tokenType,
+		"expires_in":   int(p.cfg.TokenLifetime.Seconds()),
+	}
+	if auth.scope != "" {
+		resp["scope"] = auth.scope
+	}
+	if grantType == tokenExchangeGrant {
+		resp["issued_token_type"] = accessTokenType
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accessTokens[accessToken] = &issuedToken{auth: auth, jkt: req.jkt, expiry: expiry}
+	if auth.subject == "" || grantType == tokenExchangeGrant {
+		return resp, nil
+	}
+
+	// Refresh tokens of public clients are bound to their DPoP key
+	// (RFC 9449 §5).
+	if req.client.public {
+		auth.dpopJKT = req.jkt
+	}
+	auth.refreshToken = randomString(32)
+	p.refreshTokens[auth.refreshToken] = auth
+	resp["refresh_token"] = auth.refreshToken
+
+	if hasScope(auth.scope, "openid") {
+		idClaims := jwt.MapClaims{
+			"iss":       p.cfg.Issuer,
+			"sub":       auth.subject,
+			"aud":       auth.clientID,
+			"iat":       now.Unix(),
+			"exp":       expiry.Unix(),
+			"auth_time": auth.authTime.Unix(),
+		}
+		if auth.nonce != "" {
+			idClaims["nonce"] = auth.nonce
+		}
+		idToken, err := crypto.SignJWT(p.key, idClaims, map[string]any{"kid": p.jwk.Kid})
+		if err != nil {
+			return nil, err
+		}
+		resp["id_token"] = idToken
+	}
+	return resp, nil
+}
+
+func (p *Provider) authorizationCodeGrant(req *tokenRequest) (*authorization, *oauthError) {
+	code := req.form.Get("code")
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(auth.expiry):
+		return nil, invalidGrant("unknown or expired authorization code")
+	case auth.clientID != req.client.id:
+		return nil, invalidGrant("authorization code was issued to another client")
+	case req.form.Get("redirect_uri") != auth.redirectURI:
+		return nil, invalidGrant("redirect_uri does not match the authorization request")
+	case auth.dpopJKT != "" && auth.dpopJKT != req.jkt:
+		return nil, invalidGrant("DPoP key does not match dpop_jkt of the authorization request")
+	}
+	if oauthErr := checkPKCE(auth, req.form.Get("code_verifier")); oauthErr != nil {
+		return nil, oauthErr
+	}
+	auth.dpopJKT = ""
+	return auth, nil
+}
+
+func (p *Provider) deviceCodeGrant(req *tokenRequest) (*authorization, *oauthError) {
+	deviceCode := req.form.Get("device_code")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	device, ok := p.deviceCodes[deviceCode]
+	switch {
+	case !ok || device.auth.clientID != req.client.id:
+		return nil, invalidGrant("unknown device code")
+	case time.Now().After(device.auth.expiry):
+		delete(p.deviceCodes, deviceCode)
+		return nil, &oauthError{status: http.StatusBadRequest, code: "expired_token", description: "device code expired"}
+	case device.status == devicePending:
+		return nil, &oauthError{status: http.StatusBadRequest, code: "authorization_pending", description: "the user has not yet approved the device"}
+	case device.status == deviceDenied:
+		delete(p.deviceCodes, deviceCode)
+		return nil, &oauthError{status: http.StatusBadRequest, code: "access_denied", description: "the user denied the device"}
+	}
+	if oauthErr := checkPKCE(device.auth, req.form.Get("code_verifier")); oauthErr != nil {
+		return nil, oauthErr
+	}
+	delete(p.deviceCodes, deviceCode)
+	return device.auth, nil
+}
+
+func (p *Provider) refreshTokenGrant(req *tokenRequest) (*authorization, *oauthError) {
+	refreshToken := req.form.Get("refresh_token")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	auth, ok := p.refreshTokens[refreshToken]
+	switch {
+	case !ok || auth.clientID != req.client.id:
+		return nil, invalidGrant("unknown refresh token")
+	case auth.dpopJKT != "" && auth.dpopJKT != req.jkt:
+		return nil, invalidGrant("refresh token is bound to another DPoP key")
+	}
+
+	scope := auth.scope
+	if requested := req.form.Get("scope"); requested != "" {
+		for s := range strings.FieldsSeq(requested) {
+			if !hasScope(auth.scope, s) {
+				return nil, &oauthError{status: http.StatusBadRequest, code: "invalid_scope", description: "scope " + s + " was not granted"}
+			}
+		}
+		scope = requested
+	}
+
+	// Refresh tokens rotate: the one presented is spent.
+	delete(p.refreshTokens, refreshToken)
+	refreshed := *auth
+	refreshed.scope = scope
+	return &refreshed, nil
+}
+
+func (p *Provider) clientCredentialsGrant(req *tokenRequest) (*authorization, *oauthError) {
+	if req.client.public {
+		return nil, &oauthError{status: http.StatusBadRequest, code: "unauthorized_client", description: "client_credentials requires a confidential client"}
+	}
+	return &authorization{
+		clientID: req.client.id,
+		scope:    req.form.Get("scope"),
+		audience: requestedAudience(req.form),
+	}, nil
+}
+
+// tokenExchangeGrant exchanges a token the provider issued for an access token
+// for the same user, optionally for another audience or a narrower scope
+// (RFC 8693).
+func (p *Provider) tokenExchangeGrant(req *tokenRequest) (*authorization, *oauthError) {
+	subjectToken := req.form.Get("subject_token")
+	if subjectToken == "" || req.form.Get("subject_token_type") == "" {
+		return nil, invalidRequest("subject_token and subject_token_type are required")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var subject *authorization
+	if issued, ok := p.accessTokens[subjectToken]; ok && time.Now().Before(issued.expiry) {
+		subject = issued.auth
+	} else if auth, ok := p.refreshTokens[subjectToken]; ok {
+		subject = auth
+	} else {
+		return nil, invalidGrant("subject_token was not issued by this provider or has expired")
+	}
+
+	exchanged := &authorization{
+		clientID: req.client.id,
+		subject:  subject.subject,
+		scope:    subject.scope,
+		audience: requestedAudience(req.form),
+		authTime: subject.authTime,
+	}
+	if scope := req.form.Get("scope"); scope != "" {
+		exchanged.scope = scope
+	}
+	return exchanged, nil
+}
+
+// checkPKCE verifies the code_verifier against the challenge the
+// authorization was bound to, if any (RFC 7636 §4.6).
+func checkPKCE(auth *authorization, verifier string) *oauthError {
+	if auth.codeChallenge == "" {
+		return nil
+	}
+	if verifier == "" {
+		return invalidGrant("code_verifier is required")
+	}
+	computed := verifier
+	if auth.codeChallengeMethod != "plain" {
+		computed = crypto.GeneratePKCECodeChallenge(verifier)
+	}
+	if computed != auth.codeChallenge {
+		return invalidGrant("code_verifier does not match the code challenge")
+	}
+	return nil
+}
+
+// requestedAudience returns the audience a token request asks for, named
+// either by audience or, failing that, the first resource parameter.
+func requestedAudience(form url.Values) string {
+	if audience := form.Get("audience"); audience != "" {
+		return audience
+	}
+	return form.Get("resource")
+}
+
+func hasScope(scope, want string) bool {
+	return slices.Contains(strings.Fields(scope), want)
+}
@@ -0,0 +1,150 @@
+package mockop
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClientCredentials(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+
+	resp, tokens := requestToken(t, server, url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"read"},
+		"resource":   {"https://api.example.com"},
+	}, "")
+	if resp.status != http.StatusOK || resp.header.Get("Cache-Control") != "no-store" {
+		t.Fatalf("token response = %d %v", resp.status, resp.header)
+	}
+	if tokens["refresh_token"] != nil || tokens["id_token"] != nil || tokens["expires_in"] != float64(3600) {
+		t.Errorf("token response = %v, want an access token alone", tokens)
+	}
+	claims := parseJWT(t, p, tokens["access_token"].(string))
+	if claims["sub"] != testClientID || claims["client_id"] != testClientID || claims["scope"] != "read" || claims["aud"] != "https://api.example.com" {
+		t.Errorf("access token claims = %v", claims)
+	}
+}
+
+func TestClientAuthentication(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+	var tests = []struct {
+		name       string
+		form       url.Values
+		basic      bool
+		wantStatus int
+	}{
+		{"basic", url.Values{}, true, http.StatusOK},
+		{"post", url.Values{"client_id": {testClientID}, "client_secret": {testClientSecret}}, false, http.StatusOK},
+		{"wrong secret", url.Values{"client_id": {testClientID}, "client_secret": {"wrong"}}, false, http.StatusUnauthorized},
+		{"unknown client", url.Values{"client_id": {"other"}, "client_secret": {testClientSecret}}, false, http.StatusUnauthorized},
+		{"no client", url.Values{}, false, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.form.Set("grant_type", "client_credentials")
+			resp := postForm(t, server.URL+TokenPath, tt.form, tt.basic, "")
+			if resp.status != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", resp.status, tt.wantStatus, resp.body)
+			}
+		})
+	}
+}
+
+func TestAnyClientAcceptedWhenUnconfigured(t *testing.T) {
+	t.Parallel()
+	server, _, err := NewTestServer(Config{})
+	if err != nil {
+		t.Fatalf("NewTestServer() error = %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	resp := postForm(t, server.URL+TokenPath, url.Values{"grant_type": {"client_credentials"}, "client_id": {"anyone"}, "client_secret": {"anything"}}, false, "")
+	if resp.status != http.StatusOK {
+		t.Errorf("status = %d, want %d: %s", resp.status, http.StatusOK, resp.body)
+	}
+	// client_credentials needs a confidential client
+	resp = postForm(t, server.URL+TokenPath, url.Values{"grant_type": {"client_credentials"}, "client_id": {"anyone"}}, false, "")
+	if resp.status != http.StatusBadRequest || resp.json(t)["error"] != "unauthorized_client" {
+		t.Errorf("public client response = %d %s, want unauthorized_client", resp.status, resp.body)
+	}
+}
+
+func TestUnsupportedGrantType(t *testing.T) {
+	t.Parallel()
+	server, _ := newTestProvider(t, Config{})
+
+	resp, body := requestToken(t, server, url.Values{"grant_type": {"password"}}, "")
+	if resp.status != http.StatusBadRequest || body["error"] != "unsupported_grant_type" {
+		t.Errorf("token response = %d %v, want unsupported_grant_type", resp.status, body)
+	}
+}
+
+// issueUserTokens runs an authorization code grant and returns the tokens.
+func issueUserTokens(t *testing.T, server *httptest.Server) map[string]any {
+	t.Helper()
+	_, tokens := requestToken(t, server, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {authorize(t, server, authorizationParams()).Get("code")},
+		"redirect_uri": {testRedirectURI},
+	}, "")
+	return tokens
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{})
+	tokens := issueUserTokens(t, server)
+
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokens["refresh_token"].(string)}, "scope": {"openid"}}
+	resp, refreshed := requestToken(t, server, form, "")
+	if resp.status != http.StatusOK || refreshed["refresh_token"] == tokens["refresh_token"] {
+		t.Fatalf("refresh response = %d %v, want a new refresh token", resp.status, refreshed)
+	}
+	if claims := parseJWT(t, p, refreshed["access_token"].(string)); claims["scope"] != "openid" {
+		t.Errorf("refreshed scope = %v, want the narrowed scope", claims["scope"])
+	}
+
+	if resp, body := requestToken(t, server, form, ""); body["error"] != "invalid_grant" {
+		t.Errorf("reused refresh token = %d %v, want invalid_grant", resp.status, body)
+	}
+
+	form.Set("refresh_token", refreshed["refresh_token"].(string))
+	form.Set("scope", "openid email")
+	if resp, body := requestToken(t, server, form, ""); body["error"] != "invalid_scope" {
+		t.Errorf("widened scope = %d %v, want invalid_scope", resp.status, body)
+	}
+}
+
+func TestTokenExchange(t *testing.T) {
+	t.Parallel()
+	server, p := newTestProvider(t, Config{Subject: "carol"})
+	tokens := issueUserTokens(t, server)
+
+	resp, exchanged := requestToken(t, server, url.Values{
+		"grant_type":         {tokenExchangeGrant},
+		"subject_token":      {tokens["access_token"].(string)},
+		"subject_token_type": {accessTokenType},
+		"audience":           {"backend"},
+	}, "")
+	if resp.status != http.StatusOK || exchanged["issued_token_type"] != accessTokenType || exchanged["refresh_token"] != nil {
+		t.Fatalf("exchange response = %d %v", resp.status, exchanged)
+	}
+	if claims := parseJWT(t, p, exchanged["access_token"].(string)); claims["sub"] != "carol" || claims["aud"] != "backend" {
+		t.Errorf("exchanged token claims = %v", claims)
+	}
+
+	resp, body := requestToken(t, server, url.Values{
+		"grant_type":         {tokenExchangeGrant},
+		"subject_token":      {"not-issued-here"},
+		"subject_token_type": {accessTokenType},
+	}, "")
+	if resp.status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+		t.Errorf("unknown subject token = %d %v, want invalid_grant", resp.status, body)
+	}
+}
@@ -2,20 +2,41 @@ package oidc
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 
 	"github.com/jentz/oidc-cli/crypto"
 	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/webflow"
 )
 
 type AuthorizationCodeFlow struct {
 	Config     *Config
 	FlowConfig *AuthorizationCodeFlowConfig
+	// callbackListener is the callback server's listener when it was bound
+	// before the flow to learn its port.
+	callbackListener net.Listener
 }
 
 type AuthorizationCodeFlowConfig struct {
-	Scope       string
+	// ResponseType defaults to code; the implicit and hybrid response types
+	// return tokens from the authorization endpoint too.
+	ResponseType string
+	// Nonce is generated when unset and an ID token is requested from the
+	// authorization endpoint.
+	Nonce string
+	Scope string
+	// CallbackURI with port 0 listens on a port the operating system picks,
+	// which is substituted into the redirect_uri.
 	CallbackURI string
+	// CallbackPorts are tried in turn when the CallbackURI port is taken.
+	CallbackPorts []int
+	// CallbackTLS sets the certificate an https CallbackURI is served with.
+	CallbackTLS *CallbackTLSConfig
 	RedirectURI string
 	Prompt      string
 	AcrValues   string
@@ -27,20 +48,56 @@ type AuthorizationCodeFlowConfig struct {
 	PKCE        bool
 	PAR         bool
 	DPoP        bool
+	// RequestObject, when set, sends the authorization parameters as a signed
+	// request object instead of plain parameters.
+	RequestObject *RequestObjectConfig
+	// RequestURI passes a request object hosted by the client by reference.
+	RequestURI string
+	// AuthorizationDetails is a JSON array of RFC 9396 authorization details.
+	AuthorizationDetails string
+	// Claims is the claims request parameter (OpenID Connect Core §5.5) as
+	// JSON; which requested claims came back is reported on stderr.
+	Claims string
+	// Resources are RFC 8707 resource indicators of the APIs the token is
+	// for.
+	Resources []string
+	// ResponseMode is sent as response_mode; the JARM modes make the
+	// provider return the response as a JWT, which is verified before the
+	// code is redeemed.
+	ResponseMode string
+	// ResponseDecryptionKeyFile holds the private key an encrypted response
+	// JWT is decrypted with.
+	ResponseDecryptionKeyFile string
+	// StepUp, when set, answers a resource server's step-up challenge.
+	StepUp *StepUpConfig
+	// NoBrowser prints the authorization URL instead of opening it and reads
+	// the redirect URL the user pastes from Stdin instead of listening for it.
+	NoBrowser bool
+	Stdin     io.Reader
 }
 
 func (c *AuthorizationCodeFlow) createAuthCodeRequest(ctx context.Context, codeVerifier string) (*httpclient.AuthorizationCodeRequest, error) {
 	req := &httpclient.AuthorizationCodeRequest{
-		ClientID:    c.Config.OIDC.ClientID,
-		Scope:       c.FlowConfig.Scope,
-		RedirectURI: c.FlowConfig.RedirectURI,
-		Prompt:      c.FlowConfig.Prompt,
-		AcrValues:   c.FlowConfig.AcrValues,
-		LoginHint:   c.FlowConfig.LoginHint,
-		MaxAge:      c.FlowConfig.MaxAge,
-		UILocales:   c.FlowConfig.UILocales,
-		State:       c.FlowConfig.State,
-		CustomArgs:  c.FlowConfig.CustomArgs,
+		ResponseType:         c.FlowConfig.ResponseType,
+		Nonce:                c.FlowConfig.Nonce,
+		ClientID:             c.Config.OIDC.ClientID,
+		Scope:                c.FlowConfig.Scope,
+		RedirectURI:          c.FlowConfig.RedirectURI,
+		Prompt:               c.FlowConfig.Prompt,
+		AcrValues:            c.FlowConfig.AcrValues,
+		LoginHint:            c.FlowConfig.LoginHint,
+		MaxAge:               c.FlowConfig.MaxAge,
+		UILocales:            c.FlowConfig.UILocales,
+		State:                c.FlowConfig.State,
+		CustomArgs:           c.FlowConfig.CustomArgs,
+		ResponseMode:         c.FlowConfig.ResponseMode,
+		AuthorizationDetails: c.FlowConfig.AuthorizationDetails,
+		Claims:               c.FlowConfig.Claims,
+		Resources:            c.FlowConfig.Resources,
+	}
+	// An ID token from the authorization endpoint must carry a nonce
+	if req.Nonce == "" && c.FlowConfig.hasResponseType("id_token") {
+		req.Nonce = rand.Text()
 	}
 	// If the user has not explicitly set a redirect URI, use the callback URI
 	if c.FlowConfig.RedirectURI == "" {
@@ -50,6 +107,48 @@ func (c *AuthorizationCodeFlow) createAuthCodeRequest(ctx context.Context, codeV
 		req.CodeChallenge = crypto.GeneratePKCECodeChallenge(codeVerifier)
 		req.CodeChallengeMethod = "S256"
 	}
+	// Bind the authorization code to the DPoP key (RFC 9449 §10)
+	if c.FlowConfig.DPoP {
+		jkt, err := c.Config.DPoPKeys.Thumbprint()
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute dpop_jkt: %w", err)
+		}
+		req.DPoPJKT = jkt
+	}
+	// What the response must match, kept for when the parameters move into
+	// a request object
+	req.Expect = &httpclient.ExpectedResponse{
+		ResponseType: req.ResponseType,
+		State:        req.State,
+		Nonce:        req.Nonce,
+		Issuer:       c.Config.OIDC.IssuerURL,
+	}
+	if c.FlowConfig.RequestObject != nil {
+		values, err := httpclient.CreateAuthorizationCodeRequestValues(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create authorization code request values: %w", err)
+		}
+		requestObject, err := newRequestObject(ctx, c.Config, values, c.FlowConfig.RequestObject)
+		if err != nil {
+			return nil, err
+		}
+		// Only client_id must travel outside the request object (RFC 9101);
+		// OpenID Connect Core §6.1 also requires response_type and scope, and
+		// nonce and response_mode are repeated for providers reading them
+		// from the URL.
+		req = &httpclient.AuthorizationCodeRequest{
+			ResponseType: req.ResponseType,
+			ClientID:     req.ClientID,
+			Scope:        req.Scope,
+			Nonce:        req.Nonce,
+			ResponseMode: req.ResponseMode,
+			Request:      requestObject,
+			Expect:       req.Expect,
+		}
+	}
+	if c.FlowConfig.RequestURI != "" {
+		req.RequestURI = c.FlowConfig.RequestURI
+	}
 	if c.FlowConfig.PAR {
 		parParams, err := httpclient.CreateAuthorizationCodeRequestValues(req)
 		if err != nil {
@@ -61,6 +160,9 @@ func (c *AuthorizationCodeFlow) createAuthCodeRequest(ctx context.Context, codeV
 			AuthMethod:   c.Config.OIDC.AuthMethod,
 			Params:       parParams,
 		}
+		if c.FlowConfig.DPoP {
+			parReq.DPoP = c.Config.DPoPKeys.ProofFunc()
+		}
 		resp, err := c.Config.Runtime.Client.ExecutePushedAuthorizationRequest(ctx, c.Config.OIDC.PushedAuthorizationRequestEndpoint, parReq)
 		if err != nil {
 			return nil, fmt.Errorf("pushed authorization request failed: %w", err)
@@ -70,12 +172,63 @@ func (c *AuthorizationCodeFlow) createAuthCodeRequest(ctx context.Context, codeV
 			return nil, httpclient.WrapError(err, "pushed authorization")
 		}
 		req.RequestURI = parResp.RequestURI
+		// The pushed request object now sits behind the request_uri; sending
+		// both is an error (RFC 9101 §5).
+		req.Request = ""
 	}
 	return req, nil
 }
 
+// listenCallback binds the callback server's listener up front when its port
+// is not fixed, substituting the bound port into the callback URI.
+func (c *AuthorizationCodeFlow) listenCallback() error {
+	u, err := url.Parse(c.FlowConfig.CallbackURI)
+	if err != nil {
+		return fmt.Errorf("invalid callback URI: %w", err)
+	}
+	if u.Port() != "0" && len(c.FlowConfig.CallbackPorts) == 0 {
+		return nil
+	}
+	ln, callback, err := c.Config.Runtime.Client.ListenCallback(c.FlowConfig.CallbackURI, c.FlowConfig.CallbackPorts)
+	if err != nil {
+		return err
+	}
+	c.callbackListener = ln
+	c.FlowConfig.CallbackURI = callback
+	return nil
+}
+
 func (c *AuthorizationCodeFlow) executeAuthCodeRequest(ctx context.Context, req *httpclient.AuthorizationCodeRequest) (*httpclient.AuthorizationCodeResponse, error) {
-	resp, err := c.Config.Runtime.Client.ExecuteAuthorizationCodeRequest(ctx, c.Config.OIDC.AuthorizationEndpoint, c.FlowConfig.CallbackURI, req)
+	if c.FlowConfig.NoBrowser {
+		var decode webflow.JARMDecoder
+		if isJARMResponseMode(c.FlowConfig.ResponseMode) {
+			decode = c.decodeJARMResponse
+		}
+		resp, err := c.Config.Runtime.Client.ExecutePastedAuthorizationCodeRequest(ctx, c.Config.OIDC.AuthorizationEndpoint, req, c.FlowConfig.Stdin, decode)
+		if err != nil {
+			return nil, fmt.Errorf("authorization request failed: %w", err)
+		}
+		return resp, nil
+	}
+	var opts []webflow.Option
+	if isJARMResponseMode(c.FlowConfig.ResponseMode) {
+		opts = append(opts, webflow.WithJARM(c.decodeJARMResponse))
+	}
+	// The browser keeps the fragment to itself; a relay page posts it back
+	if c.FlowConfig.fragmentResponse() {
+		opts = append(opts, webflow.WithFragmentRelay())
+	}
+	if callback, err := url.Parse(c.FlowConfig.CallbackURI); err == nil && callback.Scheme == "https" {
+		cert, err := c.callbackCertificate(callback)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, webflow.WithTLS(cert))
+	}
+	if ln := c.callbackListener; ln != nil {
+		opts = append(opts, webflow.WithListenFunc(func(_, _ string) (net.Listener, error) { return ln, nil }))
+	}
+	resp, err := c.Config.Runtime.Client.ExecuteAuthorizationCodeRequest(ctx, c.Config.OIDC.AuthorizationEndpoint, c.FlowConfig.CallbackURI, req, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("authorization request failed: %w", err)
 	}
@@ -91,6 +244,7 @@ func (c *AuthorizationCodeFlow) executeTokenRequest(ctx context.Context, code, c
 		c.FlowConfig.CallbackURI,
 		codeVerifier,
 	)
+	tokenRequest.Resources = c.FlowConfig.Resources
 	tokenRequest.DPoP = dpop
 	resp, err := c.Config.Runtime.Client.ExecuteTokenRequest(ctx, c.Config.OIDC.TokenEndpoint, tokenRequest)
 	if err != nil {
@@ -104,6 +258,34 @@ func (c *AuthorizationCodeFlow) executeTokenRequest(ctx context.Context, code, c
 }
 
 func (c *AuthorizationCodeFlow) Run(ctx context.Context) error {
+	// Fail before the browser opens if a response JWT or front-channel ID
+	// token could not be verified
+	if isJARMResponseMode(c.FlowConfig.ResponseMode) || c.FlowConfig.hasResponseType("id_token") {
+		if c.Config.OIDC.IssuerURL == "" {
+			return errors.New("an issuer is required to verify the authorization response")
+		}
+		if c.Config.OIDC.JWKSEndpoint == "" {
+			return errors.New("the authorization server advertises no jwks_uri, set one with --jwks-url")
+		}
+	}
+	// Take the step-up demand before the authorization request is built
+	var demand *stepUpDemand
+	if c.FlowConfig.StepUp != nil {
+		var err error
+		if demand, err = c.stepUpDemand(ctx); err != nil {
+			return err
+		}
+		c.applyStepUp(demand)
+	}
+	// Bind the callback port before it goes into the redirect_uri
+	if !c.FlowConfig.NoBrowser {
+		if err := c.listenCallback(); err != nil {
+			return err
+		}
+		if ln := c.callbackListener; ln != nil {
+			defer func() { _ = ln.Close() }()
+		}
+	}
 	// Handle PKCE
 	codeVerifier, err := c.Config.OIDC.setupPKCE(c.FlowConfig.PKCE)
 	if err != nil {
@@ -119,6 +301,22 @@ func (c *AuthorizationCodeFlow) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if authResp.IDToken != "" {
+		if err := c.verifyFrontChannelIDToken(ctx, authResp, authCodeReq.Expect.Nonce); err != nil {
+			return err
+		}
+	}
+	// The implicit response types return no code to redeem
+	if authResp.Code == "" {
+		tokens := frontChannelTokens(authResp)
+		if demand != nil {
+			if err := c.checkStepUp(ctx, demand, tokens); err != nil {
+				return err
+			}
+		}
+		c.reportRequestedClaims(ctx, tokens)
+		return c.Config.Runtime.Logger.OutputJSON(tokens)
+	}
 	// Handle DPoP
 	var dpopFunc httpclient.DPoPProofFunc
 	if c.FlowConfig.DPoP {
@@ -129,6 +327,19 @@ func (c *AuthorizationCodeFlow) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.FlowConfig.DPoP {
+		if err := c.Config.DPoPKeys.checkAccessTokenBinding(tokenData); err != nil {
+			return err
+		}
+	}
+
+	if demand != nil {
+		if err := c.checkStepUp(ctx, demand, tokenData); err != nil {
+			return err
+		}
+	}
 
+	showAuthorizationDetails(c.Config.Runtime.Logger, tokenData)
+	c.reportRequestedClaims(ctx, tokenData)
 	return c.Config.Runtime.Logger.OutputJSON(tokenData)
 }
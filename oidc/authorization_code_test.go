@@ -5,12 +5,16 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/jentz/oidc-cli/crypto"
 	"github.com/jentz/oidc-cli/crypto/cryptotest"
 )
 
@@ -358,6 +362,19 @@ func TestAuthorizationCodeFlowRunDPoP(t *testing.T) {
 
 	// VerifyDPoPProof fails on an empty proof, so it doubles as the presence check.
 	cryptotest.VerifyDPoPProof(t, tokenReq.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
+
+	// The authorization request must bind the code to the same key (RFC 9449 §10).
+	authURL, err := url.Parse(browser.openedURL)
+	if err != nil {
+		t.Fatalf("parsing opened URL %q: %v", browser.openedURL, err)
+	}
+	wantJKT, err := crypto.JWKThumbprint(fixture.dpopPublicKey)
+	if err != nil {
+		t.Fatalf("computing thumbprint: %v", err)
+	}
+	if got := authURL.Query().Get("dpop_jkt"); got != wantJKT {
+		t.Errorf("dpop_jkt = %q, want %q", got, wantJKT)
+	}
 }
 
 // TestAuthorizationCodeFlowRunStateMismatch proves the CSRF check is wired into
@@ -415,3 +432,241 @@ func TestAuthorizationCodeFlowRunStateMismatch(t *testing.T) {
 		t.Errorf("emitted %d requests, want 0 when the callback state is rejected", len(fixture.requests))
 	}
 }
+
+func TestAuthorizationCodeFlowRunResponseModes(t *testing.T) {
+	t.Parallel()
+
+	// Each responder delivers the code the way the response mode returns it.
+	postForm := func(addr string, opened *url.URL) error {
+		resp, err := http.PostForm("http://"+addr+"/callback", url.Values{ //nolint:noctx // test-local loopback request
+			"code":  {"auth-code"},
+			"state": {opened.Query().Get("state")},
+		})
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	tests := []struct {
+		name    string
+		mode    string
+		respond func(addr string, opened *url.URL) error
+	}{
+		{name: "form_post", mode: "form_post", respond: postForm},
+		{
+			name: "fragment",
+			mode: "fragment",
+			respond: func(addr string, opened *url.URL) error {
+				// The fragment never reaches the server: the bare redirect
+				// gets the relay page, whose script posts the fragment back.
+				resp, err := http.Get("http://" + addr + "/callback") //nolint:noctx // test-local loopback request
+				if err != nil {
+					return err
+				}
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					return err
+				}
+				if !strings.Contains(string(body), "location.hash") {
+					return fmt.Errorf("callback served %q, want the relay page", body)
+				}
+				return postForm(addr, opened)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture, opened, err := runAuthorizationCodeWith(t,
+				&AuthorizationCodeFlowConfig{Scope: "openid", State: "state-123", ResponseMode: tt.mode},
+				tt.respond)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := opened.Query().Get("response_mode"); got != tt.mode {
+				t.Errorf("response_mode = %q, want %q", got, tt.mode)
+			}
+			if got := fixture.onlyRequest(t).Form.Get("code"); got != "auth-code" {
+				t.Errorf("token request code = %q, want auth-code", got)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunResources(t *testing.T) {
+	t.Parallel()
+
+	resources := []string{"https://api.example.com", "https://other.example.com"}
+
+	t.Run("query", func(t *testing.T) {
+		t.Parallel()
+		fixture, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{Scope: "openid", Resources: resources})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got := opened.Query()["resource"]; !slices.Equal(got, resources) {
+			t.Errorf("authorization resource = %q, want %q", got, resources)
+		}
+		if got := fixture.onlyRequest(t).Form["resource"]; !slices.Equal(got, resources) {
+			t.Errorf("token request resource = %q, want %q", got, resources)
+		}
+	})
+
+	t.Run("PAR", func(t *testing.T) {
+		t.Parallel()
+		fixture, _, err := runAuthorizationCode(t,
+			&AuthorizationCodeFlowConfig{Scope: "openid", PAR: true, Resources: resources},
+			withRoute(testPAREndpoint, http.StatusCreated, `{"request_uri":"urn:par:rs","expires_in":60}`),
+		)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if got := fixture.requests[0].Form["resource"]; !slices.Equal(got, resources) {
+			t.Errorf("PAR resource = %q, want %q", got, resources)
+		}
+	})
+
+	t.Run("request object", func(t *testing.T) {
+		t.Parallel()
+		keyFile, key := writeSigningKey(t)
+		for _, tc := range []struct {
+			resources []string
+			want      any
+		}{
+			{resources: resources[:1], want: resources[0]},
+			{resources: resources, want: []any{resources[0], resources[1]}},
+		} {
+			_, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+				Scope:         "openid",
+				Resources:     tc.resources,
+				RequestObject: &RequestObjectConfig{KeyFile: keyFile},
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			claims := verifyRequestObject(t, opened.Query().Get("request"), key)
+			if !reflect.DeepEqual(claims["resource"], tc.want) {
+				t.Errorf("request object resource = %#v, want %#v", claims["resource"], tc.want)
+			}
+		}
+	})
+}
+
+func TestAuthorizationCodeFlowRunNoBrowser(t *testing.T) {
+	t.Parallel()
+
+	const redirect = "http://localhost/callback?code=auth-code&state=state-123&iss="
+	keyFile, _ := writeSigningKey(t)
+	tests := []struct {
+		name          string
+		pasted        string
+		requestObject *RequestObjectConfig
+		wantErr       string
+	}{
+		{name: "redirect URL", pasted: redirect + url.QueryEscape(testIssuer) + "\n"},
+		{name: "bare code", pasted: "auth-code\n"},
+		{name: "issuer mismatch", pasted: redirect + url.QueryEscape("https://attacker.example.com"), wantErr: "issuer mismatch"},
+		{
+			name:          "issuer mismatch with a request object",
+			pasted:        redirect + url.QueryEscape("https://attacker.example.com"),
+			requestObject: &RequestObjectConfig{KeyFile: keyFile},
+			wantErr:       "issuer mismatch",
+		},
+		{name: "nothing pasted", pasted: "", wantErr: "no redirect URL was pasted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			browser := &recordingBrowser{}
+			fixture := newReadyConfig(t,
+				withBrowser(browser),
+				withListener(func(_, _ string) (net.Listener, error) {
+					t.Error("no-browser started the callback server")
+					return nil, io.ErrClosedPipe
+				}),
+				withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`),
+			)
+			fixture.config.OIDC.IssuerURL = testIssuer
+			flow := &AuthorizationCodeFlow{
+				Config: fixture.config,
+				FlowConfig: &AuthorizationCodeFlowConfig{
+					Scope:         "openid",
+					State:         "state-123",
+					CallbackURI:   "http://localhost/callback",
+					RequestObject: tt.requestObject,
+					NoBrowser:     true,
+					Stdin:         strings.NewReader(tt.pasted),
+				},
+			}
+			err := flow.Run(t.Context())
+
+			if browser.openedURL != "" {
+				t.Errorf("opened %q, want no browser", browser.openedURL)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if len(fixture.requests) != 0 {
+					t.Errorf("emitted %d requests, want none", len(fixture.requests))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(fixture.requests) != 1 || fixture.requests[0].Form.Get("code") != "auth-code" {
+				t.Fatalf("requests = %+v, want the pasted code redeemed", fixture.requests)
+			}
+			if !strings.Contains(fixture.output.String(), "abc123") {
+				t.Errorf("output = %q, want the tokens", fixture.output.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunAnyCallbackPort(t *testing.T) {
+	t.Parallel()
+
+	browser := &callbackFiringBrowser{}
+	browser.fire = func() error {
+		opened, err := url.Parse(browser.openedURL)
+		if err != nil {
+			return err
+		}
+		query := opened.Query()
+		resp, err := http.Get(query.Get("redirect_uri") + "?code=auth-code&state=" + url.QueryEscape(query.Get("state"))) //nolint:noctx // test-local loopback request
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	fixture := newReadyConfig(t,
+		withBrowser(browser),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`),
+	)
+	flow := &AuthorizationCodeFlow{
+		Config:     fixture.config,
+		FlowConfig: &AuthorizationCodeFlowConfig{Scope: "openid", State: "state-123", CallbackURI: "http://127.0.0.1:0/callback"},
+	}
+	if err := flow.Run(t.Context()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	opened, err := url.Parse(browser.openedURL)
+	if err != nil {
+		t.Fatalf("parsing opened URL: %v", err)
+	}
+	redirectURI, err := url.Parse(opened.Query().Get("redirect_uri"))
+	if err != nil || redirectURI.Hostname() != "127.0.0.1" || redirectURI.Port() == "0" || redirectURI.Path != "/callback" {
+		t.Fatalf("redirect_uri = %q, want the bound port substituted", opened.Query().Get("redirect_uri"))
+	}
+	if len(fixture.requests) != 1 {
+		t.Fatalf("emitted %d requests, want the token request", len(fixture.requests))
+	}
+	if got := fixture.requests[0].Form.Get("redirect_uri"); got != redirectURI.String() {
+		t.Errorf("token request redirect_uri = %q, want %q", got, redirectURI)
+	}
+}
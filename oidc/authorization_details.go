@@ -0,0 +1,94 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/log"
+)
+
+// setAuthorizationDetails adds the authorization_details parameter (RFC 9396
+// §6) to a token request when details are configured.
+func setAuthorizationDetails(req *httpclient.TokenRequest, details string) {
+	if details != "" {
+		req.Params.Set("authorization_details", details)
+	}
+}
+
+// showAuthorizationDetails summarises the authorization_details a token
+// response grants (RFC 9396 §7) on stderr, one entry per type. Details some
+// servers return as an encoded JSON string are decoded in place, so the JSON
+// output shows them as structured data too.
+func showAuthorizationDetails(logger *log.Logger, tokenData map[string]any) {
+	granted, ok := tokenData["authorization_details"]
+	if !ok {
+		return
+	}
+	if encoded, isString := granted.(string); isString {
+		var decoded any
+		if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+			logger.Errorf("authorization_details is not JSON: %v\n", err)
+			return
+		}
+		tokenData["authorization_details"] = decoded
+		granted = decoded
+	}
+	details, ok := granted.([]any)
+	if !ok {
+		logger.Errorf("authorization_details is not an array\n")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("granted authorization_details:\n")
+	for _, entry := range details {
+		detail, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "  %v\n", detail["type"])
+		names := make([]string, 0, len(detail))
+		for name := range detail {
+			if name != "type" {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "    %s: %s\n", name, formatDetailValue(detail[name]))
+		}
+	}
+	logger.Errorf("%s", b.String())
+}
+
+// formatDetailValue renders a string as is, a list of strings comma
+// separated, and anything else as compact JSON.
+func formatDetailValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			s, ok := item.(string)
+			if !ok {
+				return compactJSON(v)
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ", ")
+	default:
+		return compactJSON(v)
+	}
+}
+
+func compactJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,187 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/log"
+)
+
+const testAuthorizationDetails = `[{"type":"payment_initiation","actions":["initiate","status"],"instructedAmount":{"currency":"EUR","amount":"123.50"}}]`
+
+func TestShowAuthorizationDetails(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		granted   any
+		wantErr   string
+		wantLines []string
+	}{
+		{
+			name:    "array",
+			granted: []any{map[string]any{"type": "account_information", "locations": []any{"https://example.com/accounts"}}},
+			wantLines: []string{
+				"granted authorization_details:",
+				"  account_information",
+				"    locations: https://example.com/accounts",
+			},
+		},
+		{
+			name:    "encoded as a string",
+			granted: testAuthorizationDetails,
+			wantLines: []string{
+				"  payment_initiation",
+				"    actions: initiate, status",
+				`    instructedAmount: {"amount":"123.50","currency":"EUR"}`,
+			},
+		},
+		{name: "not json", granted: "payment", wantErr: "authorization_details is not JSON"},
+		{name: "not an array", granted: map[string]any{"type": "x"}, wantErr: "authorization_details is not an array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var stdout, stderr bytes.Buffer
+			tokenData := map[string]any{"authorization_details": tt.granted}
+			showAuthorizationDetails(log.New(log.WithOutput(&stdout, &stderr)), tokenData)
+
+			if tt.wantErr != "" {
+				if !strings.Contains(stderr.String(), tt.wantErr) {
+					t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantErr)
+				}
+				return
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(stderr.String(), line+"\n") {
+					t.Errorf("stderr = %q, want the line %q", stderr.String(), line)
+				}
+			}
+			if _, ok := tokenData["authorization_details"].([]any); !ok {
+				t.Errorf("authorization_details = %T, want it decoded to an array", tokenData["authorization_details"])
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout = %q, want the summary on stderr only", stdout.String())
+			}
+		})
+	}
+
+	t.Run("absent", func(t *testing.T) {
+		t.Parallel()
+		var stderr bytes.Buffer
+		showAuthorizationDetails(log.New(log.WithOutput(&bytes.Buffer{}, &stderr)), map[string]any{"access_token": "abc123"})
+		if stderr.Len() != 0 {
+			t.Errorf("stderr = %q, want nothing without authorization_details", stderr.String())
+		}
+	})
+}
+
+// flowRunner is the Run method every flow shares.
+type flowRunner interface {
+	Run(ctx context.Context) error
+}
+
+func TestTokenFlowsSendAuthorizationDetails(t *testing.T) {
+	t.Parallel()
+
+	// The granted details come back encoded as a string and are printed as
+	// structured JSON.
+	tokenResponse := `{"access_token":"abc123","token_type":"Bearer","authorization_details":` + jsonString(t, testAuthorizationDetails) + `}`
+	tests := []struct {
+		name string
+		flow func(*Config) flowRunner
+	}{
+		{
+			name: "client credentials",
+			flow: func(c *Config) flowRunner {
+				return &ClientCredentialsFlow{Config: c, FlowConfig: &ClientCredentialsFlowConfig{AuthorizationDetails: testAuthorizationDetails}}
+			},
+		},
+		{
+			name: "token refresh",
+			flow: func(c *Config) flowRunner {
+				return &TokenRefreshFlow{Config: c, FlowConfig: &TokenRefreshFlowConfig{RefreshToken: "rt", AuthorizationDetails: testAuthorizationDetails}}
+			},
+		},
+		{
+			name: "token exchange",
+			flow: func(c *Config) flowRunner {
+				return &TokenExchangeFlow{Config: c, FlowConfig: &TokenExchangeFlowConfig{
+					SubjectToken:         "st",
+					SubjectTokenType:     "urn:ietf:params:oauth:token-type:access_token",
+					AuthorizationDetails: testAuthorizationDetails,
+				}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t, withResponse(http.StatusOK, tokenResponse))
+			if err := tt.flow(fixture.config).Run(context.Background()); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := fixture.onlyRequest(t).Form.Get("authorization_details"); got != testAuthorizationDetails {
+				t.Errorf("authorization_details = %q, want %q", got, testAuthorizationDetails)
+			}
+			var output map[string]any
+			if err := json.Unmarshal(fixture.output.Bytes(), &output); err != nil {
+				t.Fatalf("parsing output %q: %v", fixture.output.String(), err)
+			}
+			if _, ok := output["authorization_details"].([]any); !ok {
+				t.Errorf("output authorization_details = %v, want an array", output["authorization_details"])
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunAuthorizationDetails(t *testing.T) {
+	t.Parallel()
+
+	_, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+		Scope:                "openid",
+		AuthorizationDetails: testAuthorizationDetails,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := opened.Query().Get("authorization_details"); got != testAuthorizationDetails {
+		t.Errorf("authorization_details = %q, want %q", got, testAuthorizationDetails)
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestObjectAuthorizationDetails(t *testing.T) {
+	t.Parallel()
+
+	keyFile, key := writeSigningKey(t)
+	_, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+		Scope:                "openid",
+		AuthorizationDetails: testAuthorizationDetails,
+		RequestObject:        &RequestObjectConfig{KeyFile: keyFile},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	claims := verifyRequestObject(t, opened.Query().Get("request"), key)
+	details, ok := claims["authorization_details"].([]any)
+	if !ok || len(details) != 1 {
+		t.Fatalf("request object authorization_details = %v, want an embedded array", claims["authorization_details"])
+	}
+	if detail, _ := details[0].(map[string]any); detail["type"] != "payment_initiation" {
+		t.Errorf("authorization_details[0] = %v, want the payment_initiation entry", details[0])
+	}
+}
+
+// jsonString encodes s as a JSON string literal.
+func jsonString(t *testing.T, s string) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("encoding %q: %v", s, err)
+	}
+	return string(b)
+}
@@ -0,0 +1,70 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// CallFlow calls a protected resource with an access token from a TokenSource,
+// presenting it as a Bearer or DPoP-bound token. A call rejected with
+// invalid_token is retried once with a new token.
+type CallFlow struct {
+	Config     *Config
+	FlowConfig *CallFlowConfig
+}
+
+type CallFlowConfig struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    []byte
+	Tokens  *TokenSource
+}
+
+func (c *CallFlow) Run(ctx context.Context) error {
+	tokens := c.FlowConfig.Tokens
+	token, err := tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain access token: %w", err)
+	}
+
+	req, resp, err := c.call(ctx, token)
+	if err != nil {
+		return err
+	}
+	if httpclient.IsInvalidTokenChallenge(resp) && tokens.CanRefresh() {
+		c.Config.Runtime.Logger.Printf("access token rejected, obtaining a new one and retrying\n")
+		token, err = tokens.Refresh(ctx, token)
+		if err != nil {
+			return fmt.Errorf("failed to refresh access token: %w", err)
+		}
+		req, resp, err = c.call(ctx, token)
+		if err != nil {
+			return err
+		}
+	}
+
+	return outputResourceResponse(c.Config.Runtime.Logger, req, resp)
+}
+
+// call sends the request once with token attached.
+func (c *CallFlow) call(ctx context.Context, token *Token) (*httpclient.ResourceRequest, *httpclient.Response, error) {
+	req := &httpclient.ResourceRequest{
+		Method:      c.FlowConfig.Method,
+		URL:         c.FlowConfig.URL,
+		AccessToken: token.AccessToken,
+		Headers:     c.FlowConfig.Headers,
+		Body:        c.FlowConfig.Body,
+	}
+	if c.FlowConfig.Tokens.DPoP {
+		req.DPoP = c.Config.DPoPKeys.AccessTokenProofFunc(token.AccessToken)
+	}
+
+	resp, err := c.Config.Runtime.Client.ExecuteResourceRequest(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resource request failed: %w", err)
+	}
+	return req, resp, nil
+}
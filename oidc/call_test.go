@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// invalidTokenResponse is a resource server's rejection of an expired token.
+func invalidTokenResponse() cannedResponse {
+	header := make(http.Header)
+	header.Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="token expired"`)
+	return cannedResponse{status: http.StatusUnauthorized, header: header}
+}
+
+func TestCallFlowAttachesBearerToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"cc-1"}`),
+		withRoute(testResourceURL, http.StatusOK, `{"items":[]}`),
+	)
+	flow := &CallFlow{
+		Config: fixture.config,
+		FlowConfig: &CallFlowConfig{
+			Method: http.MethodGet,
+			URL:    testResourceURL,
+			Tokens: &TokenSource{Config: fixture.config, Grant: GrantClientCredentials},
+		},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want token then resource", len(fixture.requests))
+	}
+	call := fixture.requests[1]
+	if got := call.Header.Get("Authorization"); got != "Bearer cc-1" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer cc-1")
+	}
+	if got := call.Header.Get("DPoP"); got != "" {
+		t.Errorf("DPoP = %q, want none for a bearer call", got)
+	}
+	if got := fixture.output.String(); got != "{\"items\":[]}\n" {
+		t.Errorf("output = %q, want the response body", got)
+	}
+}
+
+func TestCallFlowAttachesDPoPToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys(),
+		withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"dpop-1","token_type":"DPoP"}`),
+		withRoute(testResourceURL, http.StatusOK, `ok`),
+	)
+	flow := &CallFlow{
+		Config: fixture.config,
+		FlowConfig: &CallFlowConfig{
+			Method: http.MethodPost,
+			URL:    testResourceURL,
+			Body:   []byte("a=1"),
+			Tokens: &TokenSource{Config: fixture.config, Grant: GrantClientCredentials, DPoP: true},
+		},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want token then resource", len(fixture.requests))
+	}
+	cryptotest.VerifyDPoPProof(t, fixture.requests[0].Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
+	call := fixture.requests[1]
+	if got := call.Header.Get("Authorization"); got != "DPoP dpop-1" {
+		t.Errorf("Authorization = %q, want %q", got, "DPoP dpop-1")
+	}
+	cryptotest.VerifyDPoPProof(t, call.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testResourceURL)
+	if got := call.Form.Get("a"); got != "1" {
+		t.Errorf("body a = %q, want 1", got)
+	}
+}
+
+func TestCallFlowRefreshesOnInvalidToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"at-2"}`),
+		withRouteResponses(testResourceURL,
+			invalidTokenResponse(),
+			cannedResponse{status: http.StatusOK, body: "ok"},
+		),
+	)
+	flow := &CallFlow{
+		Config: fixture.config,
+		FlowConfig: &CallFlowConfig{
+			Method: http.MethodGet,
+			URL:    testResourceURL,
+			Tokens: &TokenSource{
+				Config:       fixture.config,
+				Grant:        GrantRefreshToken,
+				AccessToken:  "at-1",
+				RefreshToken: "rt-1",
+			},
+		},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 3 {
+		t.Fatalf("got %d emitted requests, want call, refresh, retry", len(fixture.requests))
+	}
+	if got := fixture.requests[0].Header.Get("Authorization"); got != "Bearer at-1" {
+		t.Errorf("first call Authorization = %q, want Bearer at-1", got)
+	}
+	if got := fixture.requests[1].Form.Get("refresh_token"); got != "rt-1" {
+		t.Errorf("refresh_token = %q, want rt-1", got)
+	}
+	if got := fixture.requests[2].Header.Get("Authorization"); got != "Bearer at-2" {
+		t.Errorf("retry Authorization = %q, want Bearer at-2", got)
+	}
+	if got := fixture.output.String(); got != "ok\n" {
+		t.Errorf("output = %q, want %q", got, "ok\n")
+	}
+}
+
+// TestCallFlowRetriesOnce pins that a token the resource server keeps
+// rejecting surfaces the rejection after a single refresh.
+func TestCallFlowRetriesOnce(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"cc"}`),
+		withRouteResponses(testResourceURL, invalidTokenResponse()),
+	)
+	flow := &CallFlow{
+		Config: fixture.config,
+		FlowConfig: &CallFlowConfig{
+			Method: http.MethodGet,
+			URL:    testResourceURL,
+			Tokens: &TokenSource{Config: fixture.config, Grant: GrantClientCredentials},
+		},
+	}
+	err := flow.Run(context.Background())
+	if !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Fatalf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	if len(fixture.requests) != 4 {
+		t.Errorf("got %d emitted requests, want token, call, token, call", len(fixture.requests))
+	}
+}
+
+func TestCallFlowStaticTokenIsNotRetried(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withRouteResponses(testResourceURL, invalidTokenResponse()))
+	flow := &CallFlow{
+		Config: fixture.config,
+		FlowConfig: &CallFlowConfig{
+			Method: http.MethodGet,
+			URL:    testResourceURL,
+			Tokens: &TokenSource{Config: fixture.config, AccessToken: "static"},
+		},
+	}
+	if err := flow.Run(context.Background()); !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Fatalf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	fixture.onlyRequest(t)
+}
@@ -0,0 +1,94 @@
+package oidc
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+const (
+	// ephemeralCertificateLifetime is how long a certificate generated for a
+	// single run is valid.
+	ephemeralCertificateLifetime = time.Hour
+	// writtenCertificateLifetime is how long a certificate written to disk to
+	// be trusted is valid.
+	writtenCertificateLifetime = 90 * 24 * time.Hour
+)
+
+// CallbackTLSConfig sets the certificate an https callback URI is served
+// with. Without a certificate file, a self-signed certificate is generated
+// for the run.
+type CallbackTLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// Write generates a self-signed certificate into CertFile and KeyFile
+	// when they do not exist yet, so it can be trusted once and reused.
+	Write bool
+}
+
+// callbackCertificate returns the certificate the https callback server is
+// served with, telling on stderr when the browser will warn about it.
+func (c *AuthorizationCodeFlow) callbackCertificate(callback *url.URL) (tls.Certificate, error) {
+	logger := c.Config.Runtime.Logger
+	conf := c.FlowConfig.CallbackTLS
+	if conf == nil {
+		conf = &CallbackTLSConfig{}
+	}
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if host := callback.Hostname(); host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		hosts = append([]string{host}, hosts...)
+	}
+
+	if conf.CertFile == "" {
+		certPEM, keyPEM, err := crypto.GenerateSelfSignedCertificate(hosts, ephemeralCertificateLifetime)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to generate callback certificate: %w", err)
+		}
+		logger.Errorf("the callback server uses a self-signed certificate for this run, so the browser will warn about %s://%s;\n"+
+			"accept the warning to continue, or write a certificate to trust once with --callback-tls-cert, --callback-tls-key and --callback-tls-write\n",
+			callback.Scheme, callback.Host)
+		return tls.X509KeyPair(certPEM, keyPEM)
+	}
+
+	if conf.Write {
+		_, err := os.Stat(conf.CertFile)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			if err := writeCallbackCertificate(conf, hosts); err != nil {
+				return tls.Certificate{}, err
+			}
+			logger.Errorf("wrote a self-signed callback certificate to %s, valid for %d days;\n"+
+				"trust it in your browser or system certificate store to stop the browser warning about %s://%s\n",
+				conf.CertFile, int(writtenCertificateLifetime.Hours()/24), callback.Scheme, callback.Host)
+		case err != nil:
+			return tls.Certificate{}, fmt.Errorf("failed to check the callback certificate: %w", err)
+		}
+	}
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read the callback certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// writeCallbackCertificate generates a self-signed certificate for hosts and
+// writes it and its key to the configured files.
+func writeCallbackCertificate(conf *CallbackTLSConfig, hosts []string) error {
+	certPEM, keyPEM, err := crypto.GenerateSelfSignedCertificate(hosts, writtenCertificateLifetime)
+	if err != nil {
+		return fmt.Errorf("failed to generate callback certificate: %w", err)
+	}
+	if err := os.WriteFile(conf.KeyFile, keyPEM, 0o600); err != nil {
+		return fmt.Errorf("failed to write the callback certificate key: %w", err)
+	}
+	// #nosec G306 -- a certificate is public, only its key is kept private.
+	if err := os.WriteFile(conf.CertFile, certPEM, 0o644); err != nil {
+		return fmt.Errorf("failed to write the callback certificate: %w", err)
+	}
+	return nil
+}
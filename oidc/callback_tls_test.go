@@ -0,0 +1,97 @@
+package oidc
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorizationCodeFlowRunHTTPSCallback(t *testing.T) {
+	t.Parallel()
+
+	browser := &callbackFiringBrowser{}
+	browser.fire = func() error {
+		opened, err := url.Parse(browser.openedURL)
+		if err != nil {
+			return err
+		}
+		query := opened.Query()
+		// Like a user accepting the browser's warning
+		client := &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}, //nolint:gosec // the self-signed callback certificate is under test
+		}
+		resp, err := client.Get(query.Get("redirect_uri") + "?code=auth-code&state=" + url.QueryEscape(query.Get("state"))) //nolint:noctx // test-local loopback request
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	fixture := newReadyConfig(t,
+		withBrowser(browser),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`),
+	)
+	flow := &AuthorizationCodeFlow{
+		Config:     fixture.config,
+		FlowConfig: &AuthorizationCodeFlowConfig{Scope: "openid", State: "state-123", CallbackURI: "https://127.0.0.1:0/callback"},
+	}
+	if err := flow.Run(t.Context()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(fixture.stderr.String(), "the browser will warn") {
+		t.Errorf("stderr = %q, want the certificate warning explained", fixture.stderr.String())
+	}
+	if len(fixture.requests) != 1 || !strings.HasPrefix(fixture.requests[0].Form.Get("redirect_uri"), "https://127.0.0.1:") {
+		t.Errorf("requests = %+v, want the code redeemed for the https redirect_uri", fixture.requests)
+	}
+}
+
+func TestCallbackCertificate(t *testing.T) {
+	t.Parallel()
+
+	callback := &url.URL{Scheme: "https", Host: "localhost:9555", Path: "/callback"}
+	dir := t.TempDir()
+	conf := &CallbackTLSConfig{
+		CertFile: filepath.Join(dir, "callback.pem"),
+		KeyFile:  filepath.Join(dir, "callback-key.pem"),
+		Write:    true,
+	}
+
+	fixture := newReadyConfig(t)
+	flow := &AuthorizationCodeFlow{Config: fixture.config, FlowConfig: &AuthorizationCodeFlowConfig{CallbackTLS: conf}}
+	written, err := flow.callbackCertificate(callback)
+	if err != nil {
+		t.Fatalf("callbackCertificate() error = %v", err)
+	}
+	if !strings.Contains(fixture.stderr.String(), "wrote a self-signed callback certificate to "+conf.CertFile) {
+		t.Errorf("stderr = %q, want the written certificate reported", fixture.stderr.String())
+	}
+	if info, err := os.Stat(conf.KeyFile); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file = %v, %v, want it private", info, err)
+	}
+
+	// The written certificate is reused on the next run
+	fixture = newReadyConfig(t)
+	flow = &AuthorizationCodeFlow{Config: fixture.config, FlowConfig: &AuthorizationCodeFlowConfig{CallbackTLS: conf}}
+	reused, err := flow.callbackCertificate(callback)
+	if err != nil {
+		t.Fatalf("callbackCertificate() error = %v", err)
+	}
+	if !bytes.Equal(reused.Certificate[0], written.Certificate[0]) {
+		t.Error("callbackCertificate() generated a new certificate, want the written one reused")
+	}
+	if fixture.stderr.Len() != 0 {
+		t.Errorf("stderr = %q, want no warning for a trusted certificate", fixture.stderr.String())
+	}
+
+	flow.FlowConfig.CallbackTLS = &CallbackTLSConfig{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: conf.KeyFile}
+	if _, err := flow.callbackCertificate(callback); err == nil || !strings.Contains(err.Error(), "failed to read the callback certificate") {
+		t.Errorf("callbackCertificate() error = %v, want a missing certificate reported", err)
+	}
+}
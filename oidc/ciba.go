@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// CIBAFlow runs the OpenID Connect Client-Initiated Backchannel Authentication
+// flow in poll mode: it asks the provider to authenticate the end-user named
+// by a hint on their own device, then polls the token endpoint until they
+// approve or deny the request.
+type CIBAFlow struct {
+	Config     *Config
+	FlowConfig *CIBAFlowConfig
+}
+
+type CIBAFlowConfig struct {
+	Scope          string
+	LoginHint      string
+	IDTokenHint    string
+	LoginHintToken string
+	BindingMessage string
+	ACRValues      string
+	// RequestedExpiry is the lifetime in seconds asked for the request, zero
+	// leaves it to the provider.
+	RequestedExpiry int
+	DPoP            bool
+}
+
+func (c *CIBAFlow) Run(ctx context.Context) error {
+	endpoint := c.Config.OIDC.BackchannelAuthenticationEndpoint
+	if endpoint == "" {
+		return errors.New("the authorization server advertises no backchannel authentication endpoint, set one with --backchannel-url")
+	}
+
+	client := c.Config.Runtime.Client
+	req := &httpclient.BackchannelAuthenticationRequest{
+		ClientID:        c.Config.OIDC.ClientID,
+		ClientSecret:    c.Config.OIDC.ClientSecret,
+		AuthMethod:      c.Config.OIDC.AuthMethod,
+		Scope:           c.FlowConfig.Scope,
+		LoginHint:       c.FlowConfig.LoginHint,
+		IDTokenHint:     c.FlowConfig.IDTokenHint,
+		LoginHintToken:  c.FlowConfig.LoginHintToken,
+		BindingMessage:  c.FlowConfig.BindingMessage,
+		ACRValues:       c.FlowConfig.ACRValues,
+		RequestedExpiry: c.FlowConfig.RequestedExpiry,
+	}
+
+	resp, err := client.ExecuteBackchannelAuthenticationRequest(ctx, endpoint, req)
+	if err != nil {
+		return fmt.Errorf("backchannel authentication request failed: %w", err)
+	}
+
+	cibaResp, err := httpclient.ParseBackchannelAuthenticationResponse(resp)
+	if err != nil {
+		return httpclient.WrapError(err, "backchannel authentication")
+	}
+
+	logger := c.Config.Runtime.Logger
+	logger.Printf("auth_req_id: %s, expires in %ds\n", cibaResp.AuthReqID, cibaResp.ExpiresIn)
+	if c.FlowConfig.BindingMessage != "" {
+		logger.Errorf("waiting for the user to approve the request with binding message %q\n", c.FlowConfig.BindingMessage)
+	} else {
+		logger.Errorln("waiting for the user to approve the request")
+	}
+
+	// Poll for token
+	tokenReq := httpclient.CreateCIBATokenRequest(
+		c.Config.OIDC.ClientID,
+		c.Config.OIDC.ClientSecret,
+		c.Config.OIDC.AuthMethod,
+		cibaResp.AuthReqID,
+	)
+
+	if c.FlowConfig.DPoP {
+		tokenReq.DPoP = c.Config.DPoPKeys.ProofFunc()
+	}
+
+	tokenResp, err := client.ExecutePollingTokenRequest(ctx, c.Config.OIDC.TokenEndpoint, tokenReq, cibaResp.Interval)
+	if err != nil {
+		return fmt.Errorf("polling token request failed: %w", err)
+	}
+	return c.Config.outputTokens(tokenResp, c.FlowConfig.DPoP)
+}
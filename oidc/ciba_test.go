@@ -0,0 +1,192 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+const cibaAuthBody = `{"auth_req_id":"auth-req-1","expires_in":120,"interval":2}`
+
+func TestCIBAFlowRun(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withRoute(testBackchannelEndpoint, http.StatusOK, cibaAuthBody),
+		withRouteResponses(testTokenEndpoint,
+			cannedResponse{status: http.StatusBadRequest, body: `{"error":"authorization_pending"}`},
+			cannedResponse{status: http.StatusBadRequest, body: `{"error":"slow_down"}`},
+			cannedResponse{status: http.StatusOK, body: `{"access_token":"abc123","token_type":"Bearer"}`},
+		),
+	)
+	var sleeps []time.Duration
+	fixture.config.Runtime.Client.SetSleepFunc(func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	})
+
+	flow := &CIBAFlow{
+		Config: fixture.config,
+		FlowConfig: &CIBAFlowConfig{
+			Scope:           "openid",
+			LoginHint:       "alice@example.com",
+			BindingMessage:  "W4SCT",
+			ACRValues:       "urn:example:mfa",
+			RequestedExpiry: 120,
+		},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 4 {
+		t.Fatalf("got %d emitted requests, want 4 (authentication + 3 token polls)", len(fixture.requests))
+	}
+
+	// Authentication request: the hint and binding message on the wire, with
+	// the client authenticating as it does at the token endpoint.
+	authReq := fixture.requests[0]
+	if authReq.URL != testBackchannelEndpoint || authReq.Method != http.MethodPost {
+		t.Errorf("authentication request = %s %s, want POST %s", authReq.Method, authReq.URL, testBackchannelEndpoint)
+	}
+	if got := authReq.Header.Get("Authorization"); got != basicAuthHeader() {
+		t.Errorf("authentication Authorization = %q, want %q", got, basicAuthHeader())
+	}
+	wantAuthForm := url.Values{
+		"scope":            {"openid"},
+		"login_hint":       {"alice@example.com"},
+		"binding_message":  {"W4SCT"},
+		"acr_values":       {"urn:example:mfa"},
+		"requested_expiry": {"120"},
+	}
+	if !reflect.DeepEqual(authReq.Form, wantAuthForm) {
+		t.Errorf("authentication form = %v, want %v", authReq.Form, wantAuthForm)
+	}
+
+	// Token polls: the CIBA grant naming the authentication request.
+	wantTokenForm := url.Values{
+		"grant_type":  {"urn:openid:params:grant-type:ciba"},
+		"auth_req_id": {"auth-req-1"},
+	}
+	for _, tokenReq := range fixture.requests[1:] {
+		if tokenReq.URL != testTokenEndpoint {
+			t.Errorf("token url = %q, want %q", tokenReq.URL, testTokenEndpoint)
+		}
+		if !reflect.DeepEqual(tokenReq.Form, wantTokenForm) {
+			t.Errorf("token form = %v, want %v", tokenReq.Form, wantTokenForm)
+		}
+	}
+
+	// The advertised interval paces polling, and slow_down lengthens it.
+	wantSleeps := []time.Duration{2 * time.Second, 7 * time.Second}
+	if !reflect.DeepEqual(sleeps, wantSleeps) {
+		t.Errorf("sleeps = %v, want %v", sleeps, wantSleeps)
+	}
+
+	wantOutput := `{
+  "access_token": "abc123",
+  "token_type": "Bearer"
+}
+`
+	if got := fixture.output.String(); got != wantOutput {
+		t.Errorf("output = %q, want %q", got, wantOutput)
+	}
+}
+
+func TestCIBAFlowRunDPoP(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withDPoPKeys(),
+		withRoute(testBackchannelEndpoint, http.StatusOK, cibaAuthBody),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"DPoP"}`),
+	)
+
+	flow := &CIBAFlow{
+		Config:     fixture.config,
+		FlowConfig: &CIBAFlowConfig{Scope: "openid", IDTokenHint: "id-token", DPoP: true},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want 2 (authentication + token)", len(fixture.requests))
+	}
+	if got := fixture.requests[0].Header.Get("DPoP"); got != "" {
+		t.Errorf("authentication DPoP = %q, want no proof", got)
+	}
+	if got := fixture.requests[0].Form.Get("id_token_hint"); got != "id-token" {
+		t.Errorf("id_token_hint = %q, want %q", got, "id-token")
+	}
+
+	// VerifyDPoPProof fails on an empty proof, so it doubles as the presence check.
+	cryptotest.VerifyDPoPProof(t, fixture.requests[1].Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
+}
+
+func TestCIBAFlowRunRejected(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		opts     []fixtureOption
+		wantReqs int
+	}{
+		{
+			name:     "unknown user",
+			opts:     []fixtureOption{withRoute(testBackchannelEndpoint, http.StatusBadRequest, `{"error":"unknown_user_id"}`)},
+			wantReqs: 1,
+		},
+		{
+			name: "denied by user",
+			opts: []fixtureOption{
+				withRoute(testBackchannelEndpoint, http.StatusOK, cibaAuthBody),
+				withResponse(http.StatusBadRequest, `{"error":"access_denied"}`),
+			},
+			wantReqs: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t, tt.opts...)
+			flow := &CIBAFlow{
+				Config:     fixture.config,
+				FlowConfig: &CIBAFlowConfig{Scope: "openid", LoginHint: "alice@example.com"},
+			}
+			err := flow.Run(context.Background())
+			if !errors.Is(err, httpclient.ErrOAuthError) {
+				t.Errorf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+			}
+			if len(fixture.requests) != tt.wantReqs {
+				t.Errorf("got %d emitted requests, want %d", len(fixture.requests), tt.wantReqs)
+			}
+			if fixture.output.Len() != 0 {
+				t.Errorf("output = %q, want none", fixture.output)
+			}
+		})
+	}
+}
+
+func TestCIBAFlowWithoutBackchannelEndpoint(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	fixture.config.OIDC.BackchannelAuthenticationEndpoint = ""
+	flow := &CIBAFlow{Config: fixture.config, FlowConfig: &CIBAFlowConfig{Scope: "openid", LoginHint: "alice@example.com"}}
+	if err := flow.Run(context.Background()); err == nil {
+		t.Fatal("Run() error = nil, want an error for the missing endpoint")
+	}
+	if len(fixture.requests) != 0 {
+		t.Errorf("got %d emitted requests, want none", len(fixture.requests))
+	}
+}
@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// requestedClaim is a claim of the claims request parameter (OpenID Connect
+// Core §5.5.1); a claim requested as null has none of the members.
+type requestedClaim struct {
+	Essential bool  `json:"essential"`
+	Value     any   `json:"value"`
+	Values    []any `json:"values"`
+}
+
+// reportRequestedClaims tells on stderr which claims of the claims request
+// came back: the id_token ones in the ID token, the userinfo ones from the
+// userinfo endpoint, called with the access token. The report is advisory,
+// so a claim that is missing, even an essential one, does not fail the flow.
+func (c *AuthorizationCodeFlow) reportRequestedClaims(ctx context.Context, tokenData map[string]any) {
+	if c.FlowConfig.Claims == "" {
+		return
+	}
+	logger := c.Config.Runtime.Logger
+	var request map[string]map[string]*requestedClaim
+	if err := json.Unmarshal([]byte(c.FlowConfig.Claims), &request); err != nil {
+		logger.Errorf("failed to read the claims request: %v\n", err)
+		return
+	}
+
+	if requested := request["id_token"]; len(requested) > 0 {
+		idToken, _ := tokenData["id_token"].(string)
+		claims := jwt.MapClaims{}
+		if idToken == "" {
+			logger.Errorf("no id_token was returned to check the requested id_token claims in\n")
+		} else if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+			logger.Errorf("failed to read the id_token claims: %v\n", err)
+		} else {
+			logger.Errorf("%s", formatClaimsReport("id_token", requested, claims))
+		}
+	}
+
+	if requested := request["userinfo"]; len(requested) > 0 {
+		userinfo, err := c.fetchUserinfo(ctx, tokenData)
+		if err != nil {
+			logger.Errorf("failed to check the requested userinfo claims: %v\n", err)
+		} else {
+			logger.Errorf("%s", formatClaimsReport("userinfo", requested, userinfo))
+		}
+	}
+}
+
+// fetchUserinfo calls the userinfo endpoint with the access token in
+// tokenData, presenting it under DPoP when the flow uses DPoP.
+func (c *AuthorizationCodeFlow) fetchUserinfo(ctx context.Context, tokenData map[string]any) (map[string]any, error) {
+	accessToken, _ := tokenData["access_token"].(string)
+	if accessToken == "" {
+		return nil, errors.New("no access token was returned to call the userinfo endpoint with")
+	}
+	if c.Config.OIDC.UserinfoEndpoint == "" {
+		return nil, errors.New("the authorization server advertises no userinfo_endpoint")
+	}
+	req := &httpclient.UserinfoRequest{AccessToken: accessToken}
+	if c.FlowConfig.DPoP {
+		req.DPoP = c.Config.DPoPKeys.AccessTokenProofFunc(accessToken)
+	}
+	resp, err := c.Config.Runtime.Client.ExecuteUserinfoRequest(ctx, c.Config.OIDC.UserinfoEndpoint, req)
+	if err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+	userinfo, err := httpclient.ParseUserinfoResponse(resp)
+	if err != nil {
+		return nil, httpclient.WrapError(err, "userinfo")
+	}
+	return userinfo, nil
+}
+
+// formatClaimsReport lists each requested claim with the value returned for
+// it, noting missing essential claims and values other than those requested.
+func formatClaimsReport(target string, requested map[string]*requestedClaim, returned map[string]any) string {
+	names := make([]string, 0, len(requested))
+	for name := range requested {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "requested %s claims:\n", target)
+	for _, name := range names {
+		want := requested[name]
+		if want == nil {
+			want = &requestedClaim{}
+		}
+		got, ok := returned[name]
+		switch {
+		case !ok && want.Essential:
+			fmt.Fprintf(&b, "  %s: not returned (essential)\n", name)
+		case !ok:
+			fmt.Fprintf(&b, "  %s: not returned\n", name)
+		case want.Value != nil && !reflect.DeepEqual(got, want.Value):
+			fmt.Fprintf(&b, "  %s: returned %s, requested %s\n", name, compactJSON(got), compactJSON(want.Value))
+		case want.Values != nil && !slices.ContainsFunc(want.Values, func(v any) bool { return reflect.DeepEqual(got, v) }):
+			fmt.Fprintf(&b, "  %s: returned %s, requested one of %s\n", name, compactJSON(got), compactJSON(want.Values))
+		default:
+			fmt.Fprintf(&b, "  %s: returned %s\n", name, compactJSON(got))
+		}
+	}
+	return b.String()
+}
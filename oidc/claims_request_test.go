@@ -0,0 +1,124 @@
+package oidc
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClaimsRequest = `{"id_token":{"acr":{"values":["urn:example:silver","urn:example:gold"]},"auth_time":{"essential":true}},"userinfo":{"email":{"essential":true},"phone_number":null}}`
+
+func TestAuthorizationCodeFlowRunClaimsRequest(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider(t)
+	idToken := provider.sign(t, jwt.MapClaims{
+		"iss": testIssuer,
+		"aud": testClientID,
+		"sub": "alice",
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"acr": "urn:example:bronze",
+	})
+	tests := []struct {
+		name     string
+		userinfo cannedResponse
+		want     []string
+	}{
+		{
+			name:     "userinfo returned",
+			userinfo: cannedResponse{status: http.StatusOK, body: `{"sub":"alice","email":"alice@example.com"}`},
+			want: []string{
+				"requested id_token claims:",
+				`  acr: returned "urn:example:bronze", requested one of ["urn:example:silver","urn:example:gold"]`,
+				"  auth_time: not returned (essential)",
+				"requested userinfo claims:",
+				`  email: returned "alice@example.com"`,
+				"  phone_number: not returned",
+			},
+		},
+		{
+			name:     "userinfo rejected",
+			userinfo: cannedResponse{status: http.StatusUnauthorized, header: http.Header{"Www-Authenticate": {`Bearer error="invalid_token"`}}},
+			want: []string{
+				"requested id_token claims:",
+				"failed to check the requested userinfo claims:",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture, opened, err := runAuthorizationCode(t,
+				&AuthorizationCodeFlowConfig{Scope: "openid", Claims: testClaimsRequest},
+				withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"abc123","token_type":"Bearer","id_token":"`+idToken+`"}`),
+				withRouteResponses(testUserinfoEndpoint, tt.userinfo),
+			)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := opened.Query().Get("claims"); got != testClaimsRequest {
+				t.Errorf("claims = %q, want %q", got, testClaimsRequest)
+			}
+			if len(fixture.requests) != 2 || fixture.requests[1].URL != testUserinfoEndpoint {
+				t.Fatalf("emitted %d requests, want the token request then the userinfo call", len(fixture.requests))
+			}
+			if got := fixture.requests[1].Header.Get("Authorization"); got != "Bearer abc123" {
+				t.Errorf("userinfo Authorization = %q, want the access token", got)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(fixture.stderr.String(), line) {
+					t.Errorf("stderr = %q, want it to contain %q", fixture.stderr.String(), line)
+				}
+			}
+			if strings.Contains(fixture.output.String(), "requested") {
+				t.Errorf("output = %q, want the report on stderr only", fixture.output.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunClaimsRequestWithoutIDToken(t *testing.T) {
+	t.Parallel()
+
+	fixture, _, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+		Scope:  "openid",
+		Claims: `{"id_token":{"acr":{"essential":true}}}`,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := "no id_token was returned"; !strings.Contains(fixture.stderr.String(), want) {
+		t.Errorf("stderr = %q, want it to contain %q", fixture.stderr.String(), want)
+	}
+	if len(fixture.requests) != 1 {
+		t.Errorf("emitted %d requests, want only the token request without userinfo claims", len(fixture.requests))
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestObjectClaims(t *testing.T) {
+	t.Parallel()
+
+	keyFile, key := writeSigningKey(t)
+	_, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+		Scope:         "openid",
+		Claims:        testClaimsRequest,
+		RequestObject: &RequestObjectConfig{KeyFile: keyFile},
+	}, withRoute(testUserinfoEndpoint, http.StatusOK, `{"sub":"alice"}`))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	claims := verifyRequestObject(t, opened.Query().Get("request"), key)
+	request, ok := claims["claims"].(map[string]any)
+	if !ok {
+		t.Fatalf("request object claims = %v, want an embedded object", claims["claims"])
+	}
+	if _, ok := request["userinfo"].(map[string]any); !ok {
+		t.Errorf("request object claims = %v, want the userinfo member", request)
+	}
+	if opened.Query().Has("claims") {
+		t.Error("authorization URL carries claims outside the request object")
+	}
+}
@@ -14,6 +14,11 @@ type ClientCredentialsFlow struct {
 
 type ClientCredentialsFlowConfig struct {
 	Scope string
+	// AuthorizationDetails is a JSON array of RFC 9396 authorization details.
+	AuthorizationDetails string
+	// Resources are RFC 8707 resource indicators of the APIs the token is
+	// for.
+	Resources []string
 }
 
 func (c *ClientCredentialsFlow) Run(ctx context.Context) error {
@@ -25,6 +30,8 @@ func (c *ClientCredentialsFlow) Run(ctx context.Context) error {
 		c.Config.OIDC.AuthMethod,
 		c.FlowConfig.Scope,
 	)
+	setAuthorizationDetails(req, c.FlowConfig.AuthorizationDetails)
+	req.Resources = c.FlowConfig.Resources
 
 	resp, err := client.ExecuteTokenRequest(ctx, c.Config.OIDC.TokenEndpoint, req)
 	if err != nil {
@@ -36,5 +43,6 @@ func (c *ClientCredentialsFlow) Run(ctx context.Context) error {
 		return httpclient.WrapError(err, "token")
 	}
 
+	showAuthorizationDetails(c.Config.Runtime.Logger, tokenData)
 	return c.Config.Runtime.Logger.OutputJSON(tokenData)
 }
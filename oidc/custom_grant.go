@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"context"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// CustomGrantFlow obtains tokens with an extension grant (RFC 6749 §4.5) the
+// CLI has no dedicated command for, sending the grant type and parameters as
+// given.
+type CustomGrantFlow struct {
+	Config     *Config
+	FlowConfig *CustomGrantFlowConfig
+}
+
+type CustomGrantFlowConfig struct {
+	GrantType  string
+	CustomArgs *httpclient.CustomArgs
+	DPoP       bool
+}
+
+func (c *CustomGrantFlow) Run(ctx context.Context) error {
+	req := httpclient.CreateCustomGrantTokenRequest(
+		c.FlowConfig.GrantType,
+		c.Config.OIDC.ClientID,
+		c.Config.OIDC.ClientSecret,
+		c.Config.OIDC.tokenAuthMethod(),
+		c.FlowConfig.CustomArgs,
+	)
+	return c.Config.requestTokens(ctx, req, c.FlowConfig.DPoP)
+}
@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+const testCustomGrantType = "urn:example:params:grant-type:otp"
+
+func TestCustomGrantFlowRun(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`))
+	flow := &CustomGrantFlow{
+		Config: fixture.config,
+		FlowConfig: &CustomGrantFlowConfig{
+			GrantType:  testCustomGrantType,
+			CustomArgs: &httpclient.CustomArgs{"otp": "123456", "username": "alice"},
+		},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if req.URL != testTokenEndpoint {
+		t.Errorf("url = %q, want %q", req.URL, testTokenEndpoint)
+	}
+	if got := req.Header.Get("Authorization"); got != basicAuthHeader() {
+		t.Errorf("Authorization = %q, want %q", got, basicAuthHeader())
+	}
+	wantForm := url.Values{
+		"grant_type": {testCustomGrantType},
+		"otp":        {"123456"},
+		"username":   {"alice"},
+	}
+	if !reflect.DeepEqual(req.Form, wantForm) {
+		t.Errorf("form = %v, want %v", req.Form, wantForm)
+	}
+
+	wantOutput := `{
+  "access_token": "abc123",
+  "token_type": "Bearer"
+}
+`
+	if got := fixture.output.String(); got != wantOutput {
+		t.Errorf("output = %q, want %q", got, wantOutput)
+	}
+}
+
+// TestCustomGrantFlowRunGrantTypeWins pins that a grant_type parameter cannot
+// override the grant type the flow was configured with.
+func TestCustomGrantFlowRunGrantTypeWins(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withPublicClient())
+	flow := &CustomGrantFlow{
+		Config: fixture.config,
+		FlowConfig: &CustomGrantFlowConfig{
+			GrantType:  testCustomGrantType,
+			CustomArgs: &httpclient.CustomArgs{"grant_type": "password"},
+		},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if got := req.Form["grant_type"]; !reflect.DeepEqual(got, []string{testCustomGrantType}) {
+		t.Errorf("grant_type = %v, want only %q", got, testCustomGrantType)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want no header for a public client", got)
+	}
+	if got := req.Form.Get("client_id"); got != testClientID {
+		t.Errorf("client_id = %q, want %q in the body", got, testClientID)
+	}
+}
+
+func TestCustomGrantFlowRunDPoP(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys(),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"DPoP"}`))
+	flow := &CustomGrantFlow{
+		Config:     fixture.config,
+		FlowConfig: &CustomGrantFlowConfig{GrantType: testCustomGrantType, DPoP: true},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	// VerifyDPoPProof fails on an empty proof, so it doubles as the presence check.
+	cryptotest.VerifyDPoPProof(t, req.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
+}
+
+func TestCustomGrantFlowRunRejected(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusBadRequest, `{"error":"unsupported_grant_type"}`))
+	flow := &CustomGrantFlow{Config: fixture.config, FlowConfig: &CustomGrantFlowConfig{GrantType: testCustomGrantType}}
+
+	err := flow.Run(context.Background())
+	if !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Errorf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want none", fixture.output)
+	}
+}
@@ -17,6 +17,9 @@ type DeviceFlowConfig struct {
 	Scope string
 	DPoP  bool
 	PKCE  bool
+	// Resources are RFC 8707 resource indicators of the APIs the token is
+	// for.
+	Resources []string
 }
 
 func (c *DeviceFlow) Run(ctx context.Context) error {
@@ -27,8 +30,9 @@ func (c *DeviceFlow) Run(ctx context.Context) error {
 	}
 
 	req := &httpclient.DeviceAuthorizationRequest{
-		ClientID: c.Config.OIDC.ClientID,
-		Scope:    c.FlowConfig.Scope,
+		ClientID:  c.Config.OIDC.ClientID,
+		Scope:     c.FlowConfig.Scope,
+		Resources: c.FlowConfig.Resources,
 	}
 
 	if codeVerifier != "" {
@@ -69,6 +73,7 @@ func (c *DeviceFlow) Run(ctx context.Context) error {
 		deviceAuthResp.DeviceCode,
 		codeVerifier,
 	)
+	tokenReq.Resources = c.FlowConfig.Resources
 
 	if c.FlowConfig.DPoP {
 		tokenReq.DPoP = c.Config.DPoPKeys.ProofFunc()
@@ -82,6 +87,11 @@ func (c *DeviceFlow) Run(ctx context.Context) error {
 	if err != nil {
 		return httpclient.WrapError(err, "token")
 	}
+	if c.FlowConfig.DPoP {
+		if err := c.Config.DPoPKeys.checkAccessTokenBinding(tokenData); err != nil {
+			return err
+		}
+	}
 
 	return logger.OutputJSON(tokenData)
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/jentz/oidc-cli/crypto/cryptotest"
@@ -228,3 +229,30 @@ func TestDeviceFlowRunDPoP(t *testing.T) {
 	// VerifyDPoPProof fails on an empty proof, so it doubles as the presence check.
 	cryptotest.VerifyDPoPProof(t, tokenReq.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
 }
+
+func TestDeviceFlowRunResources(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withBrowser(&recordingBrowser{}),
+		withRoute(testDeviceAuthEndpoint, http.StatusOK, `{"device_code":"dev-code-1","user_code":"WDJB-MJHT","verification_uri":"https://op.example.com/device","expires_in":1800}`),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`),
+	)
+	resources := []string{"https://api.example.com", "https://other.example.com"}
+	flow := &DeviceFlow{
+		Config:     fixture.config,
+		FlowConfig: &DeviceFlowConfig{Scope: "openid", Resources: resources},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want 2 (device-auth + token)", len(fixture.requests))
+	}
+	for i, name := range []string{"device-auth", "token"} {
+		if got := fixture.requests[i].Form["resource"]; !slices.Equal(got, resources) {
+			t.Errorf("%s resource = %q, want %q", name, got, resources)
+		}
+	}
+}
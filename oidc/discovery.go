@@ -22,7 +22,10 @@ type DiscoveryConfiguration struct {
 	UserinfoEndpoint                   string   `json:"userinfo_endpoint,omitempty"`
 	RevocationEndpoint                 string   `json:"revocation_endpoint,omitempty"`
 	DeviceAuthorizationEndpoint        string   `json:"device_authorization_endpoint,omitempty"`
+	BackchannelAuthenticationEndpoint  string   `json:"backchannel_authentication_endpoint,omitempty"`
+	EndSessionEndpoint                 string   `json:"end_session_endpoint,omitempty"`
 	JwksURI                            string   `json:"jwks_uri,omitempty"`
+	RegistrationEndpoint               string   `json:"registration_endpoint,omitempty"`
 	TokenEndpointAuthMethods           []string `json:"token_endpoint_auth_methods_supported,omitempty"`
 }
 
@@ -21,7 +21,10 @@ const defaultBody = `{
     "issuer": "https://example.com",
     "authorization_endpoint": "https://example.com/auth",
     "token_endpoint": "https://example.com/token",
-    "jwks_uri": "https://example.com/jwks"
+    "jwks_uri": "https://example.com/jwks",
+    "registration_endpoint": "https://example.com/register",
+    "backchannel_authentication_endpoint": "https://example.com/bc-authorize",
+    "end_session_endpoint": "https://example.com/logout"
 }`
 
 func TestClientDiscover(t *testing.T) {
@@ -44,10 +47,13 @@ func TestClientDiscover(t *testing.T) {
 				Body:       io.NopCloser(bytes.NewBufferString(defaultBody)),
 			},
 			want: &DiscoveryConfiguration{
-				Issuer:                "https://example.com",
-				AuthorizationEndpoint: "https://example.com/auth",
-				TokenEndpoint:         "https://example.com/token",
-				JwksURI:               "https://example.com/jwks",
+				Issuer:                            "https://example.com",
+				AuthorizationEndpoint:             "https://example.com/auth",
+				TokenEndpoint:                     "https://example.com/token",
+				JwksURI:                           "https://example.com/jwks",
+				RegistrationEndpoint:              "https://example.com/register",
+				BackchannelAuthenticationEndpoint: "https://example.com/bc-authorize",
+				EndSessionEndpoint:                "https://example.com/logout",
 			},
 			wantURL: "https://example.com/.well-known/openid-configuration",
 		},
@@ -62,10 +68,13 @@ func TestClientDiscover(t *testing.T) {
 				Body:       io.NopCloser(bytes.NewBufferString(defaultBody)),
 			},
 			want: &DiscoveryConfiguration{
-				Issuer:                "https://example.com",
-				AuthorizationEndpoint: "https://example.com/auth",
-				TokenEndpoint:         "https://example.com/token",
-				JwksURI:               "https://example.com/jwks",
+				Issuer:                            "https://example.com",
+				AuthorizationEndpoint:             "https://example.com/auth",
+				TokenEndpoint:                     "https://example.com/token",
+				JwksURI:                           "https://example.com/jwks",
+				RegistrationEndpoint:              "https://example.com/register",
+				BackchannelAuthenticationEndpoint: "https://example.com/bc-authorize",
+				EndSessionEndpoint:                "https://example.com/logout",
 			},
 			wantURL: "https://example.com/.well-known/custom",
 		},
@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/log"
+)
+
+// DPoPProofFlow prints a single DPoP proof, for use with tools that cannot
+// mint one themselves.
+type DPoPProofFlow struct {
+	Config     *Config
+	FlowConfig *DPoPProofFlowConfig
+}
+
+type DPoPProofFlowConfig struct {
+	Method      string
+	URL         string
+	AccessToken string
+	Nonce       string
+}
+
+func (c *DPoPProofFlow) Run(_ context.Context) error {
+	proofFunc := c.Config.DPoPKeys.AccessTokenProofFunc(c.FlowConfig.AccessToken)
+	proof, err := proofFunc(c.FlowConfig.Method, c.FlowConfig.URL, c.FlowConfig.Nonce)
+	if err != nil {
+		return fmt.Errorf("failed to generate DPoP proof: %w", err)
+	}
+	c.Config.Runtime.Logger.Outputln(proof)
+	return nil
+}
+
+// DPoPRequestFlow calls a resource server with a DPoP-bound access token,
+// answering a nonce challenge with one retry, and prints the response body.
+type DPoPRequestFlow struct {
+	Config     *Config
+	FlowConfig *DPoPRequestFlowConfig
+}
+
+type DPoPRequestFlowConfig struct {
+	Method      string
+	URL         string
+	AccessToken string
+	// Nonce seeds the first proof; later proofs carry whatever nonce the
+	// server hands out.
+	Nonce   string
+	Headers map[string]string
+	Body    []byte
+}
+
+func (c *DPoPRequestFlow) Run(ctx context.Context) error {
+	client := c.Config.Runtime.Client
+	proofFunc := c.Config.DPoPKeys.AccessTokenProofFunc(c.FlowConfig.AccessToken)
+
+	req := &httpclient.ResourceRequest{
+		Method:      c.FlowConfig.Method,
+		URL:         c.FlowConfig.URL,
+		AccessToken: c.FlowConfig.AccessToken,
+		Headers:     c.FlowConfig.Headers,
+		Body:        c.FlowConfig.Body,
+		DPoP: func(method, requestURL, nonce string) (string, error) {
+			if nonce == "" {
+				nonce = c.FlowConfig.Nonce
+			}
+			return proofFunc(method, requestURL, nonce)
+		},
+	}
+
+	resp, err := client.ExecuteResourceRequest(ctx, req)
+	if err != nil {
+		return fmt.Errorf("resource request failed: %w", err)
+	}
+	return outputResourceResponse(c.Config.Runtime.Logger, req, resp)
+}
+
+// outputResourceResponse logs the status of a resource call and prints the
+// response body, or returns the error a rejected call carries.
+func outputResourceResponse(logger *log.Logger, req *httpclient.ResourceRequest, resp *httpclient.Response) error {
+	logger.Printf("%s %s: %d\n", req.Method, req.URL, resp.StatusCode)
+
+	body, err := httpclient.ParseResourceResponse(resp)
+	if err != nil {
+		return httpclient.WrapError(err, "resource")
+	}
+	logger.Outputf("%s\n", body)
+	return nil
+}
@@ -1,12 +1,18 @@
 package oidc
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/jentz/oidc-cli/crypto"
 	"github.com/jentz/oidc-cli/httpclient"
 )
 
+// ErrDPoPBindingMismatch is returned when a token's cnf.jkt confirmation names
+// a key other than the loaded DPoP public key.
+var ErrDPoPBindingMismatch = errors.New("token is not bound to the DPoP key")
+
 // DPoPKeys owns the DPoP keypair: the files it loads from, the parsed keys, and
 // the proof function that signs each token request with them. The zero value
 // carries no keys, so its proof function errors rather than emitting an
@@ -51,11 +57,71 @@ func (k *DPoPKeys) Load() error {
 // request, signed with the loaded keypair. It errors when a key is absent
 // rather than emitting an unsigned proof.
 func (k DPoPKeys) ProofFunc() httpclient.DPoPProofFunc {
-	return func(method, url string) (string, error) {
-		proof, err := crypto.NewDPoPProof(k.Public, k.Private, method, url)
+	return k.AccessTokenProofFunc("")
+}
+
+// AccessTokenProofFunc is ProofFunc for requests that present accessToken, such
+// as userinfo and resource server calls: each proof carries the token's ath
+// hash so the server can bind the two (RFC 9449 §7).
+func (k DPoPKeys) AccessTokenProofFunc(accessToken string) httpclient.DPoPProofFunc {
+	return func(method, url, nonce string) (string, error) {
+		proof, err := crypto.NewDPoPProofBuilder().
+			PublicKey(k.Public).
+			PrivateKey(k.Private).
+			Method(method).
+			URL(url).
+			Nonce(nonce).
+			AccessToken(accessToken).
+			Build()
 		if err != nil {
 			return "", err
 		}
 		return proof.String(), nil
 	}
 }
+
+// Thumbprint returns the RFC 7638 thumbprint of the loaded public key, the
+// value sent as dpop_jkt and expected in a bound token's cnf.jkt claim.
+func (k DPoPKeys) Thumbprint() (string, error) {
+	if k.Public == nil {
+		return "", errors.New("no DPoP public key loaded")
+	}
+	return crypto.JWKThumbprint(k.Public)
+}
+
+// checkAccessTokenBinding compares the cnf.jkt claim of a JWT access token in
+// tokenData against the loaded key. Opaque access tokens cannot be inspected
+// locally, so they pass; introspection is the way to check those.
+func (k DPoPKeys) checkAccessTokenBinding(tokenData map[string]any) error {
+	accessToken, ok := tokenData["access_token"].(string)
+	if !ok || accessToken == "" {
+		return nil
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(accessToken, claims); err != nil {
+		return nil //nolint:nilerr // an opaque (non-JWT) access token carries no claims to compare
+	}
+	return k.checkConfirmation(claims)
+}
+
+// checkConfirmation compares the cnf.jkt member of a claim set, such as a JWT
+// payload or an introspection response, against the loaded key. A claim set
+// without cnf.jkt is not DPoP-bound by thumbprint and passes.
+func (k DPoPKeys) checkConfirmation(claims map[string]any) error {
+	cnf, ok := claims["cnf"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	jkt, ok := cnf["jkt"].(string)
+	if !ok {
+		return nil
+	}
+	thumbprint, err := k.Thumbprint()
+	if err != nil {
+		return fmt.Errorf("failed to compute DPoP key thumbprint: %w", err)
+	}
+	if jkt != thumbprint {
+		return fmt.Errorf("%w: cnf.jkt %q does not match key thumbprint %q", ErrDPoPBindingMismatch, jkt, thumbprint)
+	}
+	return nil
+}
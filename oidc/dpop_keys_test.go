@@ -6,11 +6,14 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
 	"github.com/jentz/oidc-cli/crypto/cryptotest"
 )
 
@@ -31,7 +34,7 @@ func TestDPoPKeysProofFuncSignsWithLoadedKeys(t *testing.T) {
 		url    = "https://op.example.com/token"
 	)
 
-	proof, err := keys.ProofFunc()(method, url)
+	proof, err := keys.ProofFunc()(method, url, "")
 	if err != nil {
 		t.Fatalf("ProofFunc returned error: %v", err)
 	}
@@ -80,7 +83,7 @@ func TestDPoPKeysProofFuncWithoutKeys(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			proof, err := tt.keys.ProofFunc()("POST", "https://op.example.com/token")
+			proof, err := tt.keys.ProofFunc()("POST", "https://op.example.com/token", "")
 			if err == nil {
 				t.Fatal("ProofFunc error = nil, want error for absent key")
 			}
@@ -147,7 +150,7 @@ func TestDPoPKeysLoadParsesUsableKeypair(t *testing.T) {
 		method = "POST"
 		url    = "https://op.example.com/token"
 	)
-	proof, err := keys.ProofFunc()(method, url)
+	proof, err := keys.ProofFunc()(method, url, "")
 	if err != nil {
 		t.Fatalf("ProofFunc returned error: %v", err)
 	}
@@ -212,3 +215,103 @@ func TestDPoPKeysLoadNoFiles(t *testing.T) {
 		t.Errorf("keys = %+v, want both Private and Public nil", keys)
 	}
 }
+
+// unsignedJWT renders claims as an alg=none JWT; the binding check reads the
+// payload without verifying it, so a signature would add nothing.
+func unsignedJWT(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("signing unsigned JWT: %v", err)
+	}
+	return token
+}
+
+// TestDPoPKeysCheckAccessTokenBinding pins that only a JWT access token whose
+// cnf.jkt names a different key is rejected; opaque tokens and JWTs without a
+// confirmation cannot be checked locally and pass.
+func TestDPoPKeysCheckAccessTokenBinding(t *testing.T) {
+	t.Parallel()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	keys := DPoPKeys{Public: &priv.PublicKey, Private: priv}
+	jkt, err := keys.Thumbprint()
+	if err != nil {
+		t.Fatalf("Thumbprint returned error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		accessToken any
+		wantErr     bool
+	}{
+		{"bound to the key", unsignedJWT(t, jwt.MapClaims{"cnf": map[string]any{"jkt": jkt}}), false},
+		{"bound to another key", unsignedJWT(t, jwt.MapClaims{"cnf": map[string]any{"jkt": "other"}}), true},
+		{"jwt without cnf", unsignedJWT(t, jwt.MapClaims{"sub": "alice"}), false},
+		{"opaque token", "opaque-access-token", false},
+		{"no access token", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tokenData := map[string]any{}
+			if tt.accessToken != nil {
+				tokenData["access_token"] = tt.accessToken
+			}
+			err := keys.checkAccessTokenBinding(tokenData)
+			if tt.wantErr && !errors.Is(err, ErrDPoPBindingMismatch) {
+				t.Errorf("checkAccessTokenBinding error = %v, want ErrDPoPBindingMismatch", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkAccessTokenBinding error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// TestDPoPKeysThumbprintWithoutKey pins that the thumbprint of an unloaded
+// keypair errors instead of hashing an empty key.
+func TestDPoPKeysThumbprintWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (DPoPKeys{}).Thumbprint(); err == nil {
+		t.Error("Thumbprint error = nil, want error without a public key")
+	}
+}
+
+// TestDPoPKeysAccessTokenProofFunc checks a resource proof carries the access
+// token hash and the server nonce alongside the standard claims.
+func TestDPoPKeysAccessTokenProofFunc(t *testing.T) {
+	t.Parallel()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	keys := DPoPKeys{Public: &priv.PublicKey, Private: priv}
+
+	const (
+		method = "GET"
+		url    = "https://rs.example.com/resource"
+	)
+	proof, err := keys.AccessTokenProofFunc("access-123")(method, url, "rs-nonce")
+	if err != nil {
+		t.Fatalf("AccessTokenProofFunc returned error: %v", err)
+	}
+	cryptotest.VerifyDPoPProof(t, proof, &priv.PublicKey, method, url)
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(proof, claims); err != nil {
+		t.Fatalf("parsing proof: %v", err)
+	}
+	if got, want := claims["ath"], crypto.AccessTokenHash("access-123"); got != want {
+		t.Errorf("ath = %v, want %v", got, want)
+	}
+	if got := claims["nonce"]; got != "rs-nonce" {
+		t.Errorf("nonce = %v, want rs-nonce", got)
+	}
+}
@@ -0,0 +1,137 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+const testResourceURL = "https://api.example.com/items"
+
+// proofClaims returns the unverified claims of a DPoP proof; the signature is
+// checked separately with cryptotest.VerifyDPoPProof.
+func proofClaims(t *testing.T, proof string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(proof, claims); err != nil {
+		t.Fatalf("parsing proof: %v", err)
+	}
+	return claims
+}
+
+func TestDPoPProofFlowPrintsBoundProof(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys())
+	flow := &DPoPProofFlow{
+		Config: fixture.config,
+		FlowConfig: &DPoPProofFlowConfig{
+			Method:      http.MethodGet,
+			URL:         testResourceURL,
+			AccessToken: "access-123",
+			Nonce:       "rs-nonce",
+		},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 0 {
+		t.Errorf("got %d emitted requests, want none", len(fixture.requests))
+	}
+	proof := strings.TrimSpace(fixture.output.String())
+	cryptotest.VerifyDPoPProof(t, proof, fixture.dpopPublicKey, http.MethodGet, testResourceURL)
+	claims := proofClaims(t, proof)
+	if got, want := claims["ath"], crypto.AccessTokenHash("access-123"); got != want {
+		t.Errorf("ath = %v, want %v", got, want)
+	}
+	if got := claims["nonce"]; got != "rs-nonce" {
+		t.Errorf("nonce = %v, want rs-nonce", got)
+	}
+}
+
+func TestDPoPProofFlowWithoutKeys(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	flow := &DPoPProofFlow{
+		Config:     fixture.config,
+		FlowConfig: &DPoPProofFlowConfig{Method: http.MethodGet, URL: testResourceURL},
+	}
+	if err := flow.Run(context.Background()); err == nil {
+		t.Fatal("Run() error = nil, want missing key error")
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want empty", fixture.output.String())
+	}
+}
+
+func TestDPoPRequestFlowSendsBoundToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys(), withResponse(http.StatusOK, `{"items":[]}`))
+	flow := &DPoPRequestFlow{
+		Config: fixture.config,
+		FlowConfig: &DPoPRequestFlowConfig{
+			Method:      http.MethodGet,
+			URL:         testResourceURL,
+			AccessToken: "access-123",
+			Nonce:       "seed-nonce",
+			Headers:     map[string]string{"Accept": "application/json"},
+		},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if req.Method != http.MethodGet || req.URL != testResourceURL {
+		t.Errorf("request = %s %s, want GET %s", req.Method, req.URL, testResourceURL)
+	}
+	if got := req.Header.Get("Authorization"); got != "DPoP access-123" {
+		t.Errorf("Authorization = %q, want %q", got, "DPoP access-123")
+	}
+	if got := req.Header.Get("Accept"); got != "application/json" {
+		t.Errorf("Accept = %q, want application/json", got)
+	}
+	proof := req.Header.Get("DPoP")
+	cryptotest.VerifyDPoPProof(t, proof, fixture.dpopPublicKey, http.MethodGet, testResourceURL)
+	claims := proofClaims(t, proof)
+	if got, want := claims["ath"], crypto.AccessTokenHash("access-123"); got != want {
+		t.Errorf("ath = %v, want %v", got, want)
+	}
+	if got := claims["nonce"]; got != "seed-nonce" {
+		t.Errorf("nonce = %v, want seed-nonce", got)
+	}
+	if got := fixture.output.String(); got != "{\"items\":[]}\n" {
+		t.Errorf("output = %q, want the response body", got)
+	}
+}
+
+func TestDPoPRequestFlowRejected(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys(), withResponse(http.StatusForbidden, "forbidden"))
+	flow := &DPoPRequestFlow{
+		Config: fixture.config,
+		FlowConfig: &DPoPRequestFlowConfig{
+			Method:      http.MethodGet,
+			URL:         testResourceURL,
+			AccessToken: "access-123",
+		},
+	}
+	err := flow.Run(context.Background())
+	if !errors.Is(err, httpclient.ErrHTTPFailure) {
+		t.Fatalf("Run() error = %v, want %v", err, httpclient.ErrHTTPFailure)
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want empty", fixture.output.String())
+	}
+}
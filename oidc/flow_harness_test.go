@@ -23,23 +23,29 @@ import (
 // config internals or unexported helpers, so they survive config refactors.
 
 const (
+	testIssuer                = "https://op.example.com"
 	testClientID              = "test-client"
 	testClientSecret          = "test-secret"
 	testAuthorizationEndpoint = "https://op.example.com/authorize"
 	testPAREndpoint           = "https://op.example.com/par"
 	testDeviceAuthEndpoint    = "https://op.example.com/device_authorization"
 	testTokenEndpoint         = "https://op.example.com/token"
+	testBackchannelEndpoint   = "https://op.example.com/bc-authorize"
 	testIntrospectionEndpoint = "https://op.example.com/introspect"
+	testEndSessionEndpoint    = "https://op.example.com/logout"
+	testJWKSEndpoint          = "https://op.example.com/jwks"
+	testUserinfoEndpoint      = "https://op.example.com/userinfo"
 )
 
 // capturedRequest records the parts of an emitted request that a resource
 // server would see on the wire. The decoded Form is populated for the
-// form-encoded bodies the flows send.
+// form-encoded bodies the flows send; Body holds the raw payload of any other.
 type capturedRequest struct {
 	Method string
 	URL    string
 	Header http.Header
 	Form   url.Values
+	Body   []byte
 }
 
 // flowFixture bundles a ready Config with the request capture and output buffer
@@ -51,29 +57,37 @@ type flowFixture struct {
 	config   *Config
 	requests []*capturedRequest
 	output   *bytes.Buffer
+	// stderr holds what the flow reports beside its output, such as
+	// warnings and summaries.
+	stderr *bytes.Buffer
 
 	// dpopPublicKey is the key the fixture's DPoP proofs are bound to, set only
 	// when withDPoPKeys is used, so tests can verify the emitted proof.
 	dpopPublicKey any
 }
 
-// cannedResponse is the status and body the transport replies with for a route.
+// cannedResponse is the status, body and any headers the transport replies
+// with for a route.
 type cannedResponse struct {
 	status int
 	body   string
+	header http.Header
 }
 
 type fixtureSettings struct {
-	clientID       string
-	clientSecret   string
-	authMethod     httpclient.AuthMethod
-	dpopKeys       bool
-	responseStatus int
-	responseBody   string
+	clientID     string
+	clientSecret string
+	authMethod   httpclient.AuthMethod
+	// authMethodDefaulted marks authMethod as picked by discovery.
+	authMethodDefaulted bool
+	dpopKeys            bool
+	responseStatus      int
+	responseBody        string
 	// routes overrides the default response per request URL, letting an
 	// interactive flow return a request_uri from the PAR endpoint and a token
-	// from the token endpoint within one Run.
-	routes  map[string]cannedResponse
+	// from the token endpoint within one Run. Successive requests to a route
+	// take its responses in turn, the last one repeating.
+	routes  map[string][]cannedResponse
 	browser webflow.Browser
 	listen  func(network, addr string) (net.Listener, error)
 }
@@ -83,11 +97,17 @@ type fixtureOption func(*fixtureSettings)
 // withRoute sets the canned response the transport returns for a specific
 // request URL, overriding the default for that endpoint only.
 func withRoute(rawURL string, status int, body string) fixtureOption {
+	return withRouteResponses(rawURL, cannedResponse{status: status, body: body})
+}
+
+// withRouteResponses sets the responses successive requests to a URL receive,
+// letting a test reject a first attempt and accept the retry.
+func withRouteResponses(rawURL string, responses ...cannedResponse) fixtureOption {
 	return func(s *fixtureSettings) {
 		if s.routes == nil {
-			s.routes = make(map[string]cannedResponse)
+			s.routes = make(map[string][]cannedResponse)
 		}
-		s.routes[rawURL] = cannedResponse{status: status, body: body}
+		s.routes[rawURL] = responses
 	}
 }
 
@@ -109,12 +129,14 @@ func withAuthMethod(m httpclient.AuthMethod) fixtureOption {
 }
 
 // withPublicClient models a public client: no secret, with the auth method
-// left at Basic so a flow's PKCE setup is what flips it to None. It exercises
-// the no-secret fallback that confidential-client cases never reach.
+// left at the Basic default discovery picked, so a flow's PKCE setup or
+// no-secret fallback is what flips it to None. It exercises the fallback that
+// confidential-client cases never reach.
 func withPublicClient() fixtureOption {
 	return func(s *fixtureSettings) {
 		s.clientSecret = ""
 		s.authMethod = httpclient.AuthMethodBasic
+		s.authMethodDefaulted = true
 	}
 }
 
@@ -151,22 +173,30 @@ func newReadyConfig(t *testing.T, opts ...fixtureOption) *flowFixture {
 		opt(settings)
 	}
 
-	fixture := &flowFixture{output: &bytes.Buffer{}}
+	fixture := &flowFixture{output: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
 
 	transport := mockTransport(func(req *http.Request) (*http.Response, error) {
 		fixture.requests = append(fixture.requests, captureRequest(t, req))
-		status, body := settings.responseStatus, settings.responseBody
-		if route, ok := settings.routes[req.URL.String()]; ok {
-			status, body = route.status, route.body
+		canned := cannedResponse{status: settings.responseStatus, body: settings.responseBody}
+		if route := settings.routes[req.URL.String()]; len(route) > 0 {
+			canned = route[0]
+			if len(route) > 1 {
+				settings.routes[req.URL.String()] = route[1:]
+			}
+		}
+		header := make(http.Header)
+		if canned.header != nil {
+			header = canned.header.Clone()
 		}
 		return &http.Response{
-			StatusCode: status,
-			Body:       io.NopCloser(bytes.NewBufferString(body)),
-			Header:     make(http.Header),
+			StatusCode: canned.status,
+			Body:       io.NopCloser(bytes.NewBufferString(canned.body)),
+			Header:     header,
+			Request:    req,
 		}, nil
 	})
 
-	logger := log.New(log.WithOutput(fixture.output, io.Discard))
+	logger := log.New(log.WithOutput(fixture.output, fixture.stderr))
 	// The client keeps its own (discard) logger so the output buffer captures
 	// the flow's output alone, independent of any client-side logging.
 	client := httpclient.NewClient(&httpclient.Config{
@@ -180,11 +210,15 @@ func newReadyConfig(t *testing.T, opts ...fixtureOption) *flowFixture {
 			ClientID:                           settings.clientID,
 			ClientSecret:                       settings.clientSecret,
 			AuthMethod:                         settings.authMethod,
+			authMethodDefaulted:                settings.authMethodDefaulted,
 			AuthorizationEndpoint:              testAuthorizationEndpoint,
 			PushedAuthorizationRequestEndpoint: testPAREndpoint,
 			DeviceAuthorizationEndpoint:        testDeviceAuthEndpoint,
+			BackchannelAuthenticationEndpoint:  testBackchannelEndpoint,
 			TokenEndpoint:                      testTokenEndpoint,
 			IntrospectionEndpoint:              testIntrospectionEndpoint,
+			EndSessionEndpoint:                 testEndSessionEndpoint,
+			UserinfoEndpoint:                   testUserinfoEndpoint,
 		},
 		Runtime: Runtime{
 			Client: client,
@@ -223,8 +257,9 @@ func captureRequest(t *testing.T, req *http.Request) *capturedRequest {
 		if err != nil {
 			t.Fatalf("reading request body: %v", err)
 		}
+		captured.Body = body
 		form, err := url.ParseQuery(string(body))
-		if err != nil {
+		if err != nil && req.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
 			t.Fatalf("parsing request form: %v", err)
 		}
 		captured.Form = form
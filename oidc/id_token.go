@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// hasResponseType reports whether the response type includes part, such as
+// id_token; an empty response type is code.
+func (c *AuthorizationCodeFlowConfig) hasResponseType(part string) bool {
+	rt := c.ResponseType
+	if rt == "" {
+		rt = "code"
+	}
+	return slices.Contains(strings.Fields(rt), part)
+}
+
+// fragmentResponse reports whether the response arrives in the URL fragment,
+// either by request or as the default for response types other than code
+// (OAuth 2.0 Multiple Response Type Encoding Practices §5, JARM §2.3.4).
+func (c *AuthorizationCodeFlowConfig) fragmentResponse() bool {
+	switch c.ResponseMode {
+	case "fragment", "fragment.jwt":
+		return true
+	case "", "jwt":
+		return c.ResponseType != "" && c.ResponseType != "code"
+	default:
+		return false
+	}
+}
+
+// verifyFrontChannelIDToken validates an ID token returned from the
+// authorization endpoint (OpenID Connect Core §3.2.2.11 and §3.3.2.12): its
+// signature against the provider's JWKS, the issuer, the client in the
+// audience, exp, the nonce sent, and the c_hash and at_hash binding it to the
+// code and access token returned alongside it.
+func (c *AuthorizationCodeFlow) verifyFrontChannelIDToken(ctx context.Context, resp *httpclient.AuthorizationCodeResponse, nonce string) error {
+	keys, err := c.Config.OIDC.fetchJWKS(ctx, c.Config.Runtime.Client)
+	if err != nil {
+		return err
+	}
+	claims, err := crypto.VerifyJWT(resp.IDToken, keys,
+		jwt.WithIssuer(c.Config.OIDC.IssuerURL),
+		jwt.WithAudience(c.Config.OIDC.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid id_token: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return fmt.Errorf("invalid id_token: nonce %q does not match the nonce sent", got)
+	}
+
+	// VerifyJWT has checked the signature, so the header's alg is the one
+	// the hashes are computed with.
+	parsed, _, err := jwt.NewParser().ParseUnverified(resp.IDToken, jwt.MapClaims{})
+	if err != nil {
+		return fmt.Errorf("invalid id_token: %w", err)
+	}
+	alg := parsed.Method.Alg()
+	if resp.Code != "" {
+		if err := checkTokenHash(claims, "c_hash", alg, resp.Code); err != nil {
+			return err
+		}
+	}
+	if resp.AccessToken != "" {
+		if err := checkTokenHash(claims, "at_hash", alg, resp.AccessToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTokenHash compares the claim, which is required whenever the value is
+// returned alongside the ID token, to the value's hash.
+func checkTokenHash(claims jwt.MapClaims, claim, alg, value string) error {
+	got, ok := claims[claim].(string)
+	if !ok {
+		return fmt.Errorf("invalid id_token: %s claim is missing", claim)
+	}
+	want, err := crypto.TokenHash(alg, value)
+	if err != nil {
+		return fmt.Errorf("invalid id_token: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("invalid id_token: %s does not match", claim)
+	}
+	return nil
+}
+
+// frontChannelTokens returns the tokens of an implicit response for output,
+// shaped like a token response.
+func frontChannelTokens(resp *httpclient.AuthorizationCodeResponse) map[string]any {
+	tokens := map[string]any{}
+	for name, value := range map[string]string{
+		"id_token":     resp.IDToken,
+		"access_token": resp.AccessToken,
+		"token_type":   resp.TokenType,
+		"scope":        resp.Scope,
+	} {
+		if value != "" {
+			tokens[name] = value
+		}
+	}
+	if resp.ExpiresIn != "" {
+		if n, err := strconv.Atoi(resp.ExpiresIn); err == nil {
+			tokens["expires_in"] = n
+		} else {
+			tokens["expires_in"] = resp.ExpiresIn
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// The implicit and hybrid response types are exercised through Run: the
+// browser takes the relay page for the bare redirect, then posts the fragment
+// parameters back with an ID token signed by the test provider.
+
+func TestAuthorizationCodeFlowRunFrontChannelTokens(t *testing.T) {
+	t.Parallel()
+
+	const accessToken = "front-channel-at"
+	tests := []struct {
+		name         string
+		responseType string
+		// modify alters the ID token claims after the hashes are set.
+		modify     func(jwt.MapClaims)
+		wantErr    string
+		wantRedeem bool
+	}{
+		{name: "implicit id_token token", responseType: "id_token token"},
+		{name: "implicit token", responseType: "token"},
+		{name: "hybrid code id_token", responseType: "code id_token", wantRedeem: true},
+		{name: "hybrid code id_token token", responseType: "code id_token token", wantRedeem: true},
+		{
+			name:         "c_hash mismatch",
+			responseType: "code id_token",
+			modify:       func(c jwt.MapClaims) { c["c_hash"] = "forged" },
+			wantErr:      "c_hash does not match",
+		},
+		{
+			name:         "missing c_hash",
+			responseType: "code id_token",
+			modify:       func(c jwt.MapClaims) { delete(c, "c_hash") },
+			wantErr:      "c_hash claim is missing",
+		},
+		{
+			name:         "at_hash mismatch",
+			responseType: "id_token token",
+			modify:       func(c jwt.MapClaims) { c["at_hash"] = "forged" },
+			wantErr:      "at_hash does not match",
+		},
+		{
+			name:         "nonce mismatch",
+			responseType: "id_token token",
+			modify:       func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+			wantErr:      "nonce",
+		},
+		{
+			name:         "wrong audience",
+			responseType: "code id_token",
+			modify:       func(c jwt.MapClaims) { c["aud"] = "other-client" },
+			wantErr:      "audience",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			provider := newTestProvider(t)
+			fixture, opened, err := runAuthorizationCodeWith(t,
+				&AuthorizationCodeFlowConfig{Scope: "openid", State: "state-123", ResponseType: tt.responseType},
+				func(addr string, opened *url.URL) error {
+					// The bare redirect, as the browser sends it without the fragment
+					relay, err := http.Get("http://" + addr + "/callback") //nolint:noctx // test-local loopback request
+					if err != nil {
+						return err
+					}
+					relay.Body.Close()
+					query := opened.Query()
+					form := url.Values{"state": {query.Get("state")}}
+					returns := strings.Fields(tt.responseType)
+					claims := jwt.MapClaims{
+						"iss":   testIssuer,
+						"aud":   testClientID,
+						"sub":   "alice",
+						"exp":   time.Now().Add(time.Minute).Unix(),
+						"nonce": query.Get("nonce"),
+					}
+					for _, part := range returns {
+						switch part {
+						case "code":
+							form.Set("code", "auth-code")
+							claims["c_hash"] = tokenHash(t, "auth-code")
+						case "token":
+							form.Set("access_token", accessToken)
+							form.Set("token_type", "Bearer")
+							form.Set("expires_in", "3600")
+							claims["at_hash"] = tokenHash(t, accessToken)
+						}
+					}
+					if tt.modify != nil {
+						tt.modify(claims)
+					}
+					if strings.Contains(tt.responseType, "id_token") {
+						form.Set("id_token", provider.sign(t, claims))
+					}
+					resp, err := http.PostForm("http://"+addr+"/callback", form) //nolint:noctx // test-local loopback request
+					if err != nil {
+						return err
+					}
+					return resp.Body.Close()
+				},
+				withRoute(testJWKSEndpoint, http.StatusOK, provider.jwks),
+			)
+
+			query := opened.Query()
+			if got := query.Get("response_type"); got != tt.responseType {
+				t.Errorf("response_type = %q, want %q", got, tt.responseType)
+			}
+			if wantNonce := strings.Contains(tt.responseType, "id_token"); (query.Get("nonce") != "") != wantNonce {
+				t.Errorf("nonce = %q, want one only when an id_token is requested", query.Get("nonce"))
+			}
+			redeemed := false
+			for _, req := range fixture.requests {
+				if req.URL == testTokenEndpoint {
+					redeemed = true
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if redeemed {
+					t.Error("the code was redeemed despite an invalid id_token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if redeemed != tt.wantRedeem {
+				t.Errorf("code redeemed = %v, want %v", redeemed, tt.wantRedeem)
+			}
+			if tt.wantRedeem {
+				return
+			}
+
+			var output map[string]any
+			if err := json.Unmarshal(fixture.output.Bytes(), &output); err != nil {
+				t.Fatalf("parsing output %q: %v", fixture.output.String(), err)
+			}
+			if output["access_token"] != accessToken || output["expires_in"] != float64(3600) {
+				t.Errorf("output = %v, want the front-channel access token", output)
+			}
+			if _, ok := output["id_token"]; ok != strings.Contains(tt.responseType, "id_token") {
+				t.Errorf("output = %v, want an id_token only when requested", output)
+			}
+		})
+	}
+}
+
+func tokenHash(t *testing.T, value string) string {
+	t.Helper()
+	// The test provider signs with a P-256 key, so ES256.
+	hash, err := crypto.TokenHash("ES256", value)
+	if err != nil {
+		t.Fatalf("hashing %q: %v", value, err)
+	}
+	return hash
+}
@@ -18,6 +18,7 @@ type IntrospectFlowConfig struct {
 	TokenTypeHint   string
 	AcceptMediaType string
 	CustomArgs      *httpclient.CustomArgs
+	DPoP            bool
 }
 
 func (c *IntrospectFlow) Run(ctx context.Context) error {
@@ -43,6 +44,12 @@ func (c *IntrospectFlow) Run(ctx context.Context) error {
 	if err != nil {
 		return httpclient.WrapError(err, "introspection")
 	}
+	// Confirm the introspected token is bound to our DPoP key
+	if c.FlowConfig.DPoP {
+		if err := c.Config.DPoPKeys.checkConfirmation(introspectionData); err != nil {
+			return err
+		}
+	}
 
 	return c.Config.Runtime.Logger.OutputJSON(introspectionData)
 }
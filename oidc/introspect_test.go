@@ -2,6 +2,9 @@ package oidc
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
 	"net/http"
 	"net/url"
@@ -130,3 +133,50 @@ func TestIntrospectFlowRunServerError(t *testing.T) {
 		t.Errorf("output = %q, want empty on error", got)
 	}
 }
+
+// TestIntrospectFlowRunDPoPBinding pins that --dpop compares the introspected
+// cnf.jkt against the loaded public key.
+func TestIntrospectFlowRunDPoPBinding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		jkt     func(thumbprint string) string
+		wantErr bool
+	}{
+		{"bound to the key", func(thumbprint string) string { return thumbprint }, false},
+		{"bound to another key", func(string) string { return "someone-elses-key" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatalf("generating key: %v", err)
+			}
+			keys := DPoPKeys{Public: &priv.PublicKey, Private: priv}
+			thumbprint, err := keys.Thumbprint()
+			if err != nil {
+				t.Fatalf("Thumbprint returned error: %v", err)
+			}
+			fixture := newReadyConfig(t,
+				withResponse(http.StatusOK, `{"active":true,"cnf":{"jkt":"`+tt.jkt(thumbprint)+`"}}`))
+			fixture.config.DPoPKeys = keys
+
+			flow := &IntrospectFlow{
+				Config:     fixture.config,
+				FlowConfig: &IntrospectFlowConfig{Token: "token-to-inspect", DPoP: true},
+			}
+
+			err = flow.Run(context.Background())
+			if tt.wantErr && !errors.Is(err, ErrDPoPBindingMismatch) {
+				t.Errorf("Run() error = %v, want ErrDPoPBindingMismatch", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Run() error = %v, want nil", err)
+			}
+		})
+	}
+}
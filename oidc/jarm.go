@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// jarmResponseModes are the JWT-secured authorization response modes (JARM
+// §2.3); jwt is the default mode of the response type, query.jwt for code.
+var jarmResponseModes = map[string]bool{
+	"jwt":           true,
+	"query.jwt":     true,
+	"fragment.jwt":  true,
+	"form_post.jwt": true,
+}
+
+// jarmParameters are the authorization response parameters a response JWT
+// may carry (JARM §2.1 and §2.2), including the tokens of the implicit and
+// hybrid response types. iss is passed through for the RFC 9207 check.
+var jarmParameters = []string{
+	"code", "state", "iss", "error", "error_description", "error_uri",
+	"id_token", "access_token", "token_type", "expires_in", "scope",
+}
+
+// isJARMResponseMode reports whether the response mode returns a response
+// JWT.
+func isJARMResponseMode(mode string) bool {
+	return jarmResponseModes[mode]
+}
+
+// decodeJARMResponse verifies a response JWT as JARM §2.4 requires: it is
+// decrypted first if it is a JWE, then its signature is checked against the
+// provider's JWKS along with iss, aud and exp. The state is checked against
+// the request afterwards, like a plain response's.
+func (c *AuthorizationCodeFlow) decodeJARMResponse(ctx context.Context, response string) (url.Values, error) {
+	token := response
+	if crypto.IsJWE(token) {
+		if c.FlowConfig.ResponseDecryptionKeyFile == "" {
+			return nil, errors.New("the response is encrypted, set a decryption key with --response-decryption-key")
+		}
+		key, _, err := crypto.ReadSigningKeyFromFile(c.FlowConfig.ResponseDecryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response decryption key: %w", err)
+		}
+		plaintext, _, err := crypto.DecryptJWE(token, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt response: %w", err)
+		}
+		token = string(plaintext)
+	}
+
+	keys, err := c.Config.OIDC.fetchJWKS(ctx, c.Config.Runtime.Client)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := crypto.VerifyJWT(token, keys,
+		jwt.WithIssuer(c.Config.OIDC.IssuerURL),
+		jwt.WithAudience(c.Config.OIDC.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+
+	values := url.Values{}
+	for _, name := range jarmParameters {
+		if value, ok := claims[name].(string); ok {
+			values.Set(name, value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,270 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// JARM is exercised through the authorization-code Run: the browser answers
+// with a response JWT signed by the provider key served from the JWKS, and
+// the test asserts whether the code was redeemed.
+
+// testProvider signs response JWTs and ID tokens with a key published in
+// its JWKS.
+type testProvider struct {
+	key  *ecdsa.PrivateKey
+	kid  string
+	jwks string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating provider key: %v", err)
+	}
+	jwk, err := crypto.NewJWK(key)
+	if err != nil {
+		t.Fatalf("encoding provider key: %v", err)
+	}
+	jwks, err := json.Marshal(crypto.JWKS{Keys: []crypto.JWK{jwk.Public()}})
+	if err != nil {
+		t.Fatalf("encoding jwks: %v", err)
+	}
+	return &testProvider{key: key, kid: jwk.Kid, jwks: string(jwks)}
+}
+
+// claims returns valid response claims for the state of the opened
+// authorization URL.
+func (*testProvider) claims(opened *url.URL) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testClientID,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"code":  "auth-code",
+		"state": opened.Query().Get("state"),
+	}
+}
+
+func (p *testProvider) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := crypto.SignJWT(p.key, claims, map[string]any{"kid": p.kid})
+	if err != nil {
+		t.Fatalf("signing response: %v", err)
+	}
+	return token
+}
+
+// encryptionJWK publishes the client's key for the provider to encrypt
+// responses to.
+func encryptionJWK(t *testing.T, key *ecdsa.PrivateKey) crypto.JWK {
+	t.Helper()
+	jwk, err := crypto.NewJWK(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("encoding encryption key: %v", err)
+	}
+	jwk.Use, jwk.Alg = "enc", crypto.JWEAlgECDHES
+	return jwk
+}
+
+// sendResponse delivers a response JWT to the callback, in the query or as
+// a form_post.
+func sendResponse(addr, response string, post bool) error {
+	callback := "http://" + addr + "/callback"
+	var resp *http.Response
+	var err error
+	if post {
+		resp, err = http.PostForm(callback, url.Values{"response": {response}}) //nolint:noctx // test-local loopback request
+	} else {
+		resp, err = http.Get(callback + "?response=" + url.QueryEscape(response)) //nolint:noctx // test-local loopback request
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestAuthorizationCodeFlowRunJARM(t *testing.T) {
+	t.Parallel()
+
+	decryptionKeyFile, decryptionKey := writeSigningKey(t)
+	decryptionJWK := encryptionJWK(t, decryptionKey)
+
+	tests := []struct {
+		name    string
+		mode    string
+		post    bool
+		encrypt bool
+		modify  func(jwt.MapClaims)
+		wantErr string
+	}{
+		{name: "query.jwt", mode: "query.jwt"},
+		{name: "jwt", mode: "jwt"},
+		{name: "form_post.jwt", mode: "form_post.jwt", post: true},
+		// As relayed from the fragment by the callback page.
+		{name: "fragment.jwt", mode: "fragment.jwt", post: true},
+		{name: "encrypted response", mode: "jwt", encrypt: true},
+		{
+			name:    "wrong issuer",
+			mode:    "jwt",
+			modify:  func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" },
+			wantErr: "issuer",
+		},
+		{
+			name:    "wrong audience",
+			mode:    "jwt",
+			modify:  func(c jwt.MapClaims) { c["aud"] = "other-client" },
+			wantErr: "audience",
+		},
+		{
+			name:    "expired",
+			mode:    "jwt",
+			modify:  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			wantErr: "expired",
+		},
+		{
+			name:    "missing exp",
+			mode:    "jwt",
+			modify:  func(c jwt.MapClaims) { delete(c, "exp") },
+			wantErr: "exp claim is required",
+		},
+		{
+			name:    "state mismatch",
+			mode:    "jwt",
+			modify:  func(c jwt.MapClaims) { c["state"] = "forged-state" },
+			wantErr: "state mismatch",
+		},
+		{
+			name: "error response",
+			mode: "jwt",
+			modify: func(c jwt.MapClaims) {
+				delete(c, "code")
+				c["error"] = "access_denied"
+			},
+			wantErr: "access_denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			provider := newTestProvider(t)
+			flowConf := &AuthorizationCodeFlowConfig{
+				Scope:        "openid",
+				State:        "state-123",
+				ResponseMode: tt.mode,
+			}
+			if tt.encrypt {
+				flowConf.ResponseDecryptionKeyFile = decryptionKeyFile
+			}
+			fixture, opened, err := runAuthorizationCodeWith(t, flowConf, func(addr string, opened *url.URL) error {
+				claims := provider.claims(opened)
+				if tt.modify != nil {
+					tt.modify(claims)
+				}
+				response := provider.sign(t, claims)
+				if tt.encrypt {
+					encrypted, err := crypto.EncryptJWE([]byte(response), decryptionJWK, crypto.JWEEncA256GCM, map[string]any{"cty": "JWT"})
+					if err != nil {
+						return err
+					}
+					response = encrypted
+				}
+				return sendResponse(addr, response, tt.post)
+			}, withRoute(testJWKSEndpoint, http.StatusOK, provider.jwks))
+
+			if got := opened.Query().Get("response_mode"); got != tt.mode {
+				t.Errorf("response_mode = %q, want %q", got, tt.mode)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				for _, req := range fixture.requests {
+					if req.URL == testTokenEndpoint {
+						t.Error("the code was redeemed despite an invalid response")
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			last := fixture.requests[len(fixture.requests)-1]
+			if last.URL != testTokenEndpoint || last.Form.Get("code") != "auth-code" {
+				t.Errorf("last request = %s code %q, want the token request for auth-code", last.URL, last.Form.Get("code"))
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunJARMErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		issuer  string
+		jwks    string
+		wantErr string
+	}{
+		{name: "no issuer", jwks: testJWKSEndpoint, wantErr: "an issuer is required"},
+		{name: "no jwks_uri", issuer: testIssuer, wantErr: "no jwks_uri"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			browser := &recordingBrowser{}
+			fixture := newReadyConfig(t, withBrowser(browser))
+			fixture.config.OIDC.IssuerURL = tt.issuer
+			fixture.config.OIDC.JWKSEndpoint = tt.jwks
+			flow := &AuthorizationCodeFlow{
+				Config: fixture.config,
+				FlowConfig: &AuthorizationCodeFlowConfig{
+					CallbackURI:  "http://localhost/callback",
+					ResponseMode: "jwt",
+				},
+			}
+			err := flow.Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if browser.openedURL != "" {
+				t.Errorf("browser opened %q, want the flow to fail first", browser.openedURL)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunJARMEncryptedWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider(t)
+	_, recipient := writeSigningKey(t)
+	recipientJWK := encryptionJWK(t, recipient)
+	_, _, err := runAuthorizationCodeWith(t,
+		&AuthorizationCodeFlowConfig{Scope: "openid", ResponseMode: "jwt"},
+		func(addr string, opened *url.URL) error {
+			response, err := crypto.EncryptJWE([]byte(provider.sign(t, provider.claims(opened))), recipientJWK, crypto.JWEEncA256GCM, nil)
+			if err != nil {
+				return err
+			}
+			return sendResponse(addr, response, false)
+		},
+		withRoute(testJWKSEndpoint, http.StatusOK, provider.jwks),
+	)
+	if err == nil || !strings.Contains(err.Error(), "--response-decryption-key") {
+		t.Errorf("Run() error = %v, want a missing decryption key error", err)
+	}
+}
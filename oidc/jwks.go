@@ -0,0 +1,31 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jentz/oidc-cli/crypto"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// fetchJWKS fetches the provider's key set from the jwks_uri. It is fetched
+// on every use, so rotated keys are picked up.
+func (o *OIDCConfig) fetchJWKS(ctx context.Context, client *httpclient.Client) (crypto.JWKS, error) {
+	var keys crypto.JWKS
+	if o.JWKSEndpoint == "" {
+		return keys, errors.New("the authorization server advertises no jwks_uri")
+	}
+	resp, err := client.Get(ctx, o.JWKSEndpoint, nil)
+	if err != nil {
+		return keys, fmt.Errorf("jwks request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return keys, fmt.Errorf("jwks request failed with status %d", resp.StatusCode)
+	}
+	if err := resp.JSON(&keys); err != nil {
+		return keys, fmt.Errorf("failed to parse jwks response: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,104 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// defaultAssertionLifetime is how long a minted assertion is valid for when
+// no lifetime is configured.
+const defaultAssertionLifetime = 5 * time.Minute
+
+// JWTBearerFlow obtains tokens with the JWT bearer grant (RFC 7523 §2.1),
+// presenting either an existing assertion or one it signs itself.
+type JWTBearerFlow struct {
+	Config     *Config
+	FlowConfig *JWTBearerFlowConfig
+}
+
+type JWTBearerFlowConfig struct {
+	// Assertion is a signed JWT to present as is. When empty, one is minted
+	// from the key file and the claims below.
+	Assertion string
+	KeyFile   string
+	// KeyID is set as the kid header, overriding the kid of a JWK key file.
+	KeyID   string
+	Issuer  string
+	Subject string
+	// Audience defaults to the token endpoint.
+	Audience string
+	Lifetime time.Duration
+	// Claims are added to the minted assertion and take precedence over the
+	// claims derived from the fields above.
+	Claims map[string]any
+	Scope  string
+	DPoP   bool
+}
+
+func (c *JWTBearerFlow) Run(ctx context.Context) error {
+	logger := c.Config.Runtime.Logger
+
+	assertion := c.FlowConfig.Assertion
+	if assertion == "" {
+		var err error
+		assertion, err = c.mintAssertion()
+		if err != nil {
+			return err
+		}
+		logger.Printf("assertion: %s\n", assertion)
+	}
+
+	req := httpclient.CreateJWTBearerTokenRequest(
+		c.Config.OIDC.ClientID,
+		c.Config.OIDC.ClientSecret,
+		c.Config.OIDC.tokenAuthMethod(),
+		assertion,
+		c.FlowConfig.Scope,
+	)
+	return c.Config.requestTokens(ctx, req, c.FlowConfig.DPoP)
+}
+
+// mintAssertion signs a JWT carrying the claims RFC 7523 §3 requires: iss,
+// sub, aud and exp, plus iat and a unique jti.
+func (c *JWTBearerFlow) mintAssertion() (string, error) {
+	key, kid, err := crypto.ReadSigningKeyFromFile(c.FlowConfig.KeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read assertion signing key: %w", err)
+	}
+	if c.FlowConfig.KeyID != "" {
+		kid = c.FlowConfig.KeyID
+	}
+
+	audience := c.FlowConfig.Audience
+	if audience == "" {
+		audience = c.Config.OIDC.TokenEndpoint
+	}
+	lifetime := c.FlowConfig.Lifetime
+	if lifetime <= 0 {
+		lifetime = defaultAssertionLifetime
+	}
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss": c.FlowConfig.Issuer,
+		"sub": c.FlowConfig.Subject,
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(lifetime).Unix(),
+		"jti": rand.Text(),
+	}
+	maps.Copy(claims, c.FlowConfig.Claims)
+
+	header := map[string]any{}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	return crypto.SignJWT(key, claims, header)
+}
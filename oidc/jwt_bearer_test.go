@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+)
+
+const jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// writeSigningKey writes a fresh P-256 private key to a PEM file and returns
+// the path with the key.
+func writeSigningKey(t *testing.T) (string, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "assertion-key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	return path, key
+}
+
+func TestJWTBearerFlowRunWithAssertion(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`))
+	flow := &JWTBearerFlow{
+		Config:     fixture.config,
+		FlowConfig: &JWTBearerFlowConfig{Assertion: "eyJ.assertion.sig", Scope: "read"},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if req.URL != testTokenEndpoint {
+		t.Errorf("url = %q, want %q", req.URL, testTokenEndpoint)
+	}
+	if got := req.Header.Get("Authorization"); got != basicAuthHeader() {
+		t.Errorf("Authorization = %q, want %q", got, basicAuthHeader())
+	}
+	wantForm := url.Values{
+		"grant_type": {jwtBearerGrantType},
+		"assertion":  {"eyJ.assertion.sig"},
+		"scope":      {"read"},
+	}
+	if !reflect.DeepEqual(req.Form, wantForm) {
+		t.Errorf("form = %v, want %v", req.Form, wantForm)
+	}
+
+	wantOutput := `{
+  "access_token": "abc123",
+  "token_type": "Bearer"
+}
+`
+	if got := fixture.output.String(); got != wantOutput {
+		t.Errorf("output = %q, want %q", got, wantOutput)
+	}
+}
+
+func TestJWTBearerFlowRunMintsAssertion(t *testing.T) {
+	t.Parallel()
+	keyFile, key := writeSigningKey(t)
+
+	tests := []struct {
+		name      string
+		flowConf  JWTBearerFlowConfig
+		wantAud   string
+		wantKid   any
+		wantExtra map[string]any
+	}{
+		{
+			name: "defaults",
+			flowConf: JWTBearerFlowConfig{
+				KeyFile: keyFile,
+				Issuer:  "service-a",
+				Subject: "service-a",
+			},
+			wantAud: testTokenEndpoint,
+		},
+		{
+			name: "configured",
+			flowConf: JWTBearerFlowConfig{
+				KeyFile:  keyFile,
+				KeyID:    "key-1",
+				Issuer:   "service-a",
+				Subject:  "alice",
+				Audience: "https://op.example.com",
+				Lifetime: time.Minute,
+				Claims:   map[string]any{"tenant": "acme"},
+			},
+			wantAud:   "https://op.example.com",
+			wantKid:   "key-1",
+			wantExtra: map[string]any{"tenant": "acme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t)
+			flow := &JWTBearerFlow{Config: fixture.config, FlowConfig: &tt.flowConf}
+			if err := flow.Run(context.Background()); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			req := fixture.onlyRequest(t)
+			if got := req.Form.Get("grant_type"); got != jwtBearerGrantType {
+				t.Errorf("grant_type = %q, want %q", got, jwtBearerGrantType)
+			}
+			claims := jwt.MapClaims{}
+			token, err := jwt.ParseWithClaims(req.Form.Get("assertion"), claims, func(*jwt.Token) (any, error) { return &key.PublicKey, nil },
+				jwt.WithAudience(tt.wantAud), jwt.WithIssuer(tt.flowConf.Issuer), jwt.WithSubject(tt.flowConf.Subject), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+			if err != nil {
+				t.Fatalf("verifying assertion: %v", err)
+			}
+			if token.Header["kid"] != tt.wantKid {
+				t.Errorf("kid = %v, want %v", token.Header["kid"], tt.wantKid)
+			}
+			if claims["jti"] == "" || claims["jti"] == nil {
+				t.Error("jti is empty, want a unique identifier")
+			}
+			for name, want := range tt.wantExtra {
+				if claims[name] != want {
+					t.Errorf("claim %s = %v, want %v", name, claims[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTBearerFlowRunDPoP(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys(),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"DPoP"}`))
+	flow := &JWTBearerFlow{
+		Config:     fixture.config,
+		FlowConfig: &JWTBearerFlowConfig{Assertion: "eyJ.assertion.sig", DPoP: true},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	// VerifyDPoPProof fails on an empty proof, so it doubles as the presence check.
+	cryptotest.VerifyDPoPProof(t, req.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
+}
+
+func TestJWTBearerFlowRunMissingKey(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	flow := &JWTBearerFlow{
+		Config:     fixture.config,
+		FlowConfig: &JWTBearerFlowConfig{KeyFile: filepath.Join(t.TempDir(), "missing.pem"), Issuer: "a", Subject: "a"},
+	}
+	if err := flow.Run(context.Background()); err == nil {
+		t.Fatal("Run() error = nil, want an error for the missing key")
+	}
+	if len(fixture.requests) != 0 {
+		t.Errorf("got %d emitted requests, want none", len(fixture.requests))
+	}
+}
@@ -0,0 +1,75 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// LogoutFlow runs OpenID Connect RP-Initiated Logout: it opens the provider's
+// end-session endpoint in the browser and, when the post_logout_redirect_uri
+// points at the loopback, waits for the redirect that confirms the logout.
+type LogoutFlow struct {
+	Config     *Config
+	FlowConfig *LogoutFlowConfig
+}
+
+type LogoutFlowConfig struct {
+	IDTokenHint string
+	LogoutHint  string
+	// State is echoed back on the post-logout redirect. It is generated when
+	// empty and the flow waits for the redirect.
+	State                 string
+	PostLogoutRedirectURI string
+}
+
+func (c *LogoutFlow) Run(ctx context.Context) error {
+	endpoint := c.Config.OIDC.EndSessionEndpoint
+	if endpoint == "" {
+		return errors.New("the authorization server advertises no end session endpoint, set one with --end-session-url")
+	}
+
+	req := &httpclient.LogoutRequest{
+		IDTokenHint:           c.FlowConfig.IDTokenHint,
+		ClientID:              c.Config.OIDC.ClientID,
+		LogoutHint:            c.FlowConfig.LogoutHint,
+		State:                 c.FlowConfig.State,
+		PostLogoutRedirectURI: c.FlowConfig.PostLogoutRedirectURI,
+	}
+
+	// Only a loopback redirect lands on a server this process can run, so any
+	// other post_logout_redirect_uri is left to the browser.
+	var callback string
+	if isLoopbackURI(c.FlowConfig.PostLogoutRedirectURI) {
+		callback = c.FlowConfig.PostLogoutRedirectURI
+		if req.State == "" {
+			req.State = rand.Text()
+		}
+	}
+
+	logger := c.Config.Runtime.Logger
+	if _, err := c.Config.Runtime.Client.ExecuteLogoutRequest(ctx, endpoint, callback, req); err != nil {
+		return fmt.Errorf("logout request failed: %w", err)
+	}
+
+	if callback == "" {
+		logger.Errorln("logout requested, the provider confirms it only through a loopback post-logout-redirect-uri")
+		return nil
+	}
+	logger.Outputln("logged out")
+	return nil
+}
+
+// isLoopbackURI reports whether rawURL is an http URL on localhost or a
+// loopback address.
+func isLoopbackURI(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	return httpclient.IsLoopbackHost(u.Hostname())
+}
@@ -0,0 +1,120 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+	"github.com/jentz/oidc-cli/webflow"
+)
+
+// backChannelLogoutEvent is the member of a logout token's events claim that
+// marks it as a back-channel logout request (Back-Channel Logout §2.4).
+const backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// LogoutListenFlow runs a receiver for the logout notifications an OP sends
+// to its RPs, printing each back-channel and front-channel logout as JSON
+// until interrupted. Back-channel logout tokens are validated against the
+// provider's JWKS.
+type LogoutListenFlow struct {
+	Config     *Config
+	FlowConfig *LogoutListenFlowConfig
+}
+
+type LogoutListenFlowConfig struct {
+	ListenURI string
+}
+
+func (c *LogoutListenFlow) Run(ctx context.Context) error {
+	if c.Config.OIDC.JWKSEndpoint == "" {
+		return errors.New("the authorization server advertises no jwks_uri, set one with --jwks-url")
+	}
+
+	logger := c.Config.Runtime.Logger
+	server, err := webflow.NewLogoutServer(c.FlowConfig.ListenURI, logger, c.verifyLogoutToken,
+		webflow.WithLogoutListenFunc(c.Config.Runtime.Client.Listen))
+	if err != nil {
+		return fmt.Errorf("failed to create logout server: %w", err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Start(ctx)
+	}()
+	logger.Errorf("listening for logout requests on %s\n", c.FlowConfig.ListenURI)
+
+	for {
+		select {
+		case event := <-server.Events():
+			if err := c.outputEvent(event); err != nil {
+				return err
+			}
+		case err := <-errChan:
+			if err != nil {
+				return fmt.Errorf("logout server failed: %w", err)
+			}
+			// Print what arrived before the shutdown, then report why it
+			// stopped.
+			for {
+				select {
+				case event := <-server.Events():
+					if err := c.outputEvent(event); err != nil {
+						return err
+					}
+				default:
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// outputEvent prints an event, first checking a front-channel logout's
+// issuer, which must match when the OP sends one (Front-Channel Logout §2).
+func (c *LogoutListenFlow) outputEvent(event *webflow.LogoutEvent) error {
+	if event.Channel == webflow.FrontChannel && event.Error == "" &&
+		event.Issuer != "" && event.Issuer != c.Config.OIDC.IssuerURL {
+		event.Error = fmt.Sprintf("iss %q does not match the issuer %q", event.Issuer, c.Config.OIDC.IssuerURL)
+	}
+	return c.Config.Runtime.Logger.OutputJSON(event)
+}
+
+// verifyLogoutToken validates a logout token as Back-Channel Logout §2.6
+// requires: a signature by a key from the provider's JWKS, the issuer, the
+// client in the audience, iat, a sub or sid, the back-channel logout event,
+// and no nonce.
+func (c *LogoutListenFlow) verifyLogoutToken(ctx context.Context, logoutToken string) (map[string]any, error) {
+	keys, err := c.Config.OIDC.fetchJWKS(ctx, c.Config.Runtime.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := crypto.VerifyJWT(logoutToken, keys,
+		jwt.WithIssuer(c.Config.OIDC.IssuerURL),
+		jwt.WithAudience(c.Config.OIDC.ClientID),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logout token: %w", err)
+	}
+
+	if _, ok := claims["iat"]; !ok {
+		return nil, errors.New("invalid logout token: iat claim is missing")
+	}
+	if claims["sub"] == nil && claims["sid"] == nil {
+		return nil, errors.New("invalid logout token: sub or sid claim is required")
+	}
+	events, ok := claims["events"].(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid logout token: events claim is missing")
+	}
+	if _, ok := events[backChannelLogoutEvent].(map[string]any); !ok {
+		return nil, fmt.Errorf("invalid logout token: events claim lacks %s", backChannelLogoutEvent)
+	}
+	if _, ok := claims["nonce"]; ok {
+		return nil, errors.New("invalid logout token: nonce claim is not allowed")
+	}
+	return claims, nil
+}
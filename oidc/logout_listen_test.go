@@ -0,0 +1,279 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// The logout listener is driven through Run over a pre-bound loopback
+// listener: each test sends one logout notification, cancels the context, and
+// asserts on the event Run printed. The JWKS is served by the capture
+// transport.
+
+// runLogoutListener runs the flow, delivers one notification through send and
+// returns the printed event along with the error Run returned.
+func runLogoutListener(t *testing.T, fixture *flowFixture, ln net.Listener, send func(addr string) error) (map[string]any, error) {
+	t.Helper()
+	fixture.config.OIDC.IssuerURL = testIssuer
+	fixture.config.OIDC.JWKSEndpoint = testJWKSEndpoint
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	flow := &LogoutListenFlow{
+		Config:     fixture.config,
+		FlowConfig: &LogoutListenFlowConfig{ListenURI: "http://localhost/logout"},
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- flow.Run(ctx) }()
+
+	// Retry until the server is serving on the pre-bound listener.
+	var err error
+	for range 50 {
+		if err = send(ln.Addr().String()); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("sending logout notification: %v", err)
+	}
+	cancel()
+	runErr := <-errChan
+
+	var event map[string]any
+	if err := json.Unmarshal(fixture.output.Bytes(), &event); err != nil {
+		t.Fatalf("parsing output %q: %v", fixture.output.String(), err)
+	}
+	return event, runErr
+}
+
+func postLogoutToken(token string) func(addr string) error {
+	return func(addr string) error {
+		resp, err := http.PostForm("http://"+addr+"/logout", url.Values{"logout_token": {token}}) //nolint:noctx // test-local loopback request
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+}
+
+func TestLogoutListenFlowRunBackChannel(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating signing key: %v", err)
+	}
+	jwk, err := crypto.NewJWK(key)
+	if err != nil {
+		t.Fatalf("encoding signing key: %v", err)
+	}
+	jwksBody, err := json.Marshal(crypto.JWKS{Keys: []crypto.JWK{jwk.Public()}})
+	if err != nil {
+		t.Fatalf("encoding jwks: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating other key: %v", err)
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    testIssuer,
+			"aud":    testClientID,
+			"iat":    time.Now().Unix(),
+			"jti":    "jti-1",
+			"sid":    "sid-1",
+			"sub":    "alice",
+			"events": map[string]any{backChannelLogoutEvent: map[string]any{}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		claims  func() jwt.MapClaims
+		signer  *ecdsa.PrivateKey
+		wantErr string
+	}{
+		{
+			name:   "valid logout token",
+			claims: validClaims,
+		},
+		{
+			name:    "signed by an unknown key",
+			claims:  validClaims,
+			signer:  otherKey,
+			wantErr: "verification error",
+		},
+		{
+			name:    "wrong audience",
+			claims:  func() jwt.MapClaims { c := validClaims(); c["aud"] = "other-client"; return c },
+			wantErr: "audience",
+		},
+		{
+			name:    "wrong issuer",
+			claims:  func() jwt.MapClaims { c := validClaims(); c["iss"] = "https://other.example.com"; return c },
+			wantErr: "issuer",
+		},
+		{
+			name:    "missing events claim",
+			claims:  func() jwt.MapClaims { c := validClaims(); delete(c, "events"); return c },
+			wantErr: "events claim is missing",
+		},
+		{
+			name: "events claim without the back-channel logout event",
+			claims: func() jwt.MapClaims {
+				c := validClaims()
+				c["events"] = map[string]any{"urn:example:other": map[string]any{}}
+				return c
+			},
+			wantErr: "events claim lacks",
+		},
+		{
+			name:    "nonce is present",
+			claims:  func() jwt.MapClaims { c := validClaims(); c["nonce"] = "n-1"; return c },
+			wantErr: "nonce claim is not allowed",
+		},
+		{
+			name:    "neither sub nor sid",
+			claims:  func() jwt.MapClaims { c := validClaims(); delete(c, "sub"); delete(c, "sid"); return c },
+			wantErr: "sub or sid claim is required",
+		},
+		{
+			name:    "missing iat",
+			claims:  func() jwt.MapClaims { c := validClaims(); delete(c, "iat"); return c },
+			wantErr: "iat claim is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			signer := key
+			if tt.signer != nil {
+				signer = tt.signer
+			}
+			token, err := crypto.SignJWT(signer, tt.claims(), map[string]any{"kid": jwk.Kid, "typ": "logout+jwt"})
+			if err != nil {
+				t.Fatalf("signing logout token: %v", err)
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("binding loopback listener: %v", err)
+			}
+			fixture := newReadyConfig(t,
+				withListener(func(_, _ string) (net.Listener, error) { return ln, nil }),
+				withRoute(testJWKSEndpoint, http.StatusOK, string(jwksBody)),
+			)
+
+			event, err := runLogoutListener(t, fixture, ln, postLogoutToken(token))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Run() error = %v, want context.Canceled", err)
+			}
+			if event["channel"] != "back-channel" {
+				t.Errorf("channel = %v, want back-channel", event["channel"])
+			}
+
+			if tt.wantErr != "" {
+				got, _ := event["error"].(string)
+				if !strings.Contains(got, tt.wantErr) {
+					t.Errorf("error = %q, want it to contain %q", got, tt.wantErr)
+				}
+				return
+			}
+			if event["error"] != nil {
+				t.Fatalf("error = %v, want none", event["error"])
+			}
+			for key, want := range map[string]string{"iss": testIssuer, "sid": "sid-1", "sub": "alice"} {
+				if event[key] != want {
+					t.Errorf("%s = %v, want %s", key, event[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogoutListenFlowRunFrontChannel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		iss     string
+		wantErr string
+	}{
+		{name: "matching issuer", iss: testIssuer},
+		{name: "no issuer"},
+		{name: "mismatched issuer", iss: "https://other.example.com", wantErr: "does not match the issuer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("binding loopback listener: %v", err)
+			}
+			fixture := newReadyConfig(t, withListener(func(_, _ string) (net.Listener, error) { return ln, nil }))
+
+			query := url.Values{"sid": {"sid-1"}}
+			if tt.iss != "" {
+				query.Set("iss", tt.iss)
+			}
+			event, err := runLogoutListener(t, fixture, ln, func(addr string) error {
+				resp, err := http.Get(fmt.Sprintf("http://%s/logout?%s", addr, query.Encode())) //nolint:noctx // test-local loopback request
+				if err != nil {
+					return err
+				}
+				return resp.Body.Close()
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Run() error = %v, want context.Canceled", err)
+			}
+			if event["channel"] != "front-channel" || event["sid"] != "sid-1" {
+				t.Errorf("event = %v, want a front-channel event for sid-1", event)
+			}
+			got, _ := event["error"].(string)
+			if tt.wantErr == "" && got != "" {
+				t.Errorf("error = %q, want none", got)
+			}
+			if !strings.Contains(got, tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", got, tt.wantErr)
+			}
+			if len(fixture.requests) != 0 {
+				t.Errorf("emitted %d requests, want 0 for a front-channel logout", len(fixture.requests))
+			}
+		})
+	}
+}
+
+func TestLogoutListenFlowRunMissingJWKS(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	flow := &LogoutListenFlow{
+		Config:     fixture.config,
+		FlowConfig: &LogoutListenFlowConfig{ListenURI: "http://localhost/logout"},
+	}
+	err := flow.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no jwks_uri") {
+		t.Errorf("Run() error = %v, want a missing jwks_uri error", err)
+	}
+}
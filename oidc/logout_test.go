@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// The logout flow opens the end-session URL through the fixture's browser; a
+// loopback post-logout redirect is round-tripped over a pre-bound listener.
+
+func TestLogoutFlowRun(t *testing.T) {
+	t.Parallel()
+
+	browser := &recordingBrowser{}
+	fixture := newReadyConfig(t, withBrowser(browser))
+
+	flow := &LogoutFlow{
+		Config: fixture.config,
+		FlowConfig: &LogoutFlowConfig{
+			IDTokenHint:           "id-token",
+			LogoutHint:            "user@example.com",
+			PostLogoutRedirectURI: "https://rp.example.com/logged-out",
+		},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	logoutURL, err := url.Parse(browser.openedURL)
+	if err != nil {
+		t.Fatalf("parsing opened URL: %v", err)
+	}
+	if got := logoutURL.Scheme + "://" + logoutURL.Host + logoutURL.Path; got != testEndSessionEndpoint {
+		t.Errorf("end session endpoint = %q, want %q", got, testEndSessionEndpoint)
+	}
+	query := logoutURL.Query()
+	for key, want := range map[string]string{
+		"id_token_hint":            "id-token",
+		"client_id":                testClientID,
+		"logout_hint":              "user@example.com",
+		"post_logout_redirect_uri": "https://rp.example.com/logged-out",
+		"state":                    "",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if len(fixture.requests) != 0 {
+		t.Errorf("emitted %d requests, want 0", len(fixture.requests))
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want none for an unconfirmed logout", fixture.output.String())
+	}
+}
+
+func TestLogoutFlowRunLoopbackRedirect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		state       string
+		returnState func(sent string) string
+		wantErr     string
+	}{
+		{
+			name:        "state is generated and confirmed",
+			returnState: func(sent string) string { return sent },
+		},
+		{
+			name:        "given state is confirmed",
+			state:       "state-123",
+			returnState: func(sent string) string { return sent },
+		},
+		{
+			name:        "state mismatch is rejected",
+			state:       "state-123",
+			returnState: func(string) string { return "attacker-state" },
+			wantErr:     "state mismatch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("binding loopback listener: %v", err)
+			}
+
+			browser := &callbackFiringBrowser{}
+			browser.fire = func() error {
+				opened, err := url.Parse(browser.openedURL)
+				if err != nil {
+					return err
+				}
+				target := fmt.Sprintf("http://%s/logged-out?state=%s",
+					ln.Addr(), url.QueryEscape(tt.returnState(opened.Query().Get("state"))))
+				resp, err := http.Get(target) //nolint:noctx // test-local loopback request
+				if err != nil {
+					return err
+				}
+				return resp.Body.Close()
+			}
+
+			fixture := newReadyConfig(t,
+				withBrowser(browser),
+				withListener(func(_, _ string) (net.Listener, error) { return ln, nil }),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			flow := &LogoutFlow{
+				Config: fixture.config,
+				FlowConfig: &LogoutFlowConfig{
+					State:                 tt.state,
+					PostLogoutRedirectURI: "http://localhost/logged-out",
+				},
+			}
+
+			err = flow.Run(ctx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			opened, err := url.Parse(browser.openedURL)
+			if err != nil {
+				t.Fatalf("parsing opened URL: %v", err)
+			}
+			state := opened.Query().Get("state")
+			if state == "" {
+				t.Error("state is empty, want one sent with a loopback redirect")
+			}
+			if tt.state != "" && state != tt.state {
+				t.Errorf("state = %q, want %q", state, tt.state)
+			}
+			if got := fixture.output.String(); got != "logged out\n" {
+				t.Errorf("output = %q, want %q", got, "logged out\n")
+			}
+		})
+	}
+}
+
+func TestLogoutFlowRunMissingEndpoint(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withBrowser(&recordingBrowser{}))
+	fixture.config.OIDC.EndSessionEndpoint = ""
+
+	flow := &LogoutFlow{Config: fixture.config, FlowConfig: &LogoutFlowConfig{}}
+	err := flow.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no end session endpoint") {
+		t.Errorf("Run() error = %v, want a missing end session endpoint error", err)
+	}
+}
+
+func TestIsLoopbackURI(t *testing.T) {
+	t.Parallel()
+	tests := map[string]bool{
+		"http://localhost:9555/logout":  true,
+		"http://127.0.0.1/logout":       true,
+		"http://[::1]:8080/logout":      true,
+		"https://localhost/logout":      false,
+		"http://rp.example.com/logout":  false,
+		"https://rp.example.com/logout": false,
+		"":                              false,
+	}
+	for rawURL, want := range tests {
+		if got := isLoopbackURI(rawURL); got != want {
+			t.Errorf("isLoopbackURI(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
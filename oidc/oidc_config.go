@@ -30,10 +30,16 @@ type OIDCConfig struct {
 	PushedAuthorizationRequestEndpoint string
 	TokenEndpoint                      string
 	DeviceAuthorizationEndpoint        string
+	BackchannelAuthenticationEndpoint  string
 	IntrospectionEndpoint              string
 	UserinfoEndpoint                   string
 	JWKSEndpoint                       string
+	RegistrationEndpoint               string
+	EndSessionEndpoint                 string
 	AuthMethod                         httpclient.AuthMethod
+	// authMethodDefaulted marks an AuthMethod taken from discovery rather
+	// than set by the user.
+	authMethodDefaulted bool
 }
 
 // Runtime holds the dependencies a flow executes against rather than any
@@ -78,6 +84,10 @@ func (o *OIDCConfig) DiscoverEndpoints(ctx context.Context, client *httpclient.C
 		o.DeviceAuthorizationEndpoint = discoveryConfig.DeviceAuthorizationEndpoint
 	}
 
+	if o.BackchannelAuthenticationEndpoint == "" {
+		o.BackchannelAuthenticationEndpoint = discoveryConfig.BackchannelAuthenticationEndpoint
+	}
+
 	if o.IntrospectionEndpoint == "" {
 		o.IntrospectionEndpoint = discoveryConfig.IntrospectionEndpoint
 	}
@@ -90,12 +100,21 @@ func (o *OIDCConfig) DiscoverEndpoints(ctx context.Context, client *httpclient.C
 		o.JWKSEndpoint = discoveryConfig.JwksURI
 	}
 
+	if o.RegistrationEndpoint == "" {
+		o.RegistrationEndpoint = discoveryConfig.RegistrationEndpoint
+	}
+
+	if o.EndSessionEndpoint == "" {
+		o.EndSessionEndpoint = discoveryConfig.EndSessionEndpoint
+	}
+
 	// set default auth method if not set by user
 	if o.AuthMethod == "" {
 		for _, method := range discoveryConfig.TokenEndpointAuthMethods {
 			authMethodValue := httpclient.AuthMethod(method)
 			if authMethodValue.IsValid() {
 				o.AuthMethod = authMethodValue
+				o.authMethodDefaulted = true
 				break
 			}
 		}
@@ -123,3 +142,41 @@ func (o *OIDCConfig) setupPKCE(enabled bool) (string, error) { //nolint:revive /
 	}
 	return codeVerifier, nil
 }
+
+// tokenAuthMethod returns the auth method a flow without PKCE authenticates
+// at the token endpoint with. A client with no secret cannot authenticate
+// with one, so it falls back to none unless the user set an auth method.
+func (o *OIDCConfig) tokenAuthMethod() httpclient.AuthMethod {
+	if o.ClientSecret == "" && (o.AuthMethod == "" || o.authMethodDefaulted) {
+		return httpclient.AuthMethodNone
+	}
+	return o.AuthMethod
+}
+
+// requestTokens sends a token request, with a DPoP proof when dpop is set,
+// and outputs the tokens of the response.
+func (c *Config) requestTokens(ctx context.Context, req *httpclient.TokenRequest, dpop bool) error {
+	if dpop {
+		req.DPoP = c.DPoPKeys.ProofFunc()
+	}
+	resp, err := c.Runtime.Client.ExecuteTokenRequest(ctx, c.OIDC.TokenEndpoint, req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	return c.outputTokens(resp, dpop)
+}
+
+// outputTokens parses a token response and outputs its tokens, checking
+// first that a DPoP-bound access token is bound to the DPoP key.
+func (c *Config) outputTokens(resp *httpclient.Response, dpop bool) error {
+	tokenData, err := httpclient.ParseTokenResponse(resp)
+	if err != nil {
+		return httpclient.WrapError(err, "token")
+	}
+	if dpop {
+		if err := c.DPoPKeys.checkAccessTokenBinding(tokenData); err != nil {
+			return err
+		}
+	}
+	return c.Runtime.Logger.OutputJSON(tokenData)
+}
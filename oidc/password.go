@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	"context"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// PasswordFlow obtains tokens with the resource owner password credentials
+// grant. The grant hands the user's password to the client and is omitted
+// from OAuth 2.1; it is kept for legacy environments that allow nothing else.
+type PasswordFlow struct {
+	Config     *Config
+	FlowConfig *PasswordFlowConfig
+}
+
+type PasswordFlowConfig struct {
+	Username string
+	Password string
+	Scope    string
+	DPoP     bool
+}
+
+func (c *PasswordFlow) Run(ctx context.Context) error {
+	c.Config.Runtime.Logger.Errorln("warning: the password grant is deprecated (RFC 9700 §2.4) and should only be used against legacy test environments")
+
+	req := httpclient.CreatePasswordTokenRequest(
+		c.Config.OIDC.ClientID,
+		c.Config.OIDC.ClientSecret,
+		c.Config.OIDC.tokenAuthMethod(),
+		c.FlowConfig.Username,
+		c.FlowConfig.Password,
+		c.FlowConfig.Scope,
+	)
+	return c.Config.requestTokens(ctx, req, c.FlowConfig.DPoP)
+}
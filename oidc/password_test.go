@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/log"
+)
+
+func TestPasswordFlowRun(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`))
+	var stderr bytes.Buffer
+	fixture.config.Runtime.Logger = log.New(log.WithOutput(fixture.output, &stderr))
+
+	flow := &PasswordFlow{
+		Config: fixture.config,
+		FlowConfig: &PasswordFlowConfig{
+			Username: "alice",
+			Password: "s3cret",
+			Scope:    "openid",
+		},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if req.URL != testTokenEndpoint {
+		t.Errorf("url = %q, want %q", req.URL, testTokenEndpoint)
+	}
+	if got := req.Header.Get("Authorization"); got != basicAuthHeader() {
+		t.Errorf("Authorization = %q, want %q", got, basicAuthHeader())
+	}
+	wantForm := url.Values{
+		"grant_type": {"password"},
+		"username":   {"alice"},
+		"password":   {"s3cret"},
+		"scope":      {"openid"},
+	}
+	if !reflect.DeepEqual(req.Form, wantForm) {
+		t.Errorf("form = %v, want %v", req.Form, wantForm)
+	}
+
+	if !strings.Contains(stderr.String(), "deprecated") {
+		t.Errorf("stderr = %q, want a deprecation warning", stderr.String())
+	}
+	wantOutput := `{
+  "access_token": "abc123",
+  "token_type": "Bearer"
+}
+`
+	if got := fixture.output.String(); got != wantOutput {
+		t.Errorf("output = %q, want %q", got, wantOutput)
+	}
+}
+
+// TestPasswordFlowRunPublicClient pins the fallback for a client with no
+// secret: client_id in the body and no Authorization header.
+func TestPasswordFlowRunPublicClient(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withPublicClient())
+	flow := &PasswordFlow{
+		Config:     fixture.config,
+		FlowConfig: &PasswordFlowConfig{Username: "alice", Password: "s3cret"},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want no header for a public client", got)
+	}
+	if got := req.Form.Get("client_id"); got != testClientID {
+		t.Errorf("client_id = %q, want %q in the body", got, testClientID)
+	}
+	if req.Form.Has("scope") {
+		t.Errorf("scope = %q, want absent", req.Form.Get("scope"))
+	}
+	if got := fixture.config.OIDC.AuthMethod; got != httpclient.AuthMethodBasic {
+		t.Errorf("config AuthMethod = %q, want the shared config left as it was", got)
+	}
+}
+
+// TestPasswordFlowRunExplicitAuthMethod pins that the no-secret fallback
+// leaves an auth method the user set alone.
+func TestPasswordFlowRunExplicitAuthMethod(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withPublicClient())
+	// As if given with --auth-method rather than picked by discovery
+	fixture.config.OIDC.authMethodDefaulted = false
+	flow := &PasswordFlow{
+		Config:     fixture.config,
+		FlowConfig: &PasswordFlowConfig{Username: "alice", Password: "s3cret"},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, "Basic ") {
+		t.Errorf("Authorization = %q, want the explicit client_secret_basic kept", got)
+	}
+}
+
+func TestPasswordFlowRunDPoP(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withDPoPKeys(),
+		withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"DPoP"}`))
+
+	flow := &PasswordFlow{
+		Config:     fixture.config,
+		FlowConfig: &PasswordFlowConfig{Username: "alice", Password: "s3cret", DPoP: true},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	// VerifyDPoPProof fails on an empty proof, so it doubles as the presence check.
+	cryptotest.VerifyDPoPProof(t, req.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testTokenEndpoint)
+}
+
+func TestPasswordFlowRunRejected(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusBadRequest, `{"error":"invalid_grant","error_description":"bad credentials"}`))
+	flow := &PasswordFlow{
+		Config:     fixture.config,
+		FlowConfig: &PasswordFlowConfig{Username: "alice", Password: "wrong"},
+	}
+
+	err := flow.Run(context.Background())
+	if !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Errorf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want none", fixture.output)
+	}
+}
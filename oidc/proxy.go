@@ -0,0 +1,138 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// minRefreshInterval bounds how often the proxy refreshes in the background,
+// so a token with a very short lifetime does not turn into a request loop.
+const minRefreshInterval = 5 * time.Second
+
+// ProxyFlow runs a local reverse proxy that forwards every request to the
+// upstream with an access token from a TokenSource attached, for exercising a
+// protected API from a browser or an API client that cannot obtain tokens.
+type ProxyFlow struct {
+	Config     *Config
+	FlowConfig *ProxyFlowConfig
+}
+
+type ProxyFlowConfig struct {
+	Listen   string
+	Upstream string
+	Tokens   *TokenSource
+}
+
+func (c *ProxyFlow) Run(ctx context.Context) error {
+	logger := c.Config.Runtime.Logger
+	upstream, err := url.Parse(c.FlowConfig.Upstream)
+	if err != nil {
+		return fmt.Errorf("invalid upstream url: %w", err)
+	}
+
+	// Fail before listening when no token can be obtained at all
+	token, err := c.FlowConfig.Tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain access token: %w", err)
+	}
+
+	listener, err := c.Config.Runtime.Client.Listen("tcp", c.FlowConfig.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", c.FlowConfig.Listen, err)
+	}
+	server := &http.Server{
+		Handler:           c.handler(upstream),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Outputf("proxying http://%s to %s\n", listener.Addr(), upstream)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Serve(listener)
+	}()
+	go c.refreshBeforeExpiry(ctx, token)
+
+	select {
+	case <-ctx.Done():
+		// The parent context is already cancelled here, so use a fresh,
+		// bounded context to let in-flight requests drain without hanging.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil { //nolint:contextcheck // parent ctx is already cancelled; a fresh bounded context is deliberate
+			return err
+		}
+		return ctx.Err()
+	case err := <-errChan:
+		return err
+	}
+}
+
+// handler forwards requests to upstream through a TokenTransport, dropping any
+// credentials the caller sent so only the proxy's token reaches the upstream.
+func (c *ProxyFlow) handler(upstream *url.URL) http.Handler {
+	logger := c.Config.Runtime.Logger
+	transport := &httpclient.TokenTransport{
+		Client: c.Config.Runtime.Client,
+		Token:  c.FlowConfig.Tokens.AccessTokenFunc(),
+	}
+	if c.FlowConfig.Tokens.DPoP {
+		transport.DPoP = c.Config.DPoPKeys.AccessTokenProofFunc
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.Out.Header.Del("Authorization")
+			r.Out.Header.Del("DPoP")
+		},
+		Transport: transport,
+		ModifyResponse: func(resp *http.Response) error {
+			logger.Outputf("%s %s: %d\n", resp.Request.Method, resp.Request.URL, resp.StatusCode)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Errorf("%s %s: %v\n", r.Method, r.URL, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	return proxy
+}
+
+// refreshBeforeExpiry replaces the token shortly before it expires, so that
+// proxied requests do not stall on a token request. Tokens without an
+// expires_in are left to be replaced when the upstream rejects them.
+func (c *ProxyFlow) refreshBeforeExpiry(ctx context.Context, token *Token) {
+	tokens := c.FlowConfig.Tokens
+	if !tokens.CanRefresh() {
+		return
+	}
+	for !token.Expiry.IsZero() {
+		wait := max(time.Until(token.Expiry.Add(-2*tokenExpiryLeeway)), minRefreshInterval)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		refreshed, err := tokens.Refresh(ctx, token)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			c.Config.Runtime.Logger.Errorf("background token refresh failed: %v\n", err)
+			continue
+		}
+		c.Config.Runtime.Logger.Printf("access token refreshed in the background\n")
+		token = refreshed
+	}
+}
@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jentz/oidc-cli/crypto/cryptotest"
+)
+
+const testUpstream = "https://api.example.com"
+
+// runProxy starts the proxy on a pre-bound loopback listener, sends one request
+// through it, then stops the proxy. The fixture is only inspected after Run has
+// returned, once no server goroutine can still be recording requests.
+func runProxy(t *testing.T, tokens *TokenSource, opts ...fixtureOption) (fixture *flowFixture, status int, body string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	opts = append(opts, withListener(func(_, _ string) (net.Listener, error) { return listener, nil }))
+	fixture = newReadyConfig(t, opts...)
+	tokens.Config = fixture.config
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	flow := &ProxyFlow{
+		Config:     fixture.config,
+		FlowConfig: &ProxyFlowConfig{Listen: "127.0.0.1:0", Upstream: testUpstream, Tokens: tokens},
+	}
+	go func() { done <- flow.Run(ctx) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+listener.Addr().String()+"/items?page=2", strings.NewReader("a=1"))
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer caller-supplied")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("proxied request: %v", err)
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	return fixture, resp.StatusCode, string(respBody)
+}
+
+func TestProxyFlowInjectsBearerToken(t *testing.T) {
+	t.Parallel()
+
+	fixture, status, body := runProxy(t, &TokenSource{Grant: GrantClientCredentials},
+		withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"cc-1","expires_in":3600}`),
+		withRoute(testUpstream+"/items?page=2", http.StatusOK, "upstream says hi"),
+	)
+
+	if status != http.StatusOK || body != "upstream says hi" {
+		t.Errorf("proxied response = %d %q, want the upstream response", status, body)
+	}
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want token then upstream", len(fixture.requests))
+	}
+	upstream := fixture.requests[1]
+	if upstream.Method != http.MethodPost || upstream.URL != testUpstream+"/items?page=2" {
+		t.Errorf("upstream request = %s %s", upstream.Method, upstream.URL)
+	}
+	if got := upstream.Header.Get("Authorization"); got != "Bearer cc-1" {
+		t.Errorf("Authorization = %q, want the proxy's token", got)
+	}
+	if got := upstream.Form.Get("a"); got != "1" {
+		t.Errorf("body a = %q, want 1", got)
+	}
+	if !strings.Contains(fixture.output.String(), "POST "+testUpstream+"/items?page=2: 200") {
+		t.Errorf("output = %q, want the proxied call logged", fixture.output.String())
+	}
+}
+
+func TestProxyFlowInjectsDPoPProof(t *testing.T) {
+	t.Parallel()
+
+	fixture, status, _ := runProxy(t, &TokenSource{AccessToken: "dpop-1", DPoP: true},
+		withDPoPKeys(),
+		withRoute(testUpstream+"/items?page=2", http.StatusOK, "ok"),
+	)
+
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want 200", status)
+	}
+	upstream := fixture.onlyRequest(t)
+	if got := upstream.Header.Get("Authorization"); got != "DPoP dpop-1" {
+		t.Errorf("Authorization = %q, want %q", got, "DPoP dpop-1")
+	}
+	cryptotest.VerifyDPoPProof(t, upstream.Header.Get("DPoP"), fixture.dpopPublicKey, http.MethodPost, testUpstream+"/items")
+}
+
+func TestProxyFlowRefreshesRejectedToken(t *testing.T) {
+	t.Parallel()
+
+	fixture, status, _ := runProxy(t, &TokenSource{Grant: GrantRefreshToken, AccessToken: "at-1", RefreshToken: "rt-1"},
+		withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"at-2"}`),
+		withRouteResponses(testUpstream+"/items?page=2", invalidTokenResponse(), cannedResponse{status: http.StatusOK, body: "ok"}),
+	)
+
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want 200 after the retry", status)
+	}
+	if len(fixture.requests) != 3 {
+		t.Fatalf("got %d emitted requests, want upstream, refresh, upstream", len(fixture.requests))
+	}
+	if got := fixture.requests[2].Header.Get("Authorization"); got != "Bearer at-2" {
+		t.Errorf("retry Authorization = %q, want Bearer at-2", got)
+	}
+	if got := fixture.requests[2].Form.Get("a"); got != "1" {
+		t.Errorf("retry body a = %q, want the body replayed", got)
+	}
+}
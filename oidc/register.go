@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// Client registration operations: registering a client (RFC 7591) and
+// reading, updating or deleting a registered one (RFC 7592).
+const (
+	RegistrationCreate = "register"
+	RegistrationRead   = "read"
+	RegistrationUpdate = "update"
+	RegistrationDelete = "delete"
+)
+
+var registrationMethods = map[string]string{
+	RegistrationCreate: http.MethodPost,
+	RegistrationRead:   http.MethodGet,
+	RegistrationUpdate: http.MethodPut,
+	RegistrationDelete: http.MethodDelete,
+}
+
+// RegisterFlow registers a client with the authorization server, or manages
+// one it registered earlier, and prints the resulting client information,
+// including the client_secret and registration_access_token.
+type RegisterFlow struct {
+	Config     *Config
+	FlowConfig *RegisterFlowConfig
+}
+
+type RegisterFlowConfig struct {
+	Operation string
+	Metadata  map[string]any
+	// AccessToken is the initial access token when registering, and the
+	// registration access token when managing a client.
+	AccessToken string
+	// ClientURI is the registration_client_uri of the client to manage.
+	ClientURI string
+}
+
+func (c *RegisterFlow) Run(ctx context.Context) error {
+	method, ok := registrationMethods[c.FlowConfig.Operation]
+	if !ok {
+		return fmt.Errorf("unknown registration operation %q", c.FlowConfig.Operation)
+	}
+	req := &httpclient.ClientRegistrationRequest{
+		Method:      method,
+		URL:         c.FlowConfig.ClientURI,
+		AccessToken: c.FlowConfig.AccessToken,
+	}
+	switch c.FlowConfig.Operation {
+	case RegistrationCreate:
+		req.URL = c.Config.OIDC.RegistrationEndpoint
+		if req.URL == "" {
+			return errors.New("the authorization server advertises no registration endpoint, set one with --registration-url")
+		}
+		req.Metadata = c.FlowConfig.Metadata
+	case RegistrationUpdate:
+		req.Metadata = c.FlowConfig.Metadata
+	}
+
+	logger := c.Config.Runtime.Logger
+	resp, err := c.Config.Runtime.Client.ExecuteClientRegistrationRequest(ctx, req)
+	if err != nil {
+		return fmt.Errorf("client registration request failed: %w", err)
+	}
+	clientInfo, err := httpclient.ParseClientRegistrationResponse(resp)
+	if err != nil {
+		return httpclient.WrapError(err, "client registration")
+	}
+	logger.Printf("%s %s: %d\n", req.Method, req.URL, resp.StatusCode)
+
+	if c.FlowConfig.Operation == RegistrationDelete {
+		logger.Outputln("client deleted")
+		return nil
+	}
+	return logger.OutputJSON(clientInfo)
+}
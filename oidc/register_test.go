@@ -0,0 +1,138 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+const (
+	testRegistrationEndpoint = "https://op.example.com/register"
+	testClientURI            = "https://op.example.com/register/c-1"
+)
+
+func TestRegisterFlowRegistersClient(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusCreated,
+		`{"client_id":"c-1","client_secret":"s-1","registration_access_token":"rat-1","registration_client_uri":"`+testClientURI+`"}`))
+	fixture.config.OIDC.RegistrationEndpoint = testRegistrationEndpoint
+
+	flow := &RegisterFlow{
+		Config: fixture.config,
+		FlowConfig: &RegisterFlowConfig{
+			Operation:   RegistrationCreate,
+			AccessToken: "initial-1",
+			Metadata:    map[string]any{"client_name": "demo", "redirect_uris": []string{"http://localhost:9555/callback"}},
+		},
+	}
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if req.Method != http.MethodPost || req.URL != testRegistrationEndpoint {
+		t.Errorf("request = %s %s, want POST %s", req.Method, req.URL, testRegistrationEndpoint)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer initial-1" {
+		t.Errorf("Authorization = %q, want the initial access token", got)
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(req.Body, &metadata); err != nil {
+		t.Fatalf("decoding body %q: %v", req.Body, err)
+	}
+	want := map[string]any{"client_name": "demo", "redirect_uris": []any{"http://localhost:9555/callback"}}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("metadata = %v, want %v", metadata, want)
+	}
+
+	var output map[string]any
+	if err := json.Unmarshal(fixture.output.Bytes(), &output); err != nil {
+		t.Fatalf("decoding output %q: %v", fixture.output, err)
+	}
+	if output["client_secret"] != "s-1" || output["registration_access_token"] != "rat-1" {
+		t.Errorf("output = %v, want the client information", output)
+	}
+}
+
+func TestRegisterFlowManagesClient(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		operation  string
+		status     int
+		body       string
+		metadata   map[string]any
+		wantMethod string
+		wantOutput string
+	}{
+		{RegistrationRead, http.StatusOK, `{"client_id":"c-1"}`, nil, http.MethodGet, "{\n  \"client_id\": \"c-1\"\n}\n"},
+		{RegistrationUpdate, http.StatusOK, `{"client_id":"c-1"}`, map[string]any{"client_id": "c-1"}, http.MethodPut, "{\n  \"client_id\": \"c-1\"\n}\n"},
+		{RegistrationDelete, http.StatusNoContent, "", nil, http.MethodDelete, "client deleted\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t, withResponse(tt.status, tt.body))
+			flow := &RegisterFlow{
+				Config: fixture.config,
+				FlowConfig: &RegisterFlowConfig{
+					Operation:   tt.operation,
+					ClientURI:   testClientURI,
+					AccessToken: "rat-1",
+					Metadata:    tt.metadata,
+				},
+			}
+			if err := flow.Run(context.Background()); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			req := fixture.onlyRequest(t)
+			if req.Method != tt.wantMethod || req.URL != testClientURI {
+				t.Errorf("request = %s %s, want %s %s", req.Method, req.URL, tt.wantMethod, testClientURI)
+			}
+			if got := req.Header.Get("Authorization"); got != "Bearer rat-1" {
+				t.Errorf("Authorization = %q, want the registration access token", got)
+			}
+			if (tt.metadata == nil) != (len(req.Body) == 0) {
+				t.Errorf("body = %q, want metadata only for update", req.Body)
+			}
+			if got := fixture.output.String(); got != tt.wantOutput {
+				t.Errorf("output = %q, want %q", got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRegisterFlowWithoutRegistrationEndpoint(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	flow := &RegisterFlow{Config: fixture.config, FlowConfig: &RegisterFlowConfig{Operation: RegistrationCreate}}
+	if err := flow.Run(context.Background()); err == nil {
+		t.Fatal("Run() error = nil, want an error for the missing endpoint")
+	}
+	if len(fixture.requests) != 0 {
+		t.Errorf("got %d emitted requests, want none", len(fixture.requests))
+	}
+}
+
+func TestRegisterFlowRejected(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusBadRequest, `{"error":"invalid_client_metadata","error_description":"bad grant_types"}`))
+	fixture.config.OIDC.RegistrationEndpoint = testRegistrationEndpoint
+	flow := &RegisterFlow{Config: fixture.config, FlowConfig: &RegisterFlowConfig{Operation: RegistrationCreate, Metadata: map[string]any{}}}
+	err := flow.Run(context.Background())
+	if !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Errorf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want none", fixture.output)
+	}
+}
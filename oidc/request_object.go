@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// requestObjectLifetime is how long a request object is valid, well within
+// the 60 minutes FAPI allows between nbf and exp.
+const requestObjectLifetime = 5 * time.Minute
+
+// RequestObjectConfig selects how authorization parameters are packaged as a
+// request object (RFC 9101).
+type RequestObjectConfig struct {
+	// KeyFile holds the PEM or JWK private key the request object is signed
+	// with.
+	KeyFile string
+	KeyID   string
+	// Encrypt nests the signed request object in a JWE addressed to the
+	// provider's encryption key from its JWKS.
+	Encrypt bool
+}
+
+// newRequestObject signs the authorization parameters in values as a request
+// object addressed to the issuer (RFC 9101 §4), encrypting it when asked
+// (§6.1).
+func newRequestObject(ctx context.Context, conf *Config, values *url.Values, roConf *RequestObjectConfig) (string, error) {
+	if conf.OIDC.IssuerURL == "" {
+		return "", errors.New("an issuer is required as the audience of the request object")
+	}
+	key, kid, err := crypto.ReadSigningKeyFromFile(roConf.KeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read request object signing key: %w", err)
+	}
+	if roConf.KeyID != "" {
+		kid = roConf.KeyID
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": conf.OIDC.ClientID,
+		"aud": conf.OIDC.IssuerURL,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(requestObjectLifetime).Unix(),
+		"jti": rand.Text(),
+	}
+	for name, vals := range *values {
+		if name == "resource" && len(vals) > 1 {
+			// Several resource indicators become an array (RFC 8707 §2)
+			claims[name] = vals
+			continue
+		}
+		claims[name] = requestObjectClaim(name, values.Get(name))
+	}
+
+	header := map[string]any{"typ": "oauth-authz-req+jwt"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	signed, err := crypto.SignJWT(key, claims, header)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign request object: %w", err)
+	}
+	if !roConf.Encrypt {
+		return signed, nil
+	}
+
+	keys, err := conf.OIDC.fetchJWKS(ctx, conf.Runtime.Client)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the request object encryption key: %w", err)
+	}
+	encKey, ok := keys.EncryptionKey()
+	if !ok {
+		return "", errors.New("the authorization server publishes no key to encrypt the request object to")
+	}
+	encrypted, err := crypto.EncryptJWE([]byte(signed), encKey, crypto.JWEEncA256GCM, map[string]any{"cty": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt request object: %w", err)
+	}
+	return encrypted, nil
+}
+
+// requestObjectClaim types an authorization parameter as its JSON claim:
+// max_age is a number (OpenID Connect Core §3.1.2.1), claims (§5.5) and
+// authorization_details (RFC 9396 §3) are embedded as JSON, the rest are
+// strings.
+func requestObjectClaim(name, value string) any {
+	switch name {
+	case "max_age":
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case "claims", "authorization_details":
+		if json.Valid([]byte(value)) {
+			return json.RawMessage(value)
+		}
+	}
+	return value
+}
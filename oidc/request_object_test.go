@@ -0,0 +1,402 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/crypto"
+)
+
+// Request objects are exercised through the authorization-code Run: the
+// opened authorization URL and the PAR body carry the request object, which
+// the test verifies with the signing key (and decrypts with the provider's
+// encryption key) as an authorization server would.
+
+// runAuthorizationCode runs the authorization-code flow with a browser that
+// fires a successful callback, returning the URL the browser was opened at.
+func runAuthorizationCode(t *testing.T, flowConf *AuthorizationCodeFlowConfig, opts ...fixtureOption) (*flowFixture, *url.URL, error) {
+	t.Helper()
+	return runAuthorizationCodeWith(t, flowConf, func(addr string, opened *url.URL) error {
+		resp, err := http.Get(fmt.Sprintf("http://%s/callback?code=auth-code&state=%s", //nolint:noctx // test-local loopback request
+			addr, url.QueryEscape(requestedState(opened))))
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}, opts...)
+}
+
+// requestedState returns the state the opened authorization URL asks for,
+// from inside the request object when it carries one.
+func requestedState(opened *url.URL) string {
+	query := opened.Query()
+	if !query.Has("request") {
+		return query.Get("state")
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(query.Get("request"), claims); err != nil {
+		return ""
+	}
+	state, _ := claims["state"].(string)
+	return state
+}
+
+// runAuthorizationCodeWith runs the authorization-code flow with a browser
+// that answers the opened authorization URL through respond, which calls the
+// callback server listening on addr.
+func runAuthorizationCodeWith(t *testing.T, flowConf *AuthorizationCodeFlowConfig, respond func(addr string, opened *url.URL) error, opts ...fixtureOption) (*flowFixture, *url.URL, error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("binding loopback listener: %v", err)
+	}
+	browser := &callbackFiringBrowser{}
+	browser.fire = func() error {
+		opened, err := url.Parse(browser.openedURL)
+		if err != nil {
+			return err
+		}
+		return respond(ln.Addr().String(), opened)
+	}
+
+	opts = append([]fixtureOption{
+		withBrowser(browser),
+		withListener(func(_, _ string) (net.Listener, error) { return ln, nil }),
+	}, opts...)
+	fixture := newReadyConfig(t, opts...)
+	fixture.config.OIDC.IssuerURL = testIssuer
+	fixture.config.OIDC.JWKSEndpoint = testJWKSEndpoint
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if flowConf.CallbackURI == "" {
+		flowConf.CallbackURI = "http://localhost/callback"
+	}
+	flow := &AuthorizationCodeFlow{Config: fixture.config, FlowConfig: flowConf}
+	runErr := flow.Run(ctx)
+
+	opened, err := url.Parse(browser.openedURL)
+	if err != nil {
+		t.Fatalf("parsing opened URL %q: %v", browser.openedURL, err)
+	}
+	return fixture, opened, runErr
+}
+
+// verifyRequestObject checks the request object's header and signature and
+// returns its claims.
+func verifyRequestObject(t *testing.T, token string, key *ecdsa.PrivateKey) jwt.MapClaims {
+	t.Helper()
+	parsed, err := jwt.Parse(token, func(*jwt.Token) (any, error) { return &key.PublicKey, nil },
+		jwt.WithAudience(testIssuer), jwt.WithIssuer(testClientID), jwt.WithExpirationRequired())
+	if err != nil {
+		t.Fatalf("verifying request object: %v", err)
+	}
+	if typ := parsed.Header["typ"]; typ != "oauth-authz-req+jwt" {
+		t.Errorf("typ = %v, want oauth-authz-req+jwt", typ)
+	}
+	claims, _ := parsed.Claims.(jwt.MapClaims)
+	for _, name := range []string{"iat", "nbf", "jti"} {
+		if _, ok := claims[name]; !ok {
+			t.Errorf("request object lacks the %s claim", name)
+		}
+	}
+	return claims
+}
+
+func TestAuthorizationCodeFlowRunRequestObject(t *testing.T) {
+	t.Parallel()
+
+	keyFile, key := writeSigningKey(t)
+	fixture, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+		Scope:         "openid profile",
+		State:         "state-123",
+		MaxAge:        "600",
+		RequestObject: &RequestObjectConfig{KeyFile: keyFile, KeyID: "client-key"},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	query := opened.Query()
+	for _, name := range []string{"redirect_uri", "state", "max_age"} {
+		if query.Has(name) {
+			t.Errorf("authorization URL carries %s = %q, want it only inside the request object", name, query.Get(name))
+		}
+	}
+	if got := query.Get("client_id"); got != testClientID {
+		t.Errorf("client_id = %q, want %q", got, testClientID)
+	}
+	// OpenID Connect Core §6.1 requires response_type outside the request
+	// object too.
+	if got := query.Get("response_type"); got != "code" {
+		t.Errorf("response_type = %q, want code", got)
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(query.Get("request"), jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("parsing request object: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != "client-key" {
+		t.Errorf("kid = %v, want client-key", kid)
+	}
+	claims := verifyRequestObject(t, query.Get("request"), key)
+	for name, want := range map[string]any{
+		"response_type": "code",
+		"client_id":     testClientID,
+		"redirect_uri":  "http://localhost/callback",
+		"scope":         "openid profile",
+		"state":         "state-123",
+		"max_age":       float64(600),
+	} {
+		if claims[name] != want {
+			t.Errorf("request object %s = %v, want %v", name, claims[name], want)
+		}
+	}
+
+	// The code from the callback is still redeemed as usual.
+	if got := fixture.onlyRequest(t).Form.Get("code"); got != "auth-code" {
+		t.Errorf("token request code = %q, want auth-code", got)
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestObjectStateMismatch(t *testing.T) {
+	t.Parallel()
+
+	keyFile, _ := writeSigningKey(t)
+	fixture, _, err := runAuthorizationCodeWith(t, &AuthorizationCodeFlowConfig{
+		Scope:         "openid",
+		State:         "state-123",
+		RequestObject: &RequestObjectConfig{KeyFile: keyFile},
+	}, func(addr string, _ *url.URL) error {
+		resp, err := http.Get(fmt.Sprintf("http://%s/callback?code=auth-code&state=forged", addr)) //nolint:noctx // test-local loopback request
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	})
+	if err == nil || !strings.Contains(err.Error(), `state mismatch: expected "state-123" but got "forged"`) {
+		t.Fatalf("Run() error = %v, want a state mismatch", err)
+	}
+	if len(fixture.requests) != 0 {
+		t.Errorf("got %d emitted requests, want the code not redeemed", len(fixture.requests))
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestObjectHybrid(t *testing.T) {
+	t.Parallel()
+
+	keyFile, key := writeSigningKey(t)
+	provider := newTestProvider(t)
+	var requested jwt.MapClaims
+	fixture, opened, err := runAuthorizationCodeWith(t, &AuthorizationCodeFlowConfig{
+		Scope:         "openid",
+		State:         "state-123",
+		ResponseType:  "code id_token",
+		RequestObject: &RequestObjectConfig{KeyFile: keyFile},
+	}, func(addr string, opened *url.URL) error {
+		// The bare redirect, as the browser sends it without the fragment
+		relay, err := http.Get("http://" + addr + "/callback") //nolint:noctx // test-local loopback request
+		if err != nil {
+			return err
+		}
+		_ = relay.Body.Close()
+		// The provider answers what the request object asked for.
+		requested = verifyRequestObject(t, opened.Query().Get("request"), key)
+		state, _ := requested["state"].(string)
+		nonce, _ := requested["nonce"].(string)
+		idToken := provider.sign(t, jwt.MapClaims{
+			"iss":    testIssuer,
+			"aud":    testClientID,
+			"sub":    "alice",
+			"exp":    time.Now().Add(time.Minute).Unix(),
+			"nonce":  nonce,
+			"c_hash": tokenHash(t, "auth-code"),
+		})
+		resp, err := http.PostForm("http://"+addr+"/callback", //nolint:noctx // test-local loopback request
+			url.Values{"code": {"auth-code"}, "state": {state}, "id_token": {idToken}})
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}, withRoute(testJWKSEndpoint, http.StatusOK, provider.jwks))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// OpenID Connect Core §6.1 requires response_type outside the request
+	// object too, matching the one inside.
+	query := opened.Query()
+	if got := query.Get("response_type"); got != "code id_token" {
+		t.Errorf("response_type = %q, want code id_token", got)
+	}
+	if requested["response_type"] != "code id_token" {
+		t.Errorf("request object response_type = %v, want code id_token", requested["response_type"])
+	}
+	if nonce := query.Get("nonce"); nonce == "" || nonce != requested["nonce"] {
+		t.Errorf("nonce = %q, want the request object's %v", nonce, requested["nonce"])
+	}
+	redeemed := false
+	for _, req := range fixture.requests {
+		if req.URL == testTokenEndpoint {
+			redeemed = req.Form.Get("code") == "auth-code"
+		}
+	}
+	if !redeemed {
+		t.Error("the code was not redeemed")
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestObjectPAR(t *testing.T) {
+	t.Parallel()
+
+	keyFile, key := writeSigningKey(t)
+	fixture, opened, err := runAuthorizationCode(t,
+		&AuthorizationCodeFlowConfig{
+			Scope:         "openid",
+			PAR:           true,
+			RequestObject: &RequestObjectConfig{KeyFile: keyFile},
+		},
+		withRoute(testPAREndpoint, http.StatusCreated, `{"request_uri":"urn:par:jar","expires_in":60}`),
+	)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want 2 (PAR + token)", len(fixture.requests))
+	}
+	parForm := fixture.requests[0].Form
+	claims := verifyRequestObject(t, parForm.Get("request"), key)
+	if claims["redirect_uri"] != "http://localhost/callback" {
+		t.Errorf("request object redirect_uri = %v, want the callback", claims["redirect_uri"])
+	}
+	if parForm.Has("redirect_uri") {
+		t.Error("PAR body carries redirect_uri outside the request object")
+	}
+
+	query := opened.Query()
+	if got := query.Get("request_uri"); got != "urn:par:jar" {
+		t.Errorf("request_uri = %q, want urn:par:jar", got)
+	}
+	if query.Has("request") {
+		t.Error("authorization URL carries both request and request_uri")
+	}
+}
+
+func TestAuthorizationCodeFlowRunEncryptedRequestObject(t *testing.T) {
+	t.Parallel()
+
+	keyFile, key := writeSigningKey(t)
+	opKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating provider key: %v", err)
+	}
+	opJWK, err := crypto.NewJWK(&opKey.PublicKey)
+	if err != nil {
+		t.Fatalf("encoding provider key: %v", err)
+	}
+	opJWK.Use, opJWK.Alg = "enc", crypto.JWEAlgECDHES
+	jwks, err := json.Marshal(crypto.JWKS{Keys: []crypto.JWK{opJWK}})
+	if err != nil {
+		t.Fatalf("encoding jwks: %v", err)
+	}
+
+	fixture, opened, err := runAuthorizationCode(t,
+		&AuthorizationCodeFlowConfig{
+			Scope:         "openid",
+			RequestObject: &RequestObjectConfig{KeyFile: keyFile, Encrypt: true},
+		},
+		withRoute(testJWKSEndpoint, http.StatusOK, string(jwks)),
+	)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	request := opened.Query().Get("request")
+	if !crypto.IsJWE(request) {
+		t.Fatalf("request = %q, want a JWE", request)
+	}
+	plaintext, header, err := crypto.DecryptJWE(request, opKey)
+	if err != nil {
+		t.Fatalf("decrypting request object: %v", err)
+	}
+	if header["cty"] != "JWT" {
+		t.Errorf("cty = %v, want JWT", header["cty"])
+	}
+	verifyRequestObject(t, string(plaintext), key)
+
+	if len(fixture.requests) != 2 || fixture.requests[0].URL != testJWKSEndpoint {
+		t.Errorf("emitted %d requests, want the JWKS fetch then the token request", len(fixture.requests))
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestURI(t *testing.T) {
+	t.Parallel()
+
+	_, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{
+		Scope:      "openid",
+		RequestURI: "https://rp.example.com/request.jwt",
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := opened.Query().Get("request_uri"); got != "https://rp.example.com/request.jwt" {
+		t.Errorf("request_uri = %q, want the hosted request object", got)
+	}
+}
+
+func TestAuthorizationCodeFlowRunRequestObjectErrors(t *testing.T) {
+	t.Parallel()
+
+	keyFile, _ := writeSigningKey(t)
+	tests := []struct {
+		name    string
+		conf    *RequestObjectConfig
+		opts    []fixtureOption
+		wantErr string
+	}{
+		{
+			name:    "unreadable key",
+			conf:    &RequestObjectConfig{KeyFile: "/nonexistent/key.pem"},
+			wantErr: "failed to read request object signing key",
+		},
+		{
+			name:    "no encryption key in the jwks",
+			conf:    &RequestObjectConfig{KeyFile: keyFile, Encrypt: true},
+			opts:    []fixtureOption{withRoute(testJWKSEndpoint, http.StatusOK, `{"keys":[{"kty":"EC","use":"sig"}]}`)},
+			wantErr: "no key to encrypt the request object to",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t, append([]fixtureOption{withBrowser(&recordingBrowser{})}, tt.opts...)...)
+			fixture.config.OIDC.IssuerURL = testIssuer
+			fixture.config.OIDC.JWKSEndpoint = testJWKSEndpoint
+			flow := &AuthorizationCodeFlow{
+				Config: fixture.config,
+				FlowConfig: &AuthorizationCodeFlowConfig{
+					CallbackURI:   "http://localhost/callback",
+					RequestObject: tt.conf,
+				},
+			}
+			err := flow.Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package oidc
+
+import (
+	"context"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// SAML2BearerFlow obtains tokens with the SAML 2.0 bearer grant (RFC 7522
+// §2.1), presenting an assertion issued by another identity provider.
+type SAML2BearerFlow struct {
+	Config     *Config
+	FlowConfig *SAML2BearerFlowConfig
+}
+
+type SAML2BearerFlowConfig struct {
+	// Assertion is the base64url-encoded SAML 2.0 assertion.
+	Assertion string
+	Scope     string
+	DPoP      bool
+}
+
+func (c *SAML2BearerFlow) Run(ctx context.Context) error {
+	req := httpclient.CreateSAML2BearerTokenRequest(
+		c.Config.OIDC.ClientID,
+		c.Config.OIDC.ClientSecret,
+		c.Config.OIDC.tokenAuthMethod(),
+		c.FlowConfig.Assertion,
+		c.FlowConfig.Scope,
+	)
+	return c.Config.requestTokens(ctx, req, c.FlowConfig.DPoP)
+}
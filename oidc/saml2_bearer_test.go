@@ -0,0 +1,78 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+func TestSAML2BearerFlowRun(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`))
+	flow := &SAML2BearerFlow{
+		Config:     fixture.config,
+		FlowConfig: &SAML2BearerFlowConfig{Assertion: "PHNhbWw6QXNzZXJ0aW9uLz4", Scope: "read"},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if got := req.Header.Get("Authorization"); got != basicAuthHeader() {
+		t.Errorf("Authorization = %q, want %q", got, basicAuthHeader())
+	}
+	wantForm := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:saml2-bearer"},
+		"assertion":  {"PHNhbWw6QXNzZXJ0aW9uLz4"},
+		"scope":      {"read"},
+	}
+	if !reflect.DeepEqual(req.Form, wantForm) {
+		t.Errorf("form = %v, want %v", req.Form, wantForm)
+	}
+	if fixture.output.Len() == 0 {
+		t.Error("output is empty, want the token response")
+	}
+}
+
+// TestSAML2BearerFlowRunPublicClient pins the fallback for a client with no
+// secret: client_id in the body and no Authorization header.
+func TestSAML2BearerFlowRunPublicClient(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withPublicClient())
+	flow := &SAML2BearerFlow{Config: fixture.config, FlowConfig: &SAML2BearerFlowConfig{Assertion: "PHNhbWw6QXNzZXJ0aW9uLz4"}}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want no header for a public client", got)
+	}
+	if got := req.Form.Get("client_id"); got != testClientID {
+		t.Errorf("client_id = %q, want %q in the body", got, testClientID)
+	}
+}
+
+func TestSAML2BearerFlowRunRejected(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusBadRequest, `{"error":"invalid_grant","error_description":"assertion expired"}`))
+	flow := &SAML2BearerFlow{Config: fixture.config, FlowConfig: &SAML2BearerFlowConfig{Assertion: "PHNhbWw6QXNzZXJ0aW9uLz4"}}
+
+	err := flow.Run(context.Background())
+	if !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Errorf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want none", fixture.output)
+	}
+}
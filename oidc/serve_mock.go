@@ -0,0 +1,102 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jentz/oidc-cli/mockop"
+)
+
+// ServeMockFlow runs the mock OpenID Provider from package mockop on a local
+// listener, for trying the other commands without a real provider.
+type ServeMockFlow struct {
+	Config     *Config
+	FlowConfig *ServeMockFlowConfig
+}
+
+type ServeMockFlowConfig struct {
+	Listen string
+	// Provider configures the mock provider. An empty issuer defaults to an
+	// http URL for the listen address.
+	Provider mockop.Config
+	Faults   []mockop.Fault
+}
+
+func (c *ServeMockFlow) Run(ctx context.Context) error {
+	logger := c.Config.Runtime.Logger
+	listener, err := c.Config.Runtime.Client.Listen("tcp", c.FlowConfig.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", c.FlowConfig.Listen, err)
+	}
+
+	providerConf := c.FlowConfig.Provider
+	if providerConf.Issuer == "" {
+		providerConf.Issuer = defaultMockIssuer(c.FlowConfig.Listen, listener.Addr())
+	}
+	provider, err := mockop.New(providerConf)
+	if err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to create mock provider: %w", err)
+	}
+	for _, fault := range c.FlowConfig.Faults {
+		provider.Inject(fault)
+	}
+
+	handler := provider.Handler()
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			handler.ServeHTTP(recorder, r)
+			logger.Outputf("%s %s: %d\n", r.Method, r.URL.Path, recorder.status)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Outputf("mock provider listening on http://%s with issuer %s\n", listener.Addr(), provider.Issuer())
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Serve(listener)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil { //nolint:contextcheck // parent ctx is already cancelled; a fresh bounded context is deliberate
+			return err
+		}
+		return ctx.Err()
+	case err := <-errChan:
+		return err
+	}
+}
+
+// defaultMockIssuer names the provider by the host it was asked to listen on,
+// so that the issuer matches the URL users type, and by the port it actually
+// got, which differs when listening on port 0.
+func defaultMockIssuer(listen string, addr net.Addr) string {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "http://" + addr.String()
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// statusRecorder remembers the status code a handler wrote, for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,245 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jentz/oidc-cli/httpclient"
+	"github.com/jentz/oidc-cli/log"
+	"github.com/jentz/oidc-cli/mockop"
+)
+
+// fetchingBrowser plays the user agent: it loads the URL it is asked to open
+// and follows the provider's redirect back to the callback server.
+type fetchingBrowser struct {
+	wg sync.WaitGroup
+}
+
+func (b *fetchingBrowser) Open(rawURL string) error {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		resp, err := http.Get(rawURL) //nolint:noctx // the flow under test bounds the exchange
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	return nil
+}
+
+// newMockProviderConfig starts a mock provider and returns a Config whose
+// real HTTP client has discovered it. Sleeps between polls are recorded
+// rather than slept.
+func newMockProviderConfig(t *testing.T, providerConf mockop.Config, clientConf *httpclient.Config) (*Config, *mockop.Provider, *[]time.Duration) {
+	t.Helper()
+	server, provider, err := mockop.NewTestServer(providerConf)
+	if err != nil {
+		t.Fatalf("NewTestServer() error = %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	client := httpclient.NewClient(clientConf)
+	var sleeps []time.Duration
+	client.SetSleepFunc(func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	})
+
+	config := &Config{
+		OIDC: OIDCConfig{
+			ClientID:     testClientID,
+			ClientSecret: testClientSecret,
+			IssuerURL:    server.URL,
+		},
+		Runtime: Runtime{
+			Client: client,
+			Logger: log.New(log.WithOutput(&bytes.Buffer{}, io.Discard)),
+		},
+	}
+	if err := config.OIDC.DiscoverEndpoints(t.Context(), client); err != nil {
+		t.Fatalf("DiscoverEndpoints() error = %v", err)
+	}
+	return config, provider, &sleeps
+}
+
+func withMockDPoPKeys(t *testing.T, config *Config) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating DPoP key: %v", err)
+	}
+	config.DPoPKeys = DPoPKeys{Public: &priv.PublicKey, Private: priv}
+}
+
+func TestAuthorizationCodeFlowAgainstMockProvider(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	browser := &fetchingBrowser{}
+	config, provider, _ := newMockProviderConfig(t,
+		mockop.Config{ClientID: testClientID, ClientSecret: testClientSecret, Subject: "alice"},
+		&httpclient.Config{Browser: browser, Listen: func(_, _ string) (net.Listener, error) { return listener, nil }},
+	)
+	withMockDPoPKeys(t, config)
+	provider.Inject(mockop.Fault{Endpoint: "token", Error: "use_dpop_nonce", Count: 1})
+
+	output := &bytes.Buffer{}
+	config.Runtime.Logger = log.New(log.WithOutput(output, io.Discard))
+	flow := &AuthorizationCodeFlow{
+		Config: config,
+		FlowConfig: &AuthorizationCodeFlowConfig{
+			Scope:       "openid profile",
+			CallbackURI: "http://" + listener.Addr().String() + "/callback",
+			PKCE:        true,
+			PAR:         true,
+			DPoP:        true,
+		},
+	}
+	err = flow.Run(t.Context())
+	browser.wg.Wait()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var tokens map[string]any
+	if err := json.Unmarshal(output.Bytes(), &tokens); err != nil {
+		t.Fatalf("decoding output %q: %v", output, err)
+	}
+	if tokens["token_type"] != "DPoP" || tokens["id_token"] == nil || tokens["refresh_token"] == nil {
+		t.Errorf("tokens = %v, want DPoP-bound tokens with an id token", tokens)
+	}
+}
+
+func TestDeviceFlowAgainstMockProvider(t *testing.T) {
+	t.Parallel()
+	config, provider, sleeps := newMockProviderConfig(t,
+		mockop.Config{},
+		&httpclient.Config{Browser: &recordingBrowser{}},
+	)
+	config.OIDC.ClientSecret = ""
+	config.OIDC.AuthMethod = httpclient.AuthMethodNone
+	provider.Inject(mockop.Fault{Endpoint: "token", Error: "authorization_pending", Count: 1})
+	provider.Inject(mockop.Fault{Endpoint: "token", Error: "slow_down", Count: 1})
+
+	output := &bytes.Buffer{}
+	config.Runtime.Logger = log.New(log.WithOutput(output, io.Discard))
+	flow := &DeviceFlow{Config: config, FlowConfig: &DeviceFlowConfig{Scope: "openid", PKCE: true}}
+	if err := flow.Run(t.Context()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !strings.Contains(output.String(), `"id_token"`) {
+		t.Errorf("output = %q, want tokens", output)
+	}
+	// A wait after each faulted poll; slow_down adds five seconds to the
+	// interval (RFC 8628 §3.5).
+	want := []time.Duration{time.Second, 6 * time.Second}
+	if len(*sleeps) != len(want) {
+		t.Fatalf("sleeps = %v, want %v", *sleeps, want)
+	}
+	for i := range want {
+		if (*sleeps)[i] != want[i] {
+			t.Errorf("sleeps = %v, want %v", *sleeps, want)
+			break
+		}
+	}
+}
+
+func TestClientCredentialsFlowAgainstMockProviderFault(t *testing.T) {
+	t.Parallel()
+	config, provider, _ := newMockProviderConfig(t, mockop.Config{ClientID: testClientID, ClientSecret: testClientSecret}, nil)
+	provider.Inject(mockop.Fault{Endpoint: "token", Error: "invalid_client", Count: 1})
+
+	flow := &ClientCredentialsFlow{Config: config, FlowConfig: &ClientCredentialsFlowConfig{}}
+	err := flow.Run(t.Context())
+	if !errors.Is(err, httpclient.ErrOAuthError) {
+		t.Fatalf("Run() error = %v, want %v", err, httpclient.ErrOAuthError)
+	}
+	if err := flow.Run(t.Context()); err != nil {
+		t.Errorf("Run() after the fault error = %v", err)
+	}
+}
+
+func TestServeMockFlow(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	fixture := newReadyConfig(t, withListener(func(_, _ string) (net.Listener, error) { return listener, nil }))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	flow := &ServeMockFlow{
+		Config: fixture.config,
+		FlowConfig: &ServeMockFlowConfig{
+			Listen: "127.0.0.1:0",
+			Faults: []mockop.Fault{{Endpoint: "token", Error: "server_error"}},
+		},
+	}
+	go func() { done <- flow.Run(ctx) }()
+
+	base := "http://" + listener.Addr().String()
+	resp, err := http.Get(base + mockop.DiscoveryPath) //nolint:noctx // bounded by the test
+	if err != nil {
+		t.Fatalf("discovery request: %v", err)
+	}
+	var doc map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&doc)
+	_ = resp.Body.Close()
+	if err != nil || doc["issuer"] != base {
+		t.Errorf("discovery issuer = %v (err %v), want %s", doc["issuer"], err, base)
+	}
+	resp, err = http.PostForm(base+mockop.TokenPath, nil) //nolint:noctx // bounded by the test
+	if err != nil {
+		t.Fatalf("token request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	want := "mock provider listening on " + base + " with issuer " + base + "\n" +
+		"GET " + mockop.DiscoveryPath + ": 200\n" +
+		"POST " + mockop.TokenPath + ": 500\n"
+	if got := fixture.output.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultMockIssuer(t *testing.T) {
+	t.Parallel()
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9000}
+	var tests = []struct {
+		listen string
+		want   string
+	}{
+		{"localhost:9000", "http://localhost:9000"},
+		{":9000", "http://localhost:9000"},
+		{"127.0.0.1:0", "http://127.0.0.1:9000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.listen, func(t *testing.T) {
+			t.Parallel()
+			if got := defaultMockIssuer(tt.listen, addr); got != tt.want {
+				t.Errorf("defaultMockIssuer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// stepUpClockSkew is the leeway allowed between the provider's clock, which
+// set auth_time, and ours when checking max_age.
+const stepUpClockSkew = 30 * time.Second
+
+// StepUpConfig makes the authorization code flow answer a step-up challenge
+// (RFC 9470): the user is asked to log in again with the acr_values and
+// max_age the resource server demands, and the new ID token is checked to
+// meet them.
+type StepUpConfig struct {
+	// Challenge is the WWW-Authenticate header value the resource server
+	// rejected a token with.
+	Challenge string
+	// URL is called with AccessToken to obtain the challenge when none is
+	// given, and again with the new access token once the user has stepped
+	// up.
+	URL         string
+	AccessToken string
+}
+
+// stepUpDemand is what a step-up challenge asks of the user's
+// authentication.
+type stepUpDemand struct {
+	acrValues []string
+	// maxAge is the most seconds since the user authenticated, or -1 when
+	// the challenge sets no max_age.
+	maxAge int
+}
+
+// parseStepUpChallenge reads the demand of an insufficient_user_authentication
+// challenge. A leading "WWW-Authenticate:", as a copied header line carries,
+// is ignored.
+func parseStepUpChallenge(header string) (*stepUpDemand, error) {
+	if name, value, ok := strings.Cut(header, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "WWW-Authenticate") {
+		header = value
+	}
+	challenge, ok := httpclient.StepUpChallenge(header)
+	if !ok {
+		return nil, fmt.Errorf("%q is not an insufficient_user_authentication challenge", header)
+	}
+	demand := &stepUpDemand{
+		acrValues: strings.Fields(challenge.Param("acr_values")),
+		maxAge:    -1,
+	}
+	if maxAge := challenge.Param("max_age"); maxAge != "" {
+		n, err := strconv.Atoi(maxAge)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("the step-up challenge has an invalid max_age %q", maxAge)
+		}
+		demand.maxAge = n
+	}
+	if len(demand.acrValues) == 0 && demand.maxAge < 0 {
+		return nil, errors.New("the step-up challenge demands neither acr_values nor max_age")
+	}
+	return demand, nil
+}
+
+// stepUpDemand returns the demand of the configured challenge, calling the
+// resource for it when no challenge is given.
+func (c *AuthorizationCodeFlow) stepUpDemand(ctx context.Context) (*stepUpDemand, error) {
+	stepUp := c.FlowConfig.StepUp
+	if stepUp.Challenge != "" {
+		return parseStepUpChallenge(stepUp.Challenge)
+	}
+	resp, err := c.callStepUpResource(ctx, stepUp.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsSuccess() {
+		return nil, errors.New("the resource accepted the access token, there is no step-up challenge to answer")
+	}
+	header := resp.Headers.Get("WWW-Authenticate")
+	if _, ok := httpclient.StepUpChallenge(header); !ok {
+		return nil, fmt.Errorf("the resource answered %d without a step-up challenge", resp.StatusCode)
+	}
+	return parseStepUpChallenge(header)
+}
+
+// applyStepUp sets the authorization request up to meet the demand, forcing
+// a fresh login (RFC 9470 §4).
+func (c *AuthorizationCodeFlow) applyStepUp(demand *stepUpDemand) {
+	logger := c.Config.Runtime.Logger
+	if len(demand.acrValues) > 0 {
+		c.FlowConfig.AcrValues = strings.Join(demand.acrValues, " ")
+		logger.Printf("step-up acr_values: %s\n", c.FlowConfig.AcrValues)
+	}
+	if demand.maxAge >= 0 {
+		c.FlowConfig.MaxAge = strconv.Itoa(demand.maxAge)
+		logger.Printf("step-up max_age: %d\n", demand.maxAge)
+	}
+	c.FlowConfig.Prompt = "login"
+}
+
+// checkStepUp confirms the new ID token meets the demand: its acr is one of
+// the acr_values and its auth_time is within max_age. When the challenge came
+// from the resource, the resource is called again with the new access token.
+func (c *AuthorizationCodeFlow) checkStepUp(ctx context.Context, demand *stepUpDemand, tokenData map[string]any) error {
+	idToken, _ := tokenData["id_token"].(string)
+	if idToken == "" {
+		return errors.New("step-up failed: no id_token was returned to check the authentication in")
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+		return fmt.Errorf("step-up failed: invalid id_token: %w", err)
+	}
+	if len(demand.acrValues) > 0 {
+		acr, _ := claims["acr"].(string)
+		if !slices.Contains(demand.acrValues, acr) {
+			return fmt.Errorf("step-up failed: acr %q is not one of the demanded %s", acr, strings.Join(demand.acrValues, ", "))
+		}
+	}
+	if demand.maxAge >= 0 {
+		authTime, ok := claims["auth_time"].(float64)
+		if !ok {
+			return errors.New("step-up failed: the id_token has no auth_time to check max_age against")
+		}
+		age := time.Since(time.Unix(int64(authTime), 0))
+		if age > time.Duration(demand.maxAge)*time.Second+stepUpClockSkew {
+			return fmt.Errorf("step-up failed: the user authenticated %s ago, more than the demanded max_age of %ds", age.Round(time.Second), demand.maxAge)
+		}
+	}
+
+	logger := c.Config.Runtime.Logger
+	logger.Errorf("step-up satisfied: acr %v, auth_time %v\n", claims["acr"], claims["auth_time"])
+	if c.FlowConfig.StepUp.URL == "" {
+		return nil
+	}
+	accessToken, _ := tokenData["access_token"].(string)
+	resp, err := c.callStepUpResource(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccess() {
+		_, err := httpclient.ParseResourceResponse(resp)
+		return fmt.Errorf("the resource still rejects the stepped-up access token: %w", err)
+	}
+	logger.Errorf("the resource accepted the stepped-up access token: %d %s\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+	return nil
+}
+
+// callStepUpResource calls the step-up URL with accessToken, presenting it
+// under DPoP when the flow uses DPoP.
+func (c *AuthorizationCodeFlow) callStepUpResource(ctx context.Context, accessToken string) (*httpclient.Response, error) {
+	req := &httpclient.ResourceRequest{
+		Method:      http.MethodGet,
+		URL:         c.FlowConfig.StepUp.URL,
+		AccessToken: accessToken,
+	}
+	if c.FlowConfig.DPoP {
+		req.DPoP = c.Config.DPoPKeys.AccessTokenProofFunc(accessToken)
+	}
+	resp, err := c.Config.Runtime.Client.ExecuteResourceRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("resource request failed: %w", err)
+	}
+	return resp, nil
+}
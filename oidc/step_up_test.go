@@ -0,0 +1,193 @@
+package oidc
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testStepUpResource = "https://api.example.com/transfers"
+
+func TestParseStepUpChallenge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		header        string
+		wantAcrValues []string
+		wantMaxAge    int
+		wantErr       string
+	}{
+		{
+			name:          "acr_values and max_age",
+			header:        `Bearer error="insufficient_user_authentication", acr_values="urn:example:mfa urn:example:hwk", max_age="300"`,
+			wantAcrValues: []string{"urn:example:mfa", "urn:example:hwk"},
+			wantMaxAge:    300,
+		},
+		{
+			name:       "copied header line",
+			header:     `WWW-Authenticate: Bearer error="insufficient_user_authentication", max_age=0`,
+			wantMaxAge: 0,
+		},
+		{name: "other error", header: `Bearer error="invalid_token"`, wantErr: "not an insufficient_user_authentication challenge"},
+		{name: "no demand", header: `Bearer error="insufficient_user_authentication"`, wantErr: "neither acr_values nor max_age"},
+		{name: "invalid max_age", header: `Bearer error="insufficient_user_authentication", max_age="soon"`, wantErr: "invalid max_age"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			demand, err := parseStepUpChallenge(tt.header)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseStepUpChallenge() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStepUpChallenge() error = %v", err)
+			}
+			if !slices.Equal(demand.acrValues, tt.wantAcrValues) || demand.maxAge != tt.wantMaxAge {
+				t.Errorf("demand = %+v, want acr_values %q and max_age %d", demand, tt.wantAcrValues, tt.wantMaxAge)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunStepUp(t *testing.T) {
+	t.Parallel()
+
+	const challenge = `Bearer error="insufficient_user_authentication", acr_values="urn:example:mfa", max_age="60"`
+	rejected := cannedResponse{status: http.StatusUnauthorized, header: http.Header{"Www-Authenticate": {challenge}}}
+	accepted := cannedResponse{status: http.StatusOK, body: `{"ok":true}`}
+	provider := newTestProvider(t)
+
+	tests := []struct {
+		name     string
+		stepUp   StepUpConfig
+		claims   jwt.MapClaims
+		resource []cannedResponse
+		wantErr  string
+		// wantCalls is how often the resource is called.
+		wantCalls int
+	}{
+		{
+			name:   "challenge given",
+			stepUp: StepUpConfig{Challenge: challenge},
+			claims: jwt.MapClaims{"acr": "urn:example:mfa", "auth_time": time.Now().Unix()},
+		},
+		{
+			name:      "challenge from the resource",
+			stepUp:    StepUpConfig{URL: testStepUpResource, AccessToken: "weak-token"},
+			claims:    jwt.MapClaims{"acr": "urn:example:mfa", "auth_time": time.Now().Unix()},
+			resource:  []cannedResponse{rejected, accepted},
+			wantCalls: 2,
+		},
+		{
+			name:    "acr not met",
+			stepUp:  StepUpConfig{Challenge: challenge},
+			claims:  jwt.MapClaims{"acr": "urn:example:pwd", "auth_time": time.Now().Unix()},
+			wantErr: `acr "urn:example:pwd" is not one of the demanded urn:example:mfa`,
+		},
+		{
+			name:    "authentication too old",
+			stepUp:  StepUpConfig{Challenge: challenge},
+			claims:  jwt.MapClaims{"acr": "urn:example:mfa", "auth_time": time.Now().Add(-time.Hour).Unix()},
+			wantErr: "more than the demanded max_age of 60s",
+		},
+		{
+			name:    "no auth_time",
+			stepUp:  StepUpConfig{Challenge: challenge},
+			claims:  jwt.MapClaims{"acr": "urn:example:mfa"},
+			wantErr: "no auth_time",
+		},
+		{
+			name:      "resource still rejects",
+			stepUp:    StepUpConfig{URL: testStepUpResource, AccessToken: "weak-token"},
+			claims:    jwt.MapClaims{"acr": "urn:example:mfa", "auth_time": time.Now().Unix()},
+			resource:  []cannedResponse{rejected, rejected},
+			wantErr:   "still rejects the stepped-up access token",
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stepUp := tt.stepUp
+			idToken := provider.sign(t, tt.claims)
+			opts := []fixtureOption{
+				withRoute(testTokenEndpoint, http.StatusOK, `{"access_token":"strong-token","token_type":"Bearer","id_token":"`+idToken+`"}`),
+			}
+			if tt.resource != nil {
+				opts = append(opts, withRouteResponses(testStepUpResource, tt.resource...))
+			}
+			fixture, opened, err := runAuthorizationCode(t, &AuthorizationCodeFlowConfig{Scope: "openid", StepUp: &stepUp}, opts...)
+
+			query := opened.Query()
+			if query.Get("acr_values") != "urn:example:mfa" || query.Get("max_age") != "60" || query.Get("prompt") != "login" {
+				t.Errorf("authorization query = %v, want the demanded acr_values and max_age with prompt=login", query)
+			}
+			var calls []*capturedRequest
+			for _, req := range fixture.requests {
+				if req.URL == testStepUpResource {
+					calls = append(calls, req)
+				}
+			}
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("resource called %d times, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantCalls == 2 {
+				if got := calls[0].Header.Get("Authorization"); got != "Bearer weak-token" {
+					t.Errorf("first call Authorization = %q, want the rejected token", got)
+				}
+				if got := calls[1].Header.Get("Authorization"); got != "Bearer strong-token" {
+					t.Errorf("second call Authorization = %q, want the stepped-up token", got)
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if fixture.output.Len() != 0 {
+					t.Errorf("output = %q, want no tokens when the step-up fails", fixture.output.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !strings.Contains(fixture.stderr.String(), "step-up satisfied") {
+				t.Errorf("stderr = %q, want the step-up confirmed", fixture.stderr.String())
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowRunStepUpWithoutChallenge(t *testing.T) {
+	t.Parallel()
+
+	for _, resp := range []cannedResponse{
+		{status: http.StatusOK, body: `{"ok":true}`},
+		{status: http.StatusUnauthorized, header: http.Header{"Www-Authenticate": {`Bearer error="invalid_token"`}}},
+	} {
+		fixture := newReadyConfig(t, withRouteResponses(testStepUpResource, resp))
+		flow := &AuthorizationCodeFlow{
+			Config: fixture.config,
+			FlowConfig: &AuthorizationCodeFlowConfig{
+				Scope:  "openid",
+				StepUp: &StepUpConfig{URL: testStepUpResource, AccessToken: "token"},
+			},
+		}
+		err := flow.Run(t.Context())
+		if err == nil || !strings.Contains(err.Error(), "step-up challenge") {
+			t.Errorf("Run() error = %v, want no step-up challenge for a %d response", err, resp.status)
+		}
+		if len(fixture.requests) != 1 {
+			t.Errorf("emitted %d requests, want only the resource call", len(fixture.requests))
+		}
+	}
+}
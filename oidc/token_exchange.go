@@ -13,7 +13,7 @@ type TokenExchangeFlow struct {
 }
 
 type TokenExchangeFlowConfig struct {
-	Resource           string
+	Resources          []string
 	Audience           string
 	Scope              string
 	RequestedTokenType string
@@ -22,11 +22,13 @@ type TokenExchangeFlowConfig struct {
 	ActorToken         string
 	ActorTokenType     string
 	DPoP               bool
+	// AuthorizationDetails is a JSON array of RFC 9396 authorization details.
+	AuthorizationDetails string
 }
 
 func (c *TokenExchangeFlow) createTokenRequest() *httpclient.TokenRequest {
 	input := httpclient.TokenExchangeInput{
-		Resource:           c.FlowConfig.Resource,
+		Resources:          c.FlowConfig.Resources,
 		Audience:           c.FlowConfig.Audience,
 		Scope:              c.FlowConfig.Scope,
 		RequestedTokenType: c.FlowConfig.RequestedTokenType,
@@ -41,6 +43,7 @@ func (c *TokenExchangeFlow) createTokenRequest() *httpclient.TokenRequest {
 		c.Config.OIDC.ClientSecret,
 		c.Config.OIDC.AuthMethod,
 		&input)
+	setAuthorizationDetails(req, c.FlowConfig.AuthorizationDetails)
 
 	return req
 }
@@ -72,6 +75,12 @@ func (c *TokenExchangeFlow) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if c.FlowConfig.DPoP {
+		if err := c.Config.DPoPKeys.checkAccessTokenBinding(tokenData); err != nil {
+			return err
+		}
+	}
 
+	showAuthorizationDetails(c.Config.Runtime.Logger, tokenData)
 	return c.Config.Runtime.Logger.OutputJSON(tokenData)
 }
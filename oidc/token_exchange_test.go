@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -151,3 +152,48 @@ func TestTokenExchangeFlowRunDPoPWithoutKeys(t *testing.T) {
 		t.Errorf("output = %q, want empty on error", got)
 	}
 }
+
+func TestTokenFlowsSendResources(t *testing.T) {
+	t.Parallel()
+
+	resources := []string{"https://api.example.com", "https://other.example.com"}
+	tests := []struct {
+		name string
+		flow func(*Config) flowRunner
+	}{
+		{
+			name: "client credentials",
+			flow: func(c *Config) flowRunner {
+				return &ClientCredentialsFlow{Config: c, FlowConfig: &ClientCredentialsFlowConfig{Resources: resources}}
+			},
+		},
+		{
+			name: "token refresh",
+			flow: func(c *Config) flowRunner {
+				return &TokenRefreshFlow{Config: c, FlowConfig: &TokenRefreshFlowConfig{RefreshToken: "rt", Resources: resources}}
+			},
+		},
+		{
+			name: "token exchange",
+			flow: func(c *Config) flowRunner {
+				return &TokenExchangeFlow{Config: c, FlowConfig: &TokenExchangeFlowConfig{
+					SubjectToken:     "st",
+					SubjectTokenType: "urn:ietf:params:oauth:token-type:access_token",
+					Resources:        resources,
+				}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"abc123","token_type":"Bearer"}`))
+			if err := tt.flow(fixture.config).Run(context.Background()); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := fixture.onlyRequest(t).Form["resource"]; !slices.Equal(got, resources) {
+				t.Errorf("resource = %q, want %q", got, resources)
+			}
+		})
+	}
+}
@@ -16,12 +16,19 @@ type TokenRefreshFlowConfig struct {
 	Scope        string
 	RefreshToken string
 	DPoP         bool
+	// AuthorizationDetails is a JSON array of RFC 9396 authorization details.
+	AuthorizationDetails string
+	// Resources are RFC 8707 resource indicators of the APIs the token is
+	// for.
+	Resources []string
 }
 
 func (c *TokenRefreshFlow) Run(ctx context.Context) error {
 	client := c.Config.Runtime.Client
 
 	req := httpclient.CreateRefreshTokenRequest(c.Config.OIDC.ClientID, c.Config.OIDC.ClientSecret, c.Config.OIDC.AuthMethod, c.FlowConfig.RefreshToken, c.FlowConfig.Scope)
+	setAuthorizationDetails(req, c.FlowConfig.AuthorizationDetails)
+	req.Resources = c.FlowConfig.Resources
 
 	// Handle DPoP
 	if c.FlowConfig.DPoP {
@@ -37,6 +44,12 @@ func (c *TokenRefreshFlow) Run(ctx context.Context) error {
 	if err != nil {
 		return httpclient.WrapError(err, "token")
 	}
+	if c.FlowConfig.DPoP {
+		if err := c.Config.DPoPKeys.checkAccessTokenBinding(tokenData); err != nil {
+			return err
+		}
+	}
 
+	showAuthorizationDetails(c.Config.Runtime.Logger, tokenData)
 	return c.Config.Runtime.Logger.OutputJSON(tokenData)
 }
@@ -2,12 +2,14 @@ package oidc
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/jentz/oidc-cli/crypto/cryptotest"
 )
 
@@ -142,3 +144,29 @@ func TestTokenRefreshFlowRunDPoPWithoutKeys(t *testing.T) {
 		t.Errorf("output = %q, want empty on error", got)
 	}
 }
+
+// TestTokenRefreshFlowRunDPoPBindingMismatch pins that a JWT access token whose
+// cnf.jkt names another key is rejected instead of printed.
+func TestTokenRefreshFlowRunDPoPBindingMismatch(t *testing.T) {
+	t.Parallel()
+
+	accessToken := unsignedJWT(t, jwt.MapClaims{"cnf": map[string]any{"jkt": "someone-elses-key"}})
+	fixture := newReadyConfig(t, withDPoPKeys(),
+		withResponse(http.StatusOK, `{"access_token":"`+accessToken+`","token_type":"DPoP"}`))
+
+	flow := &TokenRefreshFlow{
+		Config: fixture.config,
+		FlowConfig: &TokenRefreshFlowConfig{
+			RefreshToken: "old-refresh",
+			DPoP:         true,
+		},
+	}
+
+	err := flow.Run(context.Background())
+	if !errors.Is(err, ErrDPoPBindingMismatch) {
+		t.Fatalf("Run() error = %v, want ErrDPoPBindingMismatch", err)
+	}
+	if got := fixture.output.String(); got != "" {
+		t.Errorf("output = %q, want empty on error", got)
+	}
+}
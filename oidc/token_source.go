@@ -0,0 +1,175 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// Grants a TokenSource can obtain access tokens with on its own.
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+// tokenExpiryLeeway is how long before its expires_in a token is treated as
+// expired, so it is not presented on a request that lands just after expiry.
+const tokenExpiryLeeway = 30 * time.Second
+
+// ErrNoTokenGrant is returned when a new access token is needed but the source
+// has no grant to obtain one with.
+var ErrNoTokenGrant = errors.New("no grant configured to obtain a new access token")
+
+// Token is an access token obtained from the token endpoint.
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	// Expiry is when the token expires, or zero when the server sent no
+	// expires_in.
+	Expiry time.Time
+}
+
+// Expired reports whether the token is expired, or about to be, at now.
+func (t *Token) Expired(now time.Time) bool {
+	return !t.Expiry.IsZero() && !now.Before(t.Expiry.Add(-tokenExpiryLeeway))
+}
+
+// TokenSource hands out the access token that calls to a protected resource
+// present, obtaining a new one with the configured grant when there is none
+// yet, when it expires, or when a resource server rejects it. It is safe for
+// concurrent use.
+type TokenSource struct {
+	Config *Config
+	// Grant is GrantClientCredentials or GrantRefreshToken; empty means the
+	// source only ever hands out AccessToken.
+	Grant string
+	Scope string
+	// AccessToken, when set, is handed out before any grant is used.
+	AccessToken string
+	// RefreshToken seeds the refresh_token grant. A rotated refresh token in a
+	// token response replaces it.
+	RefreshToken string
+	// DPoP requests DPoP-bound tokens, signed with Config.DPoPKeys.
+	DPoP bool
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// CanRefresh reports whether the source can obtain a new access token.
+func (s *TokenSource) CanRefresh() bool {
+	return s.Grant != ""
+}
+
+// Token returns the current access token, obtaining a new one when there is
+// none or it has expired.
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil && s.AccessToken != "" {
+		s.token = &Token{AccessToken: s.AccessToken, RefreshToken: s.RefreshToken}
+	}
+	if s.token != nil && !s.token.Expired(time.Now()) {
+		return s.token, nil
+	}
+	return s.fetchLocked(ctx)
+}
+
+// Refresh replaces a rejected token with a new one. When stale is no longer
+// the current token, another caller already replaced it and the current token
+// is returned without another token request.
+func (s *TokenSource) Refresh(ctx context.Context, stale *Token) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token != stale {
+		return s.token, nil
+	}
+	return s.fetchLocked(ctx)
+}
+
+// AccessTokenFunc adapts the source for httpclient.TokenTransport: a rejected
+// token is replaced, unless another request already replaced it.
+func (s *TokenSource) AccessTokenFunc() httpclient.AccessTokenFunc {
+	return func(ctx context.Context, rejected string) (string, error) {
+		token, err := s.Token(ctx)
+		if err == nil && rejected != "" && token.AccessToken == rejected {
+			token, err = s.Refresh(ctx, token)
+		}
+		if err != nil {
+			return "", err
+		}
+		return token.AccessToken, nil
+	}
+}
+
+// fetchLocked obtains a new token with the configured grant. The caller must
+// hold s.mu.
+func (s *TokenSource) fetchLocked(ctx context.Context) (*Token, error) {
+	oidcConf := s.Config.OIDC
+	refreshToken := s.RefreshToken
+	if s.token != nil && s.token.RefreshToken != "" {
+		refreshToken = s.token.RefreshToken
+	}
+
+	var req *httpclient.TokenRequest
+	switch s.Grant {
+	case GrantClientCredentials:
+		req = httpclient.CreateClientCredentialsRequest(oidcConf.ClientID, oidcConf.ClientSecret, oidcConf.AuthMethod, s.Scope)
+	case GrantRefreshToken:
+		req = httpclient.CreateRefreshTokenRequest(oidcConf.ClientID, oidcConf.ClientSecret, oidcConf.AuthMethod, refreshToken, s.Scope)
+	case "":
+		return nil, ErrNoTokenGrant
+	default:
+		return nil, fmt.Errorf("unsupported grant %q", s.Grant)
+	}
+	if s.DPoP {
+		req.DPoP = s.Config.DPoPKeys.ProofFunc()
+	}
+
+	resp, err := s.Config.Runtime.Client.ExecuteTokenRequest(ctx, oidcConf.TokenEndpoint, req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	tokenData, err := httpclient.ParseTokenResponse(resp)
+	if err != nil {
+		return nil, httpclient.WrapError(err, "token")
+	}
+	if s.DPoP {
+		if err := s.Config.DPoPKeys.checkAccessTokenBinding(tokenData); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := newToken(tokenData, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// Servers that do not rotate refresh tokens omit them from the response
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	s.token = token
+	return token, nil
+}
+
+// newToken reads a token response obtained at now.
+func newToken(tokenData map[string]any, now time.Time) (*Token, error) {
+	accessToken, ok := tokenData["access_token"].(string)
+	if !ok || accessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	token := &Token{AccessToken: accessToken}
+	token.TokenType, _ = tokenData["token_type"].(string)
+	token.RefreshToken, _ = tokenData["refresh_token"].(string)
+	if expiresIn, ok := tokenData["expires_in"].(float64); ok && expiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,148 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenSourceClientCredentialsCachesToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"cc-1","token_type":"Bearer","expires_in":3600}`))
+	source := &TokenSource{Config: fixture.config, Grant: GrantClientCredentials, Scope: "api"}
+
+	first, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	second, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+
+	if first.AccessToken != "cc-1" || second != first {
+		t.Errorf("Token() = %+v then %+v, want the same cc-1 token", first, second)
+	}
+	if first.Expiry.IsZero() {
+		t.Error("Expiry is zero, want it set from expires_in")
+	}
+	req := fixture.onlyRequest(t)
+	if got := req.Form.Get("grant_type"); got != "client_credentials" {
+		t.Errorf("grant_type = %q, want client_credentials", got)
+	}
+	if got := req.Form.Get("scope"); got != "api" {
+		t.Errorf("scope = %q, want api", got)
+	}
+}
+
+func TestTokenSourceRefreshUsesRotatedRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withRouteResponses(testTokenEndpoint,
+		cannedResponse{status: http.StatusOK, body: `{"access_token":"at-2","refresh_token":"rt-2"}`},
+		cannedResponse{status: http.StatusOK, body: `{"access_token":"at-3"}`},
+	))
+	source := &TokenSource{
+		Config:       fixture.config,
+		Grant:        GrantRefreshToken,
+		AccessToken:  "at-1",
+		RefreshToken: "rt-1",
+	}
+
+	token, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.AccessToken != "at-1" || len(fixture.requests) != 0 {
+		t.Fatalf("Token() = %q after %d requests, want the seeded at-1 without a request", token.AccessToken, len(fixture.requests))
+	}
+
+	for _, want := range []string{"at-2", "at-3"} {
+		token, err = source.Refresh(context.Background(), token)
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if token.AccessToken != want {
+			t.Errorf("Refresh() = %q, want %q", token.AccessToken, want)
+		}
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want 2", len(fixture.requests))
+	}
+	for i, want := range []string{"rt-1", "rt-2"} {
+		if got := fixture.requests[i].Form.Get("refresh_token"); got != want {
+			t.Errorf("request %d refresh_token = %q, want %q", i, got, want)
+		}
+	}
+	if token.RefreshToken != "rt-2" {
+		t.Errorf("RefreshToken = %q, want rt-2 kept when the server does not rotate it", token.RefreshToken)
+	}
+}
+
+func TestTokenSourceRefreshSkipsReplacedToken(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	source := &TokenSource{Config: fixture.config, Grant: GrantClientCredentials}
+
+	current, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	got, err := source.Refresh(context.Background(), &Token{AccessToken: "older"})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got != current {
+		t.Errorf("Refresh() = %+v, want the current token %+v", got, current)
+	}
+	fixture.onlyRequest(t)
+}
+
+func TestTokenSourceWithoutGrant(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t)
+	source := &TokenSource{Config: fixture.config, AccessToken: "static"}
+
+	token, err := source.Token(context.Background())
+	if err != nil || token.AccessToken != "static" {
+		t.Fatalf("Token() = %v, %v, want the static token", token, err)
+	}
+	if source.CanRefresh() {
+		t.Error("CanRefresh() = true, want false")
+	}
+	if _, err := source.Refresh(context.Background(), token); !errors.Is(err, ErrNoTokenGrant) {
+		t.Errorf("Refresh() error = %v, want %v", err, ErrNoTokenGrant)
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		expiry time.Time
+		want   bool
+	}{
+		{"no expiry", time.Time{}, false},
+		{"well before expiry", now.Add(time.Hour), false},
+		{"within leeway", now.Add(tokenExpiryLeeway / 2), true},
+		{"past expiry", now.Add(-time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			token := &Token{AccessToken: "at", Expiry: tt.expiry}
+			if got := token.Expired(now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+// UMAFlow obtains a requesting party token (RPT) with the UMA 2.0 permission
+// ticket grant. The ticket is given, or requested from the resource server by
+// calling the resource without a token.
+type UMAFlow struct {
+	Config     *Config
+	FlowConfig *UMAFlowConfig
+}
+
+type UMAFlowConfig struct {
+	Ticket string
+	// ResourceURL is called without a token to obtain a ticket when Ticket is
+	// empty.
+	ResourceURL      string
+	ClaimToken       string
+	ClaimTokenFormat string
+	PCT              string
+	// RPT is an existing RPT to upgrade with the permissions of the ticket.
+	RPT   string
+	Scope string
+}
+
+func (c *UMAFlow) Run(ctx context.Context) error {
+	ticket := c.FlowConfig.Ticket
+	if ticket == "" {
+		var err error
+		ticket, err = c.requestTicket(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	req := httpclient.CreateUMATicketTokenRequest(
+		c.Config.OIDC.ClientID,
+		c.Config.OIDC.ClientSecret,
+		c.Config.OIDC.tokenAuthMethod(),
+		&httpclient.UMATicketInput{
+			Ticket:           ticket,
+			ClaimToken:       c.FlowConfig.ClaimToken,
+			ClaimTokenFormat: c.FlowConfig.ClaimTokenFormat,
+			PCT:              c.FlowConfig.PCT,
+			RPT:              c.FlowConfig.RPT,
+			Scope:            c.FlowConfig.Scope,
+		},
+	)
+
+	return c.Config.requestTokens(ctx, req, false)
+}
+
+// requestTicket calls the resource without a token and returns the permission
+// ticket from the UMA challenge the resource server answers with.
+func (c *UMAFlow) requestTicket(ctx context.Context) (string, error) {
+	resp, err := c.Config.Runtime.Client.ExecuteResourceRequest(ctx, &httpclient.ResourceRequest{
+		Method: http.MethodGet,
+		URL:    c.FlowConfig.ResourceURL,
+	})
+	if err != nil {
+		return "", fmt.Errorf("resource request failed: %w", err)
+	}
+	challenge, ok := httpclient.UMAPermissionChallenge(resp)
+	if !ok {
+		if resp.IsSuccess() {
+			return "", errors.New("the resource granted access without a token, there is no permission ticket to exchange")
+		}
+		return "", fmt.Errorf("the resource answered %d without an UMA permission ticket", resp.StatusCode)
+	}
+
+	logger := c.Config.Runtime.Logger
+	logger.Printf("permission ticket: %s\n", challenge.Param("ticket"))
+	if asURI := challenge.Param("as_uri"); asURI != "" {
+		logger.Printf("authorization server: %s\n", asURI)
+	}
+	return challenge.Param("ticket"), nil
+}
@@ -0,0 +1,164 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/jentz/oidc-cli/httpclient"
+)
+
+const (
+	umaGrantType      = "urn:ietf:params:oauth:grant-type:uma-ticket"
+	testUMAResource   = "https://api.example.com/photos/1"
+	testIDTokenFormat = "http://openid.net/specs/openid-connect-core-1_0.html#IDToken"
+)
+
+func TestUMAFlowRunWithTicket(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusOK, `{"access_token":"rpt-2","token_type":"Bearer","upgraded":true}`))
+	flow := &UMAFlow{
+		Config: fixture.config,
+		FlowConfig: &UMAFlowConfig{
+			Ticket:           "ticket-1",
+			ClaimToken:       "id-token",
+			ClaimTokenFormat: testIDTokenFormat,
+			RPT:              "rpt-1",
+		},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if req.URL != testTokenEndpoint {
+		t.Errorf("url = %q, want %q", req.URL, testTokenEndpoint)
+	}
+	if got := req.Header.Get("Authorization"); got != basicAuthHeader() {
+		t.Errorf("Authorization = %q, want %q", got, basicAuthHeader())
+	}
+	wantForm := url.Values{
+		"grant_type":         {umaGrantType},
+		"ticket":             {"ticket-1"},
+		"claim_token":        {"id-token"},
+		"claim_token_format": {testIDTokenFormat},
+		"rpt":                {"rpt-1"},
+	}
+	if !reflect.DeepEqual(req.Form, wantForm) {
+		t.Errorf("form = %v, want %v", req.Form, wantForm)
+	}
+
+	wantOutput := `{
+  "access_token": "rpt-2",
+  "token_type": "Bearer",
+  "upgraded": true
+}
+`
+	if got := fixture.output.String(); got != wantOutput {
+		t.Errorf("output = %q, want %q", got, wantOutput)
+	}
+}
+
+func TestUMAFlowRunPublicClient(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withPublicClient(), withResponse(http.StatusOK, `{"access_token":"rpt-1","token_type":"Bearer"}`))
+	flow := &UMAFlow{Config: fixture.config, FlowConfig: &UMAFlowConfig{Ticket: "ticket-1"}}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	req := fixture.onlyRequest(t)
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want no header for a public client", got)
+	}
+	if got := req.Form.Get("client_id"); got != testClientID {
+		t.Errorf("client_id = %q, want %q in the body", got, testClientID)
+	}
+}
+
+func TestUMAFlowRunTicketFromResource(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t,
+		withRouteResponses(testUMAResource, cannedResponse{
+			status: http.StatusUnauthorized,
+			header: http.Header{"Www-Authenticate": {`UMA realm="photos", as_uri="https://op.example.com", ticket="ticket-from-rs"`}},
+		}),
+		withResponse(http.StatusOK, `{"access_token":"rpt-1","token_type":"Bearer"}`),
+	)
+	flow := &UMAFlow{
+		Config:     fixture.config,
+		FlowConfig: &UMAFlowConfig{ResourceURL: testUMAResource, Scope: "view"},
+	}
+
+	if err := flow.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fixture.requests) != 2 {
+		t.Fatalf("got %d emitted requests, want 2 (resource + token)", len(fixture.requests))
+	}
+	resourceReq, tokenReq := fixture.requests[0], fixture.requests[1]
+	if resourceReq.Method != http.MethodGet || resourceReq.URL != testUMAResource {
+		t.Errorf("resource request = %s %s, want GET %s", resourceReq.Method, resourceReq.URL, testUMAResource)
+	}
+	if got := resourceReq.Header.Get("Authorization"); got != "" {
+		t.Errorf("resource Authorization = %q, want an unauthenticated call", got)
+	}
+	wantForm := url.Values{
+		"grant_type": {umaGrantType},
+		"ticket":     {"ticket-from-rs"},
+		"scope":      {"view"},
+	}
+	if !reflect.DeepEqual(tokenReq.Form, wantForm) {
+		t.Errorf("token form = %v, want %v", tokenReq.Form, wantForm)
+	}
+}
+
+func TestUMAFlowRunResourceWithoutTicket(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		resp cannedResponse
+	}{
+		{"open resource", cannedResponse{status: http.StatusOK, body: "photo"}},
+		{"bearer challenge", cannedResponse{status: http.StatusUnauthorized, header: http.Header{"Www-Authenticate": {`Bearer realm="photos"`}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fixture := newReadyConfig(t, withRouteResponses(testUMAResource, tt.resp))
+			flow := &UMAFlow{Config: fixture.config, FlowConfig: &UMAFlowConfig{ResourceURL: testUMAResource}}
+			if err := flow.Run(context.Background()); err == nil {
+				t.Fatal("Run() error = nil, want an error without a ticket")
+			}
+			if len(fixture.requests) != 1 {
+				t.Errorf("got %d emitted requests, want only the resource request", len(fixture.requests))
+			}
+		})
+	}
+}
+
+func TestUMAFlowRunRequestSubmitted(t *testing.T) {
+	t.Parallel()
+
+	fixture := newReadyConfig(t, withResponse(http.StatusForbidden, `{"error":"request_submitted","ticket":"ticket-2","interval":5}`))
+	flow := &UMAFlow{Config: fixture.config, FlowConfig: &UMAFlowConfig{Ticket: "ticket-1"}}
+
+	err := flow.Run(context.Background())
+	var oauthErr *httpclient.Error
+	if !errors.As(err, &oauthErr) || oauthErr.ErrorType != "request_submitted" {
+		t.Errorf("Run() error = %v, want request_submitted", err)
+	}
+	if fixture.output.Len() != 0 {
+		t.Errorf("output = %q, want none", fixture.output)
+	}
+}
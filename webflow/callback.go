@@ -3,6 +3,7 @@ package webflow
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"embed"
 	"errors"
 	"fmt"
@@ -25,17 +26,42 @@ type CallbackServer struct {
 	response chan *CallbackResponse
 	// listen creates the server's network listener; NewCallbackServer falls
 	// back to net.Listen when WithListenFunc is not given. Override for tests.
-	listen      func(network, addr string) (net.Listener, error)
+	listen func(network, addr string) (net.Listener, error)
+	// logout marks the redirect as the post_logout_redirect_uri of
+	// RP-initiated logout, which carries no code.
+	logout bool
+	// fragmentRelay answers a redirect without parameters with relayTmpl,
+	// which posts the URL fragment of a fragment response mode back.
+	fragmentRelay bool
+	// decodeJARM unpacks a JWT-secured authorization response; nil means the
+	// parameters arrive in plain form.
+	decodeJARM JARMDecoder
+	// certificate serves an https callback URI over TLS.
+	certificate *tls.Certificate
+	relayTmpl   *template.Template
 	successTmpl *template.Template
 	errorTmpl   *template.Template
 	logger      *log.Logger
 }
 
+// JARMDecoder verifies the response JWT of a JWT-secured authorization
+// response (JARM) and returns the authorization response parameters it holds.
+type JARMDecoder func(ctx context.Context, response string) (url.Values, error)
+
 type CallbackResponse struct {
-	Code             string
-	State            string
+	Code  string
+	State string
+	// Issuer is the iss parameter of an RFC 9207 authorization response.
+	Issuer           string
 	ErrorMsg         string
 	ErrorDescription string
+	// The implicit and hybrid response types return tokens from the
+	// authorization endpoint as well.
+	IDToken     string
+	AccessToken string
+	TokenType   string
+	ExpiresIn   string
+	Scope       string
 }
 
 // Option configures a CallbackServer at construction time.
@@ -51,6 +77,41 @@ func WithListenFunc(fn func(network, addr string) (net.Listener, error)) Option
 	}
 }
 
+// WithLogout configures the server to receive the post_logout_redirect_uri of
+// RP-initiated logout: a redirect without an error is a success and confirms
+// the logout rather than a login.
+func WithLogout() Option {
+	return func(s *CallbackServer) {
+		s.logout = true
+	}
+}
+
+// WithJARM configures the server to receive JWT-secured authorization
+// responses: the code, state and error are read from the response parameter,
+// which decode verifies before the result page is chosen.
+func WithJARM(decode JARMDecoder) Option {
+	return func(s *CallbackServer) {
+		s.decodeJARM = decode
+	}
+}
+
+// WithFragmentRelay configures the server for the fragment response modes:
+// the browser keeps the fragment to itself, so a redirect without parameters
+// is answered with a page that posts the fragment back.
+func WithFragmentRelay() Option {
+	return func(s *CallbackServer) {
+		s.fragmentRelay = true
+	}
+}
+
+// WithTLS configures the server to serve an https callback URI over TLS with
+// cert.
+func WithTLS(cert tls.Certificate) Option {
+	return func(s *CallbackServer) {
+		s.certificate = &cert
+	}
+}
+
 func NewCallbackServer(callbackURI string, logger *log.Logger, opts ...Option) (*CallbackServer, error) {
 	u, err := url.Parse(callbackURI)
 	if err != nil {
@@ -91,6 +152,24 @@ func NewCallbackServer(callbackURI string, logger *log.Logger, opts ...Option) (
 		s.listen = net.Listen
 	}
 
+	if u.Scheme == "https" && s.certificate == nil {
+		return nil, errors.New("an https callback URI needs a TLS certificate")
+	}
+
+	if s.logout {
+		s.successTmpl, err = template.ParseFS(content, "html/logout-success.html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse logout template: %w", err)
+		}
+	}
+
+	if s.fragmentRelay {
+		s.relayTmpl, err = template.ParseFS(content, "html/callback-relay.html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse relay template: %w", err)
+		}
+	}
+
 	return s, nil
 }
 
@@ -109,6 +188,12 @@ func (s *CallbackServer) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", s.host, err)
 	}
+	if s.certificate != nil {
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{*s.certificate},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
 
 	// Channel to catch server errors
 	errChan := make(chan error, 1)
@@ -144,16 +229,31 @@ func (s *CallbackServer) WaitForCallback(ctx context.Context) (*CallbackResponse
 }
 
 func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
-	var resp CallbackResponse
 	var tmpl *template.Template
 	var status int
 
-	resp.Code = r.URL.Query().Get("code")
-	resp.State = r.URL.Query().Get("state")
-	resp.ErrorMsg = r.URL.Query().Get("error")
-	resp.ErrorDescription = r.URL.Query().Get("error_description")
+	if s.relayTmpl != nil && r.Method == http.MethodGet && r.URL.RawQuery == "" {
+		s.serveRelay(w)
+		return
+	}
+
+	// A form_post response mode, like the fragment relay page, delivers the
+	// parameters in the POST body.
+	params := r.URL.Query()
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			s.logger.Errorf("failed to parse callback form: %v", err)
+		}
+		params = r.PostForm
+	}
+	resp := ParseCallbackResponse(r.Context(), params, s.decodeJARM)
 
-	if resp.Code == "" {
+	failed := resp.Code == "" && resp.IDToken == "" && resp.AccessToken == ""
+	if s.logout {
+		failed = resp.ErrorMsg != ""
+	}
+
+	if failed {
 		tmpl = s.errorTmpl
 		status = http.StatusBadRequest
 	} else {
@@ -174,8 +274,62 @@ func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request)
 	}
 
 	select {
-	case s.response <- &resp:
+	case s.response <- resp:
 	default:
 		s.logger.Errorf("callback response channel is full, dropping response")
 	}
 }
+
+// ParseCallbackResponse reads the authorization response parameters of a
+// redirect. When decode is set the parameters are taken from the response
+// JWT, and a missing or invalid one is reported as an invalid_request error.
+func ParseCallbackResponse(ctx context.Context, params url.Values, decode JARMDecoder) *CallbackResponse {
+	if decode != nil {
+		params = jarmParams(ctx, params, decode)
+	}
+	return &CallbackResponse{
+		Code:             params.Get("code"),
+		State:            params.Get("state"),
+		Issuer:           params.Get("iss"),
+		ErrorMsg:         params.Get("error"),
+		ErrorDescription: params.Get("error_description"),
+		IDToken:          params.Get("id_token"),
+		AccessToken:      params.Get("access_token"),
+		TokenType:        params.Get("token_type"),
+		ExpiresIn:        params.Get("expires_in"),
+		Scope:            params.Get("scope"),
+	}
+}
+
+// jarmParams decodes the response parameter.
+func jarmParams(ctx context.Context, params url.Values, decode JARMDecoder) url.Values {
+	response := params.Get("response")
+	if response == "" {
+		// The provider may fail to produce a response JWT at all, in which
+		// case it falls back to plain error parameters.
+		if params.Has("error") {
+			return params
+		}
+		return url.Values{"error": {"invalid_request"}, "error_description": {"response parameter is missing"}}
+	}
+	decoded, err := decode(ctx, response)
+	if err != nil {
+		return url.Values{"error": {"invalid_request"}, "error_description": {err.Error()}}
+	}
+	return decoded
+}
+
+// serveRelay answers a fragment response mode redirect with the page that
+// posts the fragment back to the callback path.
+func (s *CallbackServer) serveRelay(w http.ResponseWriter) {
+	var buf bytes.Buffer
+	if err := s.relayTmpl.Execute(&buf, s.path); err != nil {
+		s.logger.Errorf("failed to execute template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := buf.WriteTo(w); err != nil {
+		s.logger.Errorf("failed to write response: %v", err)
+	}
+}
@@ -3,17 +3,21 @@ package webflow
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"html/template"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/jentz/oidc-cli/crypto"
 	"github.com/jentz/oidc-cli/log"
 )
 
@@ -198,6 +202,69 @@ func TestCallbackServerStartWithInjectedListener(t *testing.T) {
 	}
 }
 
+func TestCallbackServerStartTLS(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := crypto.GenerateSelfSignedCertificate([]string{"127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("generating certificate: %v", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("loading certificate: %v", err)
+	}
+	if _, err := NewCallbackServer("https://127.0.0.1/callback", nil); err == nil {
+		t.Error("NewCallbackServer() without a certificate error = nil, want an error")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to pre-bind listener: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	s, err := NewCallbackServer("https://127.0.0.1/callback", nil,
+		WithListenFunc(func(_, _ string) (net.Listener, error) { return ln, nil }),
+		WithTLS(cert))
+	if err != nil {
+		t.Fatalf("NewCallbackServer failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Start(ctx) }()
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(certPEM) {
+		t.Fatal("failed to trust the certificate")
+	}
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/callback?code=abc123&state=xyz", ln.Addr()), http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	httpResp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("callback request failed: %v", err)
+	}
+	_ = httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, httpResp.StatusCode)
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	resp, err := s.WaitForCallback(waitCtx)
+	if err != nil {
+		t.Fatalf("WaitForCallback failed: %v", err)
+	}
+	if resp.Code != "abc123" {
+		t.Errorf("expected code=abc123, got %q", resp.Code)
+	}
+}
+
 func TestCallbackServerStartListenError(t *testing.T) {
 	t.Parallel()
 	s, err := NewCallbackServer("http://localhost:8080/callback", nil)
@@ -250,6 +317,9 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 	tests := []struct {
 		name           string
 		query          string
+		logout         bool
+		jarm           JARMDecoder
+		form           string // POST body, sent instead of a GET when set
 		successTmpl    *template.Template
 		errorTmpl      *template.Template
 		wantStatus     int
@@ -275,6 +345,39 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 			wantBody:     "<p>Success: abc123</p>",
 			wantResponse: &CallbackResponse{Code: "abc123", State: "test-state-123"},
 		},
+		{
+			name:         "Success callback with issuer",
+			query:        "code=abc123&state=test-state-123&iss=https%3A%2F%2Fissuer.example.com",
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusOK,
+			wantBody:     "<p>Success: abc123</p>",
+			wantResponse: &CallbackResponse{Code: "abc123", State: "test-state-123", Issuer: "https://issuer.example.com"},
+		},
+		{
+			name:         "Form post callback",
+			form:         "code=abc123&state=test-state-123",
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusOK,
+			wantBody:     "<p>Success: abc123</p>",
+			wantResponse: &CallbackResponse{Code: "abc123", State: "test-state-123"},
+		},
+		{
+			name:        "Implicit callback",
+			form:        "id_token=eyJ.id.token&access_token=at-123&token_type=Bearer&expires_in=3600&state=test-state-123",
+			successTmpl: template.Must(template.New("success").Parse("<p>Success</p>")),
+			errorTmpl:   template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:  http.StatusOK,
+			wantBody:    "<p>Success</p>",
+			wantResponse: &CallbackResponse{
+				State:       "test-state-123",
+				IDToken:     "eyJ.id.token",
+				AccessToken: "at-123",
+				TokenType:   "Bearer",
+				ExpiresIn:   "3600",
+			},
+		},
 		{
 			name:         "Error callback",
 			query:        "error=invalid_grant&error_description=Bad+request",
@@ -284,6 +387,76 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 			wantBody:     "<p>Error: invalid_grant - Bad request</p>",
 			wantResponse: &CallbackResponse{ErrorMsg: "invalid_grant", ErrorDescription: "Bad request"},
 		},
+		{
+			name:         "Logout callback",
+			query:        "state=test-state-123",
+			logout:       true,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Logged out</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusOK,
+			wantBody:     "<p>Logged out</p>",
+			wantResponse: &CallbackResponse{State: "test-state-123"},
+		},
+		{
+			name:         "Logout error callback",
+			query:        "error=access_denied&state=test-state-123",
+			logout:       true,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Logged out</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}}</p>")),
+			wantStatus:   http.StatusBadRequest,
+			wantBody:     "<p>Error: access_denied</p>",
+			wantResponse: &CallbackResponse{State: "test-state-123", ErrorMsg: "access_denied"},
+		},
+		{
+			name:         "JARM callback",
+			query:        "response=signed.response.jwt",
+			jarm:         decodeTestJARM,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusOK,
+			wantBody:     "<p>Success: abc123</p>",
+			wantResponse: &CallbackResponse{Code: "abc123", State: "test-state-123"},
+		},
+		{
+			name:         "JARM form_post callback",
+			form:         "response=signed.response.jwt",
+			jarm:         decodeTestJARM,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusOK,
+			wantBody:     "<p>Success: abc123</p>",
+			wantResponse: &CallbackResponse{Code: "abc123", State: "test-state-123"},
+		},
+		{
+			name:         "JARM callback with an invalid response",
+			query:        "response=forged.response.jwt",
+			jarm:         decodeTestJARM,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusBadRequest,
+			wantBody:     "<p>Error: invalid_request - signature is invalid</p>",
+			wantResponse: &CallbackResponse{ErrorMsg: "invalid_request", ErrorDescription: "signature is invalid"},
+		},
+		{
+			name:         "JARM callback ignores plain parameters",
+			query:        "code=abc123&state=test-state-123",
+			jarm:         decodeTestJARM,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}} - {{.ErrorDescription}}</p>")),
+			wantStatus:   http.StatusBadRequest,
+			wantBody:     "<p>Error: invalid_request - response parameter is missing</p>",
+			wantResponse: &CallbackResponse{ErrorMsg: "invalid_request", ErrorDescription: "response parameter is missing"},
+		},
+		{
+			name:         "JARM callback with a plain error",
+			query:        "error=server_error",
+			jarm:         decodeTestJARM,
+			successTmpl:  template.Must(template.New("success").Parse("<p>Success: {{.Code}}</p>")),
+			errorTmpl:    template.Must(template.New("error").Parse("<p>Error: {{.ErrorMsg}}</p>")),
+			wantStatus:   http.StatusBadRequest,
+			wantBody:     "<p>Error: server_error</p>",
+			wantResponse: &CallbackResponse{ErrorMsg: "server_error"},
+		},
 		{
 			name:           "Template execution error",
 			query:          "code=abc123",
@@ -311,7 +484,14 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 			var logBuf bytes.Buffer
 			logger := log.New(log.WithVerbose(true), log.WithOutput(&logBuf, &logBuf))
 
-			s, err := NewCallbackServer("http://localhost:8080/callback", logger)
+			var opts []Option
+			if tt.logout {
+				opts = append(opts, WithLogout())
+			}
+			if tt.jarm != nil {
+				opts = append(opts, WithJARM(tt.jarm))
+			}
+			s, err := NewCallbackServer("http://localhost:8080/callback", logger, opts...)
 			if err != nil {
 				t.Skipf("Skipping due to template parsing error: %v", err)
 			}
@@ -325,6 +505,10 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest(http.MethodGet, "/callback?"+tt.query, http.NoBody)
+			if tt.form != "" {
+				r = httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(tt.form))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
 			s.handleCallback(w, r)
 
 			if w.Code != tt.wantStatus {
@@ -337,10 +521,7 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 			if tt.name != "Template execution error" && tt.name != "Channel full" {
 				select {
 				case got := <-s.response:
-					if got.Code != tt.wantResponse.Code ||
-						got.State != tt.wantResponse.State ||
-						got.ErrorMsg != tt.wantResponse.ErrorMsg ||
-						got.ErrorDescription != tt.wantResponse.ErrorDescription {
+					if *got != *tt.wantResponse {
 						t.Errorf("expected response %v, got %v", tt.wantResponse, got)
 					}
 				default:
@@ -359,3 +540,56 @@ func TestCallbackServerHandleCallback(t *testing.T) {
 		})
 	}
 }
+
+func TestCallbackServerFragmentRelay(t *testing.T) {
+	t.Parallel()
+	s, err := NewCallbackServer("http://localhost:8080/callback", nil, WithFragmentRelay())
+	if err != nil {
+		t.Fatalf("NewCallbackServer() error = %v", err)
+	}
+
+	// The browser drops the fragment, so the redirect arrives bare and is
+	// answered with the relay page rather than treated as a response.
+	w := httptest.NewRecorder()
+	s.handleCallback(w, httptest.NewRequest(http.MethodGet, "/callback", http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Errorf("relay status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `action="/callback"`) || !strings.Contains(body, "location.hash") {
+		t.Errorf("relay body = %q, want a page posting the fragment to /callback", body)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	select {
+	case got := <-s.response:
+		t.Fatalf("got response %v from the relay request, want none", got)
+	default:
+	}
+
+	// The relay page posts the fragment parameters back.
+	r := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader("code=abc123&state=test-state-123"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	s.handleCallback(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("relayed status = %d, want %d", w.Code, http.StatusOK)
+	}
+	select {
+	case got := <-s.response:
+		if got.Code != "abc123" || got.State != "test-state-123" {
+			t.Errorf("response = %v, want code abc123 and state test-state-123", got)
+		}
+	default:
+		t.Error("expected response in channel, got none")
+	}
+}
+
+// decodeTestJARM stands in for response JWT verification, accepting only
+// "signed.response.jwt".
+func decodeTestJARM(_ context.Context, response string) (url.Values, error) {
+	if response != "signed.response.jwt" {
+		return nil, errors.New("signature is invalid")
+	}
+	return url.Values{"code": {"abc123"}, "state": {"test-state-123"}}, nil
+}
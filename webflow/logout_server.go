@@ -0,0 +1,193 @@
+package webflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jentz/oidc-cli/log"
+)
+
+const (
+	// BackChannel and FrontChannel name the logout mechanism an event arrived
+	// through.
+	BackChannel  = "back-channel"
+	FrontChannel = "front-channel"
+)
+
+// LogoutServer receives the logout notifications an OP sends to an RP: OpenID
+// Connect Back-Channel Logout POSTs carrying a logout_token and Front-Channel
+// Logout GETs carrying iss and sid, both on the same path. Each notification
+// is reported as a LogoutEvent.
+type LogoutServer struct {
+	host   string
+	path   string
+	server *http.Server
+	events chan *LogoutEvent
+	// listen creates the server's network listener; NewLogoutServer falls back
+	// to net.Listen when WithLogoutListenFunc is not given. Override for tests.
+	listen func(network, addr string) (net.Listener, error)
+	verify LogoutTokenVerifier
+	logger *log.Logger
+}
+
+// LogoutTokenVerifier validates a back-channel logout token and returns its
+// claims. An error rejects the logout request.
+type LogoutTokenVerifier func(ctx context.Context, logoutToken string) (map[string]any, error)
+
+// LogoutEvent is one logout notification. Error is set when the notification
+// was rejected.
+type LogoutEvent struct {
+	Channel   string         `json:"channel"`
+	Issuer    string         `json:"iss,omitempty"`
+	SessionID string         `json:"sid,omitempty"`
+	Subject   string         `json:"sub,omitempty"`
+	Claims    map[string]any `json:"claims,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// LogoutServerOption configures a LogoutServer at construction time.
+type LogoutServerOption func(*LogoutServer)
+
+// WithLogoutListenFunc overrides the function the server uses to create its
+// network listener, as WithListenFunc does for the CallbackServer.
+func WithLogoutListenFunc(fn func(network, addr string) (net.Listener, error)) LogoutServerOption {
+	return func(s *LogoutServer) {
+		s.listen = fn
+	}
+}
+
+func NewLogoutServer(listenURI string, logger *log.Logger, verify LogoutTokenVerifier, opts ...LogoutServerOption) (*LogoutServer, error) {
+	u, err := url.Parse(listenURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen URI: %w", err)
+	}
+	if verify == nil {
+		return nil, errors.New("logout token verifier is required")
+	}
+
+	if logger == nil {
+		logger = log.Discard()
+	}
+
+	s := &LogoutServer{
+		host:   u.Host,
+		path:   u.Path,
+		events: make(chan *LogoutEvent, 16),
+		verify: verify,
+		logger: logger,
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(s)
+	}
+
+	if s.listen == nil {
+		s.listen = net.Listen
+	}
+
+	return s, nil
+}
+
+// Events returns the channel each logout notification is reported on.
+func (s *LogoutServer) Events() <-chan *LogoutEvent {
+	return s.events
+}
+
+func (s *LogoutServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+s.path, s.handleBackChannel)
+	mux.HandleFunc("GET "+s.path, s.handleFrontChannel)
+
+	s.server = &http.Server{
+		Addr:        s.host,
+		Handler:     mux,
+		ReadTimeout: 10 * time.Second,
+	}
+
+	listener, err := s.listen("tcp", s.host)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.host, err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.server.Serve(listener)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return s.server.Shutdown(shutdownCtx) //nolint:contextcheck // parent ctx is already cancelled; a fresh bounded context is deliberate
+	case err := <-errChan:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// handleBackChannel validates the logout token and answers as the OpenID
+// Connect Back-Channel Logout spec requires: 200 on success, 400 with an
+// OAuth error body otherwise (§2.8).
+func (s *LogoutServer) handleBackChannel(w http.ResponseWriter, r *http.Request) {
+	event := &LogoutEvent{Channel: BackChannel}
+	w.Header().Set("Cache-Control", "no-store")
+
+	logoutToken := r.PostFormValue("logout_token")
+	if logoutToken == "" {
+		event.Error = "logout_token is required"
+	} else if claims, err := s.verify(r.Context(), logoutToken); err != nil {
+		event.Error = err.Error()
+	} else {
+		event.Claims = claims
+		event.Issuer, _ = claims["iss"].(string)
+		event.SessionID, _ = claims["sid"].(string)
+		event.Subject, _ = claims["sub"].(string)
+	}
+
+	// Report the event before answering, so it is queued by the time the OP
+	// sees the response.
+	s.report(r.Context(), event)
+
+	if event.Error == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	body := map[string]string{"error": "invalid_request", "error_description": event.Error}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Errorf("failed to write response: %v", err)
+	}
+}
+
+// handleFrontChannel records the iss and sid the OP loaded the logout URI
+// with. The page is blank, as it is rendered in a hidden iframe.
+func (s *LogoutServer) handleFrontChannel(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.report(r.Context(), &LogoutEvent{
+		Channel:   FrontChannel,
+		Issuer:    query.Get("iss"),
+		SessionID: query.Get("sid"),
+	})
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *LogoutServer) report(ctx context.Context, event *LogoutEvent) {
+	select {
+	case s.events <- event:
+	case <-ctx.Done():
+		s.logger.Errorf("dropping %s logout event: %v", event.Channel, ctx.Err())
+	}
+}
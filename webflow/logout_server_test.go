@@ -0,0 +1,182 @@
+package webflow
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogoutServer(t *testing.T) {
+	t.Parallel()
+	verify := func(context.Context, string) (map[string]any, error) { return nil, nil }
+
+	s, err := NewLogoutServer("http://localhost:9555/logout", nil, verify)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.host != "localhost:9555" || s.path != "/logout" {
+		t.Errorf("expected host=localhost:9555 path=/logout, got host=%q path=%q", s.host, s.path)
+	}
+	if s.listen == nil {
+		t.Error("Listen function not initialized")
+	}
+
+	if _, err := NewLogoutServer("http://localhost:9555/logout", nil, nil); err == nil {
+		t.Error("expected an error without a verifier")
+	}
+}
+
+func TestLogoutServerHandlers(t *testing.T) {
+	t.Parallel()
+
+	verify := func(_ context.Context, token string) (map[string]any, error) {
+		if token != "valid-token" {
+			return nil, errors.New("bad signature")
+		}
+		return map[string]any{"iss": "https://op.example.com", "sid": "sid-1", "sub": "alice"}, nil
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		form       url.Values
+		wantStatus int
+		wantBody   string
+		wantEvent  *LogoutEvent
+	}{
+		{
+			name:       "valid back-channel logout",
+			method:     http.MethodPost,
+			target:     "/logout",
+			form:       url.Values{"logout_token": {"valid-token"}},
+			wantStatus: http.StatusOK,
+			wantEvent: &LogoutEvent{
+				Channel:   BackChannel,
+				Issuer:    "https://op.example.com",
+				SessionID: "sid-1",
+				Subject:   "alice",
+				Claims:    map[string]any{"iss": "https://op.example.com", "sid": "sid-1", "sub": "alice"},
+			},
+		},
+		{
+			name:       "invalid back-channel logout token",
+			method:     http.MethodPost,
+			target:     "/logout",
+			form:       url.Values{"logout_token": {"forged"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"bad signature"}`,
+			wantEvent:  &LogoutEvent{Channel: BackChannel, Error: "bad signature"},
+		},
+		{
+			name:       "missing logout token",
+			method:     http.MethodPost,
+			target:     "/logout",
+			form:       url.Values{},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid_request","error_description":"logout_token is required"}`,
+			wantEvent:  &LogoutEvent{Channel: BackChannel, Error: "logout_token is required"},
+		},
+		{
+			name:       "front-channel logout",
+			method:     http.MethodGet,
+			target:     "/logout?iss=https%3A%2F%2Fop.example.com&sid=sid-1",
+			wantStatus: http.StatusOK,
+			wantEvent:  &LogoutEvent{Channel: FrontChannel, Issuer: "https://op.example.com", SessionID: "sid-1"},
+		},
+		{
+			name:       "other methods are not allowed",
+			method:     http.MethodPut,
+			target:     "/logout",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := NewLogoutServer("http://localhost:9555/logout", nil, verify)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST "+s.path, s.handleBackChannel)
+			mux.HandleFunc("GET "+s.path, s.handleFrontChannel)
+
+			r := httptest.NewRequest(tt.method, tt.target, http.NoBody)
+			if tt.form != nil {
+				r = httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantBody != "" {
+				if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+					t.Errorf("expected body %q, got %q", tt.wantBody, got)
+				}
+			}
+
+			select {
+			case got := <-s.Events():
+				if tt.wantEvent == nil {
+					t.Errorf("expected no event, got %+v", got)
+				} else if !reflect.DeepEqual(got, tt.wantEvent) {
+					t.Errorf("expected event %+v, got %+v", tt.wantEvent, got)
+				}
+			default:
+				if tt.wantEvent != nil {
+					t.Error("expected an event, got none")
+				}
+			}
+		})
+	}
+}
+
+func TestLogoutServerStartWithInjectedListener(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("binding loopback listener: %v", err)
+	}
+	verify := func(context.Context, string) (map[string]any, error) { return nil, nil }
+	s, err := NewLogoutServer("http://localhost/logout", nil, verify,
+		WithLogoutListenFunc(func(_, _ string) (net.Listener, error) { return ln, nil }))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error, 1)
+	go func() { errChan <- s.Start(ctx) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/logout?sid=sid-1") //nolint:noctx // test-local loopback request
+	if err != nil {
+		t.Fatalf("front-channel request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	select {
+	case event := <-s.Events():
+		if event.SessionID != "sid-1" {
+			t.Errorf("sid = %q, want sid-1", event.SessionID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+
+	cancel()
+	if err := <-errChan; err != nil {
+		t.Errorf("Start() error = %v", err)
+	}
+}